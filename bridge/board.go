@@ -0,0 +1,363 @@
+// Copyright 2014 Krishna Raman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Shared pin state for code driving a FirmataClient from several places at
+// once, such as network services and components. A Board tracks the mode and
+// last value of every pin, validates requests against the board's
+// capabilities and fans value changes out to any number of subscribers.
+//
+// Usage:
+//
+//	b := bridge.New(arduino)
+//	b.SetMode(13, firmata.Output)
+//	b.Write(13, 1)
+//	events, cancel := b.Subscribe(2, 14)
+package bridge
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/kraman/go-firmata"
+)
+
+var (
+	// Pin number out of range
+	ErrNoSuchPin = errors.New("no such pin")
+	// Request not allowed by the board's capabilities or the pin's mode
+	ErrInvalid = errors.New("invalid request")
+)
+
+// Mode, value and capabilities of a pin
+type PinState struct {
+	Pin  int             `json:"pin"`
+	Mode firmata.PinMode `json:"mode"`
+	// Level of digital pins, raw reading of analog inputs or last written
+	// PWM duty cycle or servo angle
+	Value int `json:"value"`
+	// Filtered reading of analog inputs with filters, see SetFilters
+	Reading *float64 `json:"reading,omitempty"`
+	// Supported modes and their resolution in bits
+	Modes map[firmata.PinMode]byte `json:"modes"`
+	// Analog channel of the pin, or -1 if it has none
+	AnalogChannel int `json:"analogChannel"`
+}
+
+// Filtered reading if the pin has one, otherwise the value
+func (s PinState) Conditioned() float64 {
+	if s.Reading != nil {
+		return *s.Reading
+	}
+	return float64(s.Value)
+}
+
+// Change of a pin's value
+type PinEvent struct {
+	Pin   int             `json:"pin"`
+	Mode  firmata.PinMode `json:"mode"`
+	Value int             `json:"value"`
+	// Filtered reading of analog inputs with filters
+	Reading *float64 `json:"reading,omitempty"`
+	// Direction of a digital input change. Not set for other pins or for
+	// the first report after a mode change.
+	Edge Edge `json:"edge,omitempty"`
+	// Host time the change was received
+	Time time.Time `json:"time"`
+}
+
+// Filtered reading if the event has one, otherwise the value
+func (e PinEvent) Conditioned() float64 {
+	if e.Reading != nil {
+		return *e.Reading
+	}
+	return float64(e.Value)
+}
+
+// Pin state tracking and event fan-out for a FirmataClient
+type Board struct {
+	Log *slog.Logger
+
+	client *firmata.FirmataClient
+
+	lock sync.Mutex
+	pins []PinState
+	// pins whose value has not been reported since their mode changed
+	stale map[int]bool
+	// last report of each digital port
+	ports    map[byte]byte
+	debounce map[int]time.Duration
+	pending  map[int]*pendingLevel
+	filters  map[int][]Filter
+	subs     map[*subscription]struct{}
+	closed   bool
+}
+
+type subscription struct {
+	pins   map[int]bool
+	events chan PinEvent
+}
+
+// Start tracking the pins of c. The Board takes over the client's value
+// channel; nothing else may read from GetValues() afterwards. Pins start out
+// in their power-on modes, with reporting enabled for analog inputs.
+func New(c *firmata.FirmataClient) *Board {
+	b := &Board{
+		Log:      c.Log,
+		client:   c,
+		stale:    make(map[int]bool),
+		ports:    make(map[byte]byte),
+		debounce: make(map[int]time.Duration),
+		pending:  make(map[int]*pendingLevel),
+		filters:  make(map[int][]Filter),
+		subs:     make(map[*subscription]struct{}),
+	}
+	for pin := 0; pin < c.PinCount(); pin++ {
+		caps, _ := c.PinCapabilities(uint8(pin))
+		state := PinState{Pin: pin, Modes: caps, AnalogChannel: -1}
+		if ch, ok := c.AnalogChannel(uint8(pin)); ok {
+			state.AnalogChannel = int(ch)
+		}
+		_, output := caps[firmata.Output]
+		switch {
+		case state.AnalogChannel >= 0:
+			state.Mode = firmata.Analog
+			c.EnableAnalogInput(uint(pin), true)
+		case output:
+			state.Mode = firmata.Output
+		default:
+			state.Mode = firmata.Ignore
+		}
+		b.pins = append(b.pins, state)
+	}
+	go b.readValues()
+	return b
+}
+
+// Underlying client, for features the Board does not cover
+func (b *Board) Client() *firmata.FirmataClient {
+	return b.client
+}
+
+// Get the state of all pins
+func (b *Board) Pins() []PinState {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	pins := make([]PinState, len(b.pins))
+	copy(pins, b.pins)
+	return pins
+}
+
+// Get the state of a pin
+func (b *Board) Pin(pin int) (state PinState, err error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if pin < 0 || pin >= len(b.pins) {
+		err = fmt.Errorf("Pin %v: %w", pin, ErrNoSuchPin)
+		return
+	}
+	state = b.pins[pin]
+	return
+}
+
+// Set the mode of a pin. Reporting is enabled for input modes so the pin's
+// value stays current.
+func (b *Board) SetMode(pin int, mode firmata.PinMode) error {
+	state, err := b.Pin(pin)
+	if err != nil {
+		return err
+	}
+	if _, ok := state.Modes[mode]; !ok {
+		return fmt.Errorf("Pin %v does not support mode %v: %w", pin, mode, ErrInvalid)
+	}
+	if err = b.client.SetPinMode(uint8(pin), mode); err != nil {
+		return err
+	}
+
+	b.lock.Lock()
+	b.pins[pin].Mode = mode
+	b.pins[pin].Value = 0
+	b.pins[pin].Reading = nil
+	b.stale[pin] = true
+	b.dropPending(pin)
+	b.lock.Unlock()
+
+	switch mode {
+	case firmata.Analog:
+		err = b.client.EnableAnalogInput(uint(pin), true)
+	case firmata.Input, firmata.InputPullup:
+		err = b.client.EnableDigitalInput(uint(pin), true)
+	default:
+		if state.Mode == firmata.Analog {
+			err = b.client.EnableAnalogInput(uint(pin), false)
+		}
+	}
+	return err
+}
+
+// Get the current raw value of a pin
+func (b *Board) Read(pin int) (int, error) {
+	state, err := b.Pin(pin)
+	return state.Value, err
+}
+
+// Get the conditioned value of a pin: the filtered reading of analog inputs
+// with filters, otherwise the raw value
+func (b *Board) Reading(pin int) (float64, error) {
+	state, err := b.Pin(pin)
+	return state.Conditioned(), err
+}
+
+// Write a value to an output pin. OUTPUT pins take 0 or 1, PWM pins a duty
+// cycle within their resolution and SERVO pins an angle of 0 to 180.
+func (b *Board) Write(pin int, value int) (err error) {
+	state, err := b.Pin(pin)
+	if err != nil {
+		return
+	}
+
+	switch state.Mode {
+	case firmata.Output:
+		if value != 0 && value != 1 {
+			return fmt.Errorf("Value %v out of range 0 to 1 for pin %v: %w", value, pin, ErrInvalid)
+		}
+		err = b.client.DigitalWrite(uint8(pin), value == 1)
+	case firmata.PWM, firmata.Servo:
+		max := 180
+		if state.Mode == firmata.PWM {
+			max = 1<<state.Modes[firmata.PWM] - 1
+		}
+		// AnalogMessage carries 14 bits but the client writes a byte
+		if max > 255 {
+			max = 255
+		}
+		if value < 0 || value > max {
+			return fmt.Errorf("Value %v out of range 0 to %v for pin %v: %w", value, max, pin, ErrInvalid)
+		}
+		err = b.client.AnalogWrite(uint(pin), byte(value))
+	default:
+		return fmt.Errorf("Pin %v in mode %v is not writable: %w", pin, state.Mode, ErrInvalid)
+	}
+	if err != nil {
+		return
+	}
+	b.update(pin, value)
+	return
+}
+
+// Get a channel of value changes of the given pins, or of all pins if none
+// are given. Events are dropped while the channel is full. The channel is
+// closed by cancel or when the client's value channel closes.
+func (b *Board) Subscribe(pins ...int) (events <-chan PinEvent, cancel func()) {
+	sub := &subscription{events: make(chan PinEvent, 64)}
+	if len(pins) > 0 {
+		sub.pins = make(map[int]bool)
+		for _, pin := range pins {
+			sub.pins[pin] = true
+		}
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.closed {
+		close(sub.events)
+		return sub.events, func() {}
+	}
+	b.subs[sub] = struct{}{}
+
+	var once sync.Once
+	return sub.events, func() {
+		once.Do(func() {
+			b.lock.Lock()
+			defer b.lock.Unlock()
+			if _, ok := b.subs[sub]; ok {
+				delete(b.subs, sub)
+				close(sub.events)
+			}
+		})
+	}
+}
+
+// Record a new value and notify subscribers if it changed or is the first
+// since the pin's mode changed
+func (b *Board) update(pin int, value int) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.record(pin, value, time.Now())
+}
+
+// Caller must hold b.lock
+func (b *Board) record(pin int, value int, at time.Time) {
+	first := b.stale[pin]
+	if b.pins[pin].Value == value && !first {
+		return
+	}
+	delete(b.stale, pin)
+	b.pins[pin].Value = value
+	ev := PinEvent{Pin: pin, Mode: b.pins[pin].Mode, Value: value, Time: at}
+	if (ev.Mode == firmata.Input || ev.Mode == firmata.InputPullup) && !first {
+		ev.Edge = Falling
+		if value != 0 {
+			ev.Edge = Rising
+		}
+	}
+	b.notify(ev)
+}
+
+// Send an event to the subscribers of its pin. Caller must hold b.lock.
+func (b *Board) notify(ev PinEvent) {
+	for sub := range b.subs {
+		if sub.pins != nil && !sub.pins[ev.Pin] {
+			continue
+		}
+		select {
+		case sub.events <- ev:
+		default:
+			b.Log.Warn("Pin event buffer overflow. Slow subscriber?", "pin", ev.Pin)
+		}
+	}
+}
+
+func (b *Board) readValues() {
+	for v := range b.client.GetValues() {
+		if v.IsAnalog() {
+			pin, val, _ := v.GetAnalogValue()
+			b.readAnalog(pin, val, time.Now())
+			continue
+		}
+
+		port, levels, _ := v.GetDigitalValue()
+		var bits byte
+		for i := byte(0); i < 8; i++ {
+			if levels[port*8+i].(bool) {
+				bits |= 1 << i
+			}
+		}
+		b.readPort(port, bits, time.Now())
+	}
+
+	// client closed, end all subscriptions
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.closed = true
+	for pin := range b.pending {
+		b.dropPending(pin)
+	}
+	for sub := range b.subs {
+		delete(b.subs, sub)
+		close(sub.events)
+	}
+}
@@ -0,0 +1,157 @@
+// Copyright 2014 Krishna Raman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bridge_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kraman/go-firmata"
+	"github.com/kraman/go-firmata/bridge"
+	"github.com/kraman/go-firmata/virtual"
+)
+
+func newTestBoard(t *testing.T) (*bridge.Board, *virtual.Board) {
+	t.Helper()
+	vb := virtual.New(virtual.ArduinoUno())
+	c, err := firmata.NewClientWithTransport(vb.Transport())
+	if err != nil {
+		vb.Close()
+		t.Fatal(err)
+	}
+	c.SetAnalogSamplingInterval(5)
+	t.Cleanup(func() {
+		c.Close()
+		vb.Close()
+	})
+	return bridge.New(c), vb
+}
+
+// Wait for the next event on events or fail the test after a second
+func nextEvent(t *testing.T, events <-chan bridge.PinEvent) bridge.PinEvent {
+	t.Helper()
+	select {
+	case ev, ok := <-events:
+		if !ok {
+			t.Fatal("event channel closed")
+		}
+		return ev
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for an event")
+	}
+	return bridge.PinEvent{}
+}
+
+// Wait until cond holds or fail the test after a second
+func eventually(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %v", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestBoardWrite(t *testing.T) {
+	b, vb := newTestBoard(t)
+
+	if err := b.Write(13, 1); err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, "pin 13 high", func() bool { return vb.DigitalOutput(13) })
+	if v, _ := b.Read(13); v != 1 {
+		t.Errorf("pin 13 reads %v", v)
+	}
+
+	if err := b.Write(13, 2); !errors.Is(err, bridge.ErrInvalid) {
+		t.Errorf("writing 2 to an output returned %v", err)
+	}
+	if err := b.Write(99, 1); !errors.Is(err, bridge.ErrNoSuchPin) {
+		t.Errorf("writing to pin 99 returned %v", err)
+	}
+	if err := b.SetMode(4, firmata.PWM); !errors.Is(err, bridge.ErrInvalid) {
+		t.Errorf("PWM mode on pin 4 returned %v", err)
+	}
+
+	if err := b.SetMode(9, firmata.PWM); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Write(9, 200); err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, "PWM duty 200", func() bool { return vb.AnalogOutput(9) == 200 })
+}
+
+func TestBoardAnalogEvents(t *testing.T) {
+	b, vb := newTestBoard(t)
+	events, cancel := b.Subscribe(14)
+	defer cancel()
+
+	vb.SetAnalogInput(14, 300)
+	for ev := nextEvent(t, events); ev.Value != 300; ev = nextEvent(t, events) {
+	}
+	if v, _ := b.Read(14); v != 300 {
+		t.Errorf("pin 14 reads %v", v)
+	}
+}
+
+func TestSubscribeClosesWithClient(t *testing.T) {
+	b, _ := newTestBoard(t)
+	events, cancel := b.Subscribe()
+	defer cancel()
+
+	b.Client().Close()
+
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case _, ok := <-events:
+			if !ok {
+				// subscriptions after the close end immediately
+				late, _ := b.Subscribe()
+				if _, ok = <-late; ok {
+					t.Error("subscription after close delivered an event")
+				}
+				return
+			}
+		case <-timeout:
+			t.Fatal("subscription not closed after the client closed")
+		}
+	}
+}
+
+func TestBoardDigitalEdges(t *testing.T) {
+	b, vb := newTestBoard(t)
+	events, cancel := b.Subscribe(2)
+	defer cancel()
+	if err := b.SetMode(2, firmata.Input); err != nil {
+		t.Fatal(err)
+	}
+
+	if ev := nextEvent(t, events); ev.Value != 0 || ev.Edge != 0 {
+		t.Fatalf("first report %+v", ev)
+	}
+	vb.SetDigitalInput(2, true)
+	if ev := nextEvent(t, events); ev.Value != 1 || ev.Edge != bridge.Rising {
+		t.Errorf("got %+v, want rising edge", ev)
+	}
+	vb.SetDigitalInput(2, false)
+	if ev := nextEvent(t, events); ev.Value != 0 || ev.Edge != bridge.Falling {
+		t.Errorf("got %+v, want falling edge", ev)
+	}
+}
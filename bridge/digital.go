@@ -0,0 +1,141 @@
+// Copyright 2014 Krishna Raman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bridge
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/kraman/go-firmata"
+)
+
+// Direction of a digital input change
+type Edge byte
+
+const (
+	// Low to high
+	Rising Edge = iota + 1
+	// High to low
+	Falling
+)
+
+func (e Edge) String() string {
+	switch e {
+	case Rising:
+		return "rising"
+	case Falling:
+		return "falling"
+	}
+	return fmt.Sprintf("Edge(%d)", byte(e))
+}
+
+func (e Edge) MarshalText() ([]byte, error) {
+	return []byte(e.String()), nil
+}
+
+// Level change of a digital input waiting out its debounce time
+type pendingLevel struct {
+	level int
+	since time.Time
+	timer *time.Timer
+}
+
+// Filter glitches and contact bounce on a digital input: a level change is
+// only reported once the input has held it for d, stamped with the time the
+// input first left its previous level. 0, the default, reports every change
+// as it arrives.
+func (b *Board) SetDebounce(pin int, d time.Duration) error {
+	if d < 0 {
+		return fmt.Errorf("Debounce time %v is negative: %w", d, ErrInvalid)
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if pin < 0 || pin >= len(b.pins) {
+		return fmt.Errorf("Pin %v: %w", pin, ErrNoSuchPin)
+	}
+	if d == 0 {
+		delete(b.debounce, pin)
+	} else {
+		b.debounce[pin] = d
+	}
+	return nil
+}
+
+// Handle a digital port report received at the given time. Only pins whose
+// bit changed since the last report of the port, or that have not been
+// reported since their mode changed, are looked at.
+func (b *Board) readPort(port byte, levels byte, at time.Time) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	changed := byte(0xFF)
+	if last, ok := b.ports[port]; ok {
+		changed = levels ^ last
+	}
+	b.ports[port] = levels
+
+	for i := 0; i < 8; i++ {
+		pin := int(port)*8 + i
+		if pin >= len(b.pins) {
+			break
+		}
+		if mode := b.pins[pin].Mode; mode != firmata.Input && mode != firmata.InputPullup {
+			continue
+		}
+		if changed&(1<<i) == 0 && !b.stale[pin] {
+			continue
+		}
+		b.filter(pin, int(levels>>i)&1, at)
+	}
+}
+
+// Record a raw input level, or hold it back until it has lasted the pin's
+// debounce time. Caller must hold b.lock.
+func (b *Board) filter(pin int, level int, at time.Time) {
+	d, ok := b.debounce[pin]
+	if !ok {
+		b.dropPending(pin)
+		b.record(pin, level, at)
+		return
+	}
+	// a bouncing input keeps the time of its first change. If it settles
+	// back at the reported level, record drops it as a glitch.
+	since := at
+	if p, ok := b.pending[pin]; ok {
+		since = p.since
+	}
+	b.dropPending(pin)
+	p := &pendingLevel{level: level, since: since}
+	p.timer = time.AfterFunc(d, func() {
+		b.lock.Lock()
+		defer b.lock.Unlock()
+		// superseded by a later change or a mode change
+		if b.pending[pin] != p {
+			return
+		}
+		delete(b.pending, pin)
+		b.record(pin, p.level, p.since)
+	})
+	b.pending[pin] = p
+}
+
+// Forget a level change waiting out its debounce time. Caller must hold
+// b.lock.
+func (b *Board) dropPending(pin int) {
+	if p, ok := b.pending[pin]; ok {
+		p.timer.Stop()
+		delete(b.pending, pin)
+	}
+}
@@ -0,0 +1,166 @@
+// Copyright 2014 Krishna Raman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bridge
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/kraman/go-firmata"
+)
+
+// Board without a client whose first eight pins are digital inputs, fed
+// by calling readPort directly
+func newInputBoard() *Board {
+	b := &Board{
+		Log:      slog.New(slog.NewTextHandler(io.Discard, nil)),
+		stale:    make(map[int]bool),
+		ports:    make(map[byte]byte),
+		debounce: make(map[int]time.Duration),
+		pending:  make(map[int]*pendingLevel),
+		filters:  make(map[int][]Filter),
+		subs:     make(map[*subscription]struct{}),
+	}
+	for pin := 0; pin < 16; pin++ {
+		mode := firmata.Output
+		if pin < 8 {
+			mode = firmata.Input
+			b.stale[pin] = true
+		}
+		b.pins = append(b.pins, PinState{Pin: pin, Mode: mode, AnalogChannel: -1})
+	}
+	return b
+}
+
+func expectEvent(t *testing.T, events <-chan PinEvent, value int, edge Edge, at time.Time) {
+	t.Helper()
+	select {
+	case ev := <-events:
+		if ev.Value != value || ev.Edge != edge || !ev.Time.Equal(at) {
+			t.Fatalf("got value %v edge %v at %v, want %v %v at %v",
+				ev.Value, ev.Edge, ev.Time, value, edge, at)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("no event for value %v", value)
+	}
+}
+
+func expectNoEvent(t *testing.T, events <-chan PinEvent, wait time.Duration) {
+	t.Helper()
+	select {
+	case ev := <-events:
+		t.Fatalf("unexpected event %+v", ev)
+	case <-time.After(wait):
+	}
+}
+
+func TestDigitalEdges(t *testing.T) {
+	b := newInputBoard()
+	events, cancel := b.Subscribe(2)
+	defer cancel()
+	t0 := time.Unix(1000, 0)
+
+	// the first report after a mode change has no edge
+	b.readPort(0, 0x04, t0)
+	expectEvent(t, events, 1, 0, t0)
+	b.readPort(0, 0x00, t0.Add(time.Second))
+	expectEvent(t, events, 0, Falling, t0.Add(time.Second))
+	// changes of other pins leave pin 2 alone
+	b.readPort(0, 0x08, t0.Add(2*time.Second))
+	expectNoEvent(t, events, 0)
+	b.readPort(0, 0x0C, t0.Add(3*time.Second))
+	expectEvent(t, events, 1, Rising, t0.Add(3*time.Second))
+
+	if v := b.pins[2].Value; v != 1 {
+		t.Errorf("pin 2 reads %v", v)
+	}
+	// output pins are not taken from port reports
+	b.readPort(1, 0xFF, t0)
+	if v := b.pins[8].Value; v != 0 {
+		t.Errorf("output pin 8 set to %v by a port report", v)
+	}
+}
+
+func TestDigitalDebounce(t *testing.T) {
+	b := newInputBoard()
+	const d = 20 * time.Millisecond
+	if err := b.SetDebounce(2, d); err != nil {
+		t.Fatal(err)
+	}
+	events, cancel := b.Subscribe(2)
+	defer cancel()
+	t0 := time.Unix(1000, 0)
+
+	b.readPort(0, 0x00, t0)
+	expectEvent(t, events, 0, 0, t0)
+
+	// contact bounce is reported once, stamped with the first change
+	b.readPort(0, 0x04, t0.Add(time.Second))
+	b.readPort(0, 0x00, t0.Add(time.Second+time.Millisecond))
+	b.readPort(0, 0x04, t0.Add(time.Second+2*time.Millisecond))
+	expectEvent(t, events, 1, Rising, t0.Add(time.Second))
+	expectNoEvent(t, events, 2*d)
+
+	// a glitch shorter than the debounce time is dropped
+	b.readPort(0, 0x00, t0.Add(2*time.Second))
+	b.readPort(0, 0x04, t0.Add(2*time.Second+time.Millisecond))
+	expectNoEvent(t, events, 2*d)
+	if v := b.pins[2].Value; v != 1 {
+		t.Errorf("pin 2 reads %v after a glitch", v)
+	}
+
+	// turning debouncing off reports changes as they arrive
+	if err := b.SetDebounce(2, 0); err != nil {
+		t.Fatal(err)
+	}
+	b.readPort(0, 0x00, t0.Add(3*time.Second))
+	select {
+	case ev := <-events:
+		if ev.Value != 0 || ev.Edge != Falling {
+			t.Errorf("got %+v", ev)
+		}
+	default:
+		t.Error("change not reported immediately without debouncing")
+	}
+}
+
+func TestDigitalDebouncePendingDropped(t *testing.T) {
+	b := newInputBoard()
+	if err := b.SetDebounce(2, 20*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	events, cancel := b.Subscribe(2)
+	defer cancel()
+
+	b.readPort(0, 0x04, time.Now())
+	// as on a mode change
+	b.lock.Lock()
+	b.dropPending(2)
+	b.lock.Unlock()
+	expectNoEvent(t, events, 50*time.Millisecond)
+}
+
+func TestSetDebounceErrors(t *testing.T) {
+	b := newInputBoard()
+	if err := b.SetDebounce(2, -time.Millisecond); !errors.Is(err, ErrInvalid) {
+		t.Errorf("negative debounce time returned %v", err)
+	}
+	if err := b.SetDebounce(16, time.Millisecond); !errors.Is(err, ErrNoSuchPin) {
+		t.Errorf("debouncing pin 16 returned %v", err)
+	}
+}
@@ -0,0 +1,263 @@
+// Copyright 2014 Krishna Raman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bridge
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/kraman/go-firmata"
+)
+
+// Stage of an analog input's filter pipeline. Most filters keep state
+// between readings, so every pin needs its own instances.
+type Filter interface {
+	Filter(value float64) float64
+}
+
+// Stateless filter stage
+type FilterFunc func(value float64) float64
+
+func (f FilterFunc) Filter(value float64) float64 {
+	return f(value)
+}
+
+// Last n values, oldest overwritten first
+type window struct {
+	size   int
+	values []float64
+	next   int
+}
+
+func newWindow(n int) *window {
+	if n < 1 {
+		n = 1
+	}
+	return &window{size: n}
+}
+
+func (w *window) add(value float64) {
+	if len(w.values) < w.size {
+		w.values = append(w.values, value)
+		return
+	}
+	w.values[w.next] = value
+	w.next = (w.next + 1) % w.size
+}
+
+type movingAverage struct {
+	*window
+}
+
+// Average of the last n values. n below 1 is treated as 1.
+func MovingAverage(n int) Filter {
+	return movingAverage{newWindow(n)}
+}
+
+func (f movingAverage) Filter(value float64) float64 {
+	f.add(value)
+	sum := 0.0
+	for _, v := range f.values {
+		sum += v
+	}
+	return sum / float64(len(f.values))
+}
+
+type median struct {
+	*window
+	sorted []float64
+}
+
+// Median of the last n values, which drops spikes shorter than half the
+// window. n below 1 is treated as 1.
+func Median(n int) Filter {
+	return &median{window: newWindow(n)}
+}
+
+func (f *median) Filter(value float64) float64 {
+	f.add(value)
+	f.sorted = append(f.sorted[:0], f.values...)
+	sort.Float64s(f.sorted)
+	mid := len(f.sorted) / 2
+	if len(f.sorted)%2 == 0 {
+		return (f.sorted[mid-1] + f.sorted[mid]) / 2
+	}
+	return f.sorted[mid]
+}
+
+type exponential struct {
+	alpha  float64
+	value  float64
+	primed bool
+}
+
+// Exponential moving average. alpha is the weight of each new value, from 1
+// (no smoothing) down towards 0, and is clamped to that range.
+func Exponential(alpha float64) Filter {
+	return &exponential{alpha: math.Max(math.SmallestNonzeroFloat64, math.Min(1, alpha))}
+}
+
+func (f *exponential) Filter(value float64) float64 {
+	if f.primed {
+		value = f.alpha*value + (1-f.alpha)*f.value
+	}
+	f.value, f.primed = value, true
+	return value
+}
+
+type deadband struct {
+	width  float64
+	value  float64
+	primed bool
+}
+
+// Hold the output until the input moves at least width away from it, so
+// noise around a steady value does not produce events
+func Deadband(width float64) Filter {
+	return &deadband{width: width}
+}
+
+func (f *deadband) Filter(value float64) float64 {
+	if !f.primed || math.Abs(value-f.value) >= f.width {
+		f.value, f.primed = value, true
+	}
+	return f.value
+}
+
+type hysteresis struct {
+	low, high float64
+	on        bool
+}
+
+// Two level threshold: the output switches to 1 once the input reaches high
+// and back to 0 once it falls to low. low must be below high.
+func Hysteresis(low, high float64) (Filter, error) {
+	if low >= high {
+		return nil, fmt.Errorf("Hysteresis low threshold %v not below high threshold %v", low, high)
+	}
+	return &hysteresis{low: low, high: high}, nil
+}
+
+func (f *hysteresis) Filter(value float64) float64 {
+	switch {
+	case value >= f.high:
+		f.on = true
+	case value <= f.low:
+		f.on = false
+	}
+	if f.on {
+		return 1
+	}
+	return 0
+}
+
+// Map inMin..inMax linearly to outMin..outMax, such as raw readings to
+// volts. Values outside the input range are extrapolated.
+func Linear(inMin, inMax, outMin, outMax float64) Filter {
+	scale := 0.0
+	if inMax != inMin {
+		scale = (outMax - outMin) / (inMax - inMin)
+	}
+	return FilterFunc(func(value float64) float64 {
+		return outMin + (value-inMin)*scale
+	})
+}
+
+// Point of a calibration table
+type CalibrationPoint struct {
+	In, Out float64
+}
+
+// Map values through a calibration table, interpolating linearly between
+// points. Values outside the table get the output of the nearest end. The
+// points must be in strictly increasing order of their input.
+func LookupTable(points ...CalibrationPoint) (Filter, error) {
+	if len(points) < 2 {
+		return nil, fmt.Errorf("Calibration table needs at least 2 points, got %v", len(points))
+	}
+	for i := 1; i < len(points); i++ {
+		if points[i].In <= points[i-1].In {
+			return nil, fmt.Errorf("Calibration point %v input %v not above %v", i, points[i].In, points[i-1].In)
+		}
+	}
+	points = append([]CalibrationPoint(nil), points...)
+	return FilterFunc(func(value float64) float64 {
+		i := sort.Search(len(points), func(i int) bool { return points[i].In >= value })
+		switch i {
+		case 0:
+			return points[0].Out
+		case len(points):
+			return points[len(points)-1].Out
+		}
+		a, b := points[i-1], points[i]
+		return a.Out + (value-a.In)/(b.In-a.In)*(b.Out-a.Out)
+	}), nil
+}
+
+// Run the readings of an analog input through filters, in order. The
+// result is kept as the pin's Reading and events are only sent when it
+// changes. Call without filters to remove them.
+func (b *Board) SetFilters(pin int, filters ...Filter) error {
+	state, err := b.Pin(pin)
+	if err != nil {
+		return err
+	}
+	if _, ok := state.Modes[firmata.Analog]; !ok {
+		return fmt.Errorf("Pin %v is not an analog input: %w", pin, ErrInvalid)
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if len(filters) == 0 {
+		delete(b.filters, pin)
+	} else {
+		b.filters[pin] = append([]Filter(nil), filters...)
+	}
+	b.pins[pin].Reading = nil
+	if b.pins[pin].Mode == firmata.Analog {
+		// report the first filtered reading even if the value is unchanged
+		b.stale[pin] = true
+	}
+	return nil
+}
+
+// Handle an analog reading received at the given time
+func (b *Board) readAnalog(pin int, raw int, at time.Time) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if pin < 0 || pin >= len(b.pins) || b.pins[pin].Mode != firmata.Analog {
+		return
+	}
+	filters, ok := b.filters[pin]
+	if !ok {
+		b.record(pin, raw, at)
+		return
+	}
+
+	reading := float64(raw)
+	for _, f := range filters {
+		reading = f.Filter(reading)
+	}
+	state := &b.pins[pin]
+	state.Value = raw
+	if state.Reading != nil && *state.Reading == reading && !b.stale[pin] {
+		return
+	}
+	delete(b.stale, pin)
+	state.Reading = &reading
+	b.notify(PinEvent{Pin: pin, Mode: state.Mode, Value: raw, Reading: &reading, Time: at})
+}
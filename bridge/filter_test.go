@@ -0,0 +1,177 @@
+// Copyright 2014 Krishna Raman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bridge
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/kraman/go-firmata"
+)
+
+// Feed inputs through f and compare the outputs with want
+func checkFilter(t *testing.T, name string, f Filter, inputs []float64, want []float64) {
+	t.Helper()
+	for i, in := range inputs {
+		if got := f.Filter(in); math.Abs(got-want[i]) > 1e-9 {
+			t.Errorf("%v: input %v (#%v) gave %v, want %v", name, in, i, got, want[i])
+		}
+	}
+}
+
+func TestMovingAverage(t *testing.T) {
+	checkFilter(t, "window of 3", MovingAverage(3),
+		[]float64{3, 6, 9, 12}, []float64{3, 4.5, 6, 9})
+	checkFilter(t, "window of 0", MovingAverage(0),
+		[]float64{3, 6, 9}, []float64{3, 6, 9})
+}
+
+func TestMedian(t *testing.T) {
+	checkFilter(t, "even count", Median(3),
+		[]float64{1, 100, 2, 3}, []float64{1, 50.5, 2, 3})
+	checkFilter(t, "spike", Median(3),
+		[]float64{10, 10, 100, 10, 10}, []float64{10, 10, 10, 10, 10})
+}
+
+func TestExponential(t *testing.T) {
+	checkFilter(t, "alpha 0.5", Exponential(0.5),
+		[]float64{10, 20, 20}, []float64{10, 15, 17.5})
+	checkFilter(t, "alpha above 1", Exponential(2),
+		[]float64{10, 20, 30}, []float64{10, 20, 30})
+	checkFilter(t, "alpha 0", Exponential(0),
+		[]float64{10, 20, 30}, []float64{10, 10, 10})
+}
+
+func TestDeadband(t *testing.T) {
+	checkFilter(t, "width 5", Deadband(5),
+		[]float64{100, 103, 95.1, 105, 101, 95}, []float64{100, 100, 100, 105, 105, 95})
+}
+
+func TestHysteresis(t *testing.T) {
+	f, err := Hysteresis(10, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkFilter(t, "10 to 20", f,
+		[]float64{5, 15, 20, 15, 11, 10, 15}, []float64{0, 0, 1, 1, 1, 0, 0})
+
+	for _, levels := range [][2]float64{{20, 10}, {10, 10}} {
+		if _, err = Hysteresis(levels[0], levels[1]); err == nil {
+			t.Errorf("thresholds %v accepted", levels)
+		}
+	}
+}
+
+func TestLinear(t *testing.T) {
+	checkFilter(t, "to volts", Linear(0, 1023, 0, 5),
+		[]float64{0, 1023, 2046, -1023}, []float64{0, 5, 10, -5})
+	checkFilter(t, "empty input range", Linear(1, 1, 2, 3),
+		[]float64{0, 1, 5}, []float64{2, 2, 2})
+}
+
+func TestLookupTable(t *testing.T) {
+	f, err := LookupTable(
+		CalibrationPoint{In: 200, Out: -10},
+		CalibrationPoint{In: 510, Out: 25},
+		CalibrationPoint{In: 830, Out: 80})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkFilter(t, "table", f,
+		[]float64{0, 200, 355, 510, 670, 830, 1023}, []float64{-10, -10, 7.5, 25, 52.5, 80, 80})
+
+	if _, err = LookupTable(CalibrationPoint{In: 1, Out: 1}); err == nil {
+		t.Error("table with one point accepted")
+	}
+	if _, err = LookupTable(CalibrationPoint{In: 2, Out: 1}, CalibrationPoint{In: 2, Out: 3}); err == nil {
+		t.Error("table with repeated input accepted")
+	}
+}
+
+func TestFilterFunc(t *testing.T) {
+	checkFilter(t, "double", FilterFunc(func(v float64) float64 { return 2 * v }),
+		[]float64{1, 2.5}, []float64{2, 5})
+}
+
+// Input board with an analog input on pin 16
+func newAnalogBoard() *Board {
+	b := newInputBoard()
+	b.pins = append(b.pins, PinState{
+		Pin:           16,
+		Mode:          firmata.Analog,
+		Modes:         map[firmata.PinMode]byte{firmata.Input: 1, firmata.Analog: 10},
+		AnalogChannel: 0,
+	})
+	return b
+}
+
+func TestSetFilters(t *testing.T) {
+	b := newAnalogBoard()
+	events, cancel := b.Subscribe(16)
+	defer cancel()
+	t0 := time.Unix(1000, 0)
+
+	if err := b.SetFilters(16, Linear(0, 1000, 0, 10), Deadband(1)); err != nil {
+		t.Fatal(err)
+	}
+	next := func(value int, reading float64) {
+		t.Helper()
+		select {
+		case ev := <-events:
+			if ev.Value != value || ev.Reading == nil || *ev.Reading != reading {
+				t.Fatalf("got %+v, want value %v reading %v", ev, value, reading)
+			}
+		default:
+			t.Fatalf("no event for value %v", value)
+		}
+	}
+
+	b.readAnalog(16, 500, t0)
+	next(500, 5)
+	// within the deadband: the raw value is kept but nothing is sent
+	b.readAnalog(16, 550, t0)
+	expectNoEvent(t, events, 0)
+	if state, _ := b.Pin(16); state.Value != 550 || *state.Reading != 5 {
+		t.Errorf("state %+v after a reading within the deadband", state)
+	}
+	if r, _ := b.Reading(16); r != 5 {
+		t.Errorf("conditioned reading %v", r)
+	}
+	b.readAnalog(16, 620, t0)
+	next(620, 6.2)
+
+	// without filters readings are raw again
+	if err := b.SetFilters(16); err != nil {
+		t.Fatal(err)
+	}
+	b.readAnalog(16, 630, t0)
+	select {
+	case ev := <-events:
+		if ev.Value != 630 || ev.Reading != nil {
+			t.Errorf("got %+v without filters", ev)
+		}
+	default:
+		t.Fatal("no event without filters")
+	}
+	if r, _ := b.Reading(16); r != 630 {
+		t.Errorf("reading %v without filters", r)
+	}
+
+	if err := b.SetFilters(2, Deadband(1)); !errors.Is(err, ErrInvalid) {
+		t.Errorf("filters on a digital pin returned %v", err)
+	}
+}
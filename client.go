@@ -18,8 +18,8 @@ import (
 	"fmt"
 	"github.com/tarm/serial"
 	"io"
-	"log"
-	"os"
+	"log/slog"
+	"sync"
 	"time"
 )
 
@@ -28,33 +28,80 @@ type FirmataClient struct {
 	serialDev string
 	baud      int
 	conn      *io.ReadWriteCloser
-	Log       *log.Logger
+	// Silent by default. Set with the WithLogger or WithLogHandler options.
+	Log *slog.Logger
 
+	infoLock        sync.Mutex
 	protocolVersion []byte
 	firmwareVersion []int
 	firmwareName    string
+	versionQueries  []chan struct{}
+	firmwareQueries []chan struct{}
+	forcedFeatures  map[Feature]bool
 
 	ready             bool
 	analogMappingDone bool
 	capabilityDone    bool
+	handshake         chan struct{}
+	handshakeOnce     sync.Once
+	readerDone        chan struct{}
 
-	digitalPinState [8]byte
+	digitalLock       sync.Mutex
+	digitalPinState   [16]byte
+	digitalPortValues [16]byte
 
 	analogPinsChannelMap map[int]byte
 	analogChannelPinsMap map[byte]int
 	pinModes             []map[PinMode]interface{}
 
 	valueChan  chan FirmataValue
-	serialChan chan string
-	spiChan    chan []byte
+	serialChan chan SerialEvent
+	serialOnce sync.Once
+	serialData chan string
 
+	writeLock sync.Mutex
+
+	sysExHandlersLock sync.Mutex
+	sysExHandlers     map[SysExCommand]SysExHandler
+
+	spiBus       chan struct{}
+	spiLock      sync.Mutex
+	spiPending   map[byte]*spiRequest
+	spiRequestID byte
+
+	i2cLock  sync.Mutex
+	i2cReads []*i2cRead
+	i2cChan  chan I2CEvent
+
+	oneWireLock     sync.Mutex
+	oneWireReads    map[uint16]chan []byte
+	oneWireSearches map[oneWireSearchKey][]chan []OneWireAddress
+	oneWireID       uint16
+
+	stepperLock      sync.Mutex
+	stepperMoves     map[stepperKey][]*StepperMove
+	stepperPositions map[byte][]chan int32
+	stepperChan      chan StepperEvent
+
+	encoderLock      sync.Mutex
+	encoderPositions map[byte]int32
+	encoderQueries   []chan map[byte]int32
+	encoderChan      chan EncoderEvent
+
+	schedulerLock   sync.Mutex
+	taskListQueries []chan []byte
+	taskQueries     map[byte][]chan *TaskInfo
+
+	metrics MetricsCollector
+
+	// Log raw protocol bytes at debug level
 	Verbose bool
 }
 
 // Creates a new FirmataClient object and connects to the Arduino board
 // over specified serial port. This function blocks till a connection is
 // succesfullt established and pin mappings are retrieved.
-func NewClient(dev string, baud int) (client *FirmataClient, err error) {
+func NewClient(dev string, baud int, opts ...ClientOption) (client *FirmataClient, err error) {
 	var conn io.ReadWriteCloser
 
 	c := &serial.Config{Name: dev, Baud: baud}
@@ -63,34 +110,68 @@ func NewClient(dev string, baud int) (client *FirmataClient, err error) {
 		return nil, err
 	}
 
+	opts = append([]ClientOption{func(c *FirmataClient) {
+		c.serialDev = dev
+		c.baud = baud
+	}}, opts...)
+	return NewClientWithTransport(conn, opts...)
+}
+
+// Creates a new FirmataClient object talking to a board over conn, which may
+// be any transport such as a network socket, a recorded session or a virtual
+// board. This function blocks till the handshake completes and pin mappings
+// are retrieved. conn is closed if the handshake fails.
+func NewClientWithTransport(conn io.ReadWriteCloser, opts ...ClientOption) (client *FirmataClient, err error) {
 	client = &FirmataClient{
-		serialDev: dev,
-		baud:      baud,
-		conn:      &conn,
-		Log:       log.New(os.Stdout, "[go-firmata] ", log.Ltime),
+		conn:        &conn,
+		Log:         discardLogger(),
+		metrics:     noopMetrics{},
+		valueChan:   make(chan FirmataValue),
+		spiBus:      make(chan struct{}, 1),
+		serialChan:  make(chan SerialEvent, 10),
+		i2cChan:     make(chan I2CEvent, 10),
+		stepperChan: make(chan StepperEvent, 10),
+		encoderChan: make(chan EncoderEvent, 10),
+		handshake:   make(chan struct{}),
+		readerDone:  make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(client)
 	}
 	go client.replyReader()
 
-	conn.Write([]byte{byte(SystemReset)})
-	t := time.NewTicker(time.Second)
+	client.sendCommand([]byte{byte(SystemReset)})
+	reset := time.NewTimer(time.Second * 15)
+	defer reset.Stop()
+	fail := time.NewTimer(time.Second * 30)
+	defer fail.Stop()
 
-	for !(client.ready && client.analogMappingDone && client.capabilityDone) {
+	for {
 		select {
-		case <-t.C:
-			//no-op
-		case <-time.After(time.Second * 15):
-			client.Log.Print("No response in 30 seconds. Resetting arduino")
-			conn.Write([]byte{byte(SystemReset)})
-		case <-time.After(time.Second * 30):
-			client.Log.Print("Unable to initialize connection")
+		case <-client.handshake:
+			client.Log.Info("Client ready to use", "device", client.serialDev)
+			return
+		case <-reset.C:
+			client.Log.Warn("No response in 15 seconds. Resetting arduino", "device", client.serialDev)
+			client.metrics.HandshakeReset()
+			client.sendCommand([]byte{byte(SystemReset)})
+		case <-fail.C:
+			client.Log.Error("Unable to initialize connection", "device", client.serialDev)
 			conn.Close()
-			client = nil
+			return nil, fmt.Errorf("No handshake from board within 30 seconds")
+		case <-client.readerDone:
+			client.Log.Error("Unable to initialize connection", "device", client.serialDev)
+			conn.Close()
+			return nil, fmt.Errorf("Connection closed during handshake")
 		}
 	}
+}
 
-	client.Log.Print("Client ready to use")
-
-	return
+// Signal NewClient once firmware, analog mapping and capabilities are known
+func (c *FirmataClient) checkHandshake() {
+	if c.ready && c.analogMappingDone && c.capabilityDone {
+		c.handshakeOnce.Do(func() { close(c.handshake) })
+	}
 }
 
 // Close the serial connection to properly clean up after ourselves
@@ -107,17 +188,54 @@ func (c *FirmataClient) Close() {
 
 // Sets the Pin mode (input, output, etc.) for the Arduino pin
 func (c *FirmataClient) SetPinMode(pin uint8, mode PinMode) error {
+	if int(pin) >= len(c.pinModes) {
+		return fmt.Errorf("Invalid pin number %v", pin)
+	}
 	if c.pinModes[pin][mode] == nil {
 		return fmt.Errorf("Pin mode %v not supported by pin %v", mode, pin)
 	}
+	if mode == InputPullup {
+		if err := c.requireFeature(FeatureInputPullup); err != nil {
+			return err
+		}
+	}
 	cmd := []byte{byte(SetPinMode), (pin & 0x7F), byte(mode)}
 	if err := c.sendCommand(cmd); err != nil {
 		return err
 	}
-	c.Log.Printf("SetPinMode: pin %d -> %s\r\n", pin, mode)
+	c.Log.Debug("SetPinMode", "pin", pin, "mode", mode)
 	return nil
 }
 
+// Number of pins reported by the board's capability response
+func (c *FirmataClient) PinCount() int {
+	return len(c.pinModes)
+}
+
+// Get the modes supported by a pin along with their resolution in bits
+func (c *FirmataClient) PinCapabilities(pin uint8) (caps map[PinMode]byte, err error) {
+	if int(pin) >= len(c.pinModes) {
+		err = fmt.Errorf("Invalid pin number %v", pin)
+		return
+	}
+	caps = make(map[PinMode]byte)
+	for mode, res := range c.pinModes[pin] {
+		caps[mode] = res.(byte)
+	}
+	return
+}
+
+// Get the analog channel of a pin, as reported by the board's analog mapping
+func (c *FirmataClient) AnalogChannel(pin uint8) (channel byte, ok bool) {
+	channel, ok = c.analogPinsChannelMap[int(pin)]
+	return
+}
+
+// Check if the board reported mode as supported by pin
+func (c *FirmataClient) SupportsPinMode(pin uint8, mode PinMode) bool {
+	return int(pin) < len(c.pinModes) && c.pinModes[pin][mode] != nil
+}
+
 // Specified if a digital Pin should be watched for input.
 // Values will be streamed back over a channel which can be retrieved by the GetValues() call
 func (c *FirmataClient) EnableDigitalInput(pin uint, val bool) (err error) {
@@ -139,28 +257,100 @@ func (c *FirmataClient) EnableDigitalInput(pin uint, val bool) (err error) {
 	return
 }
 
-// Set the value of a digital pin
+// Set the value of a digital pin. Boards speaking protocol 2.5 or later
+// receive a single-pin update so other pins on the port are left untouched.
 func (c *FirmataClient) DigitalWrite(pin uint8, val bool) error {
-	if pin < 0 || pin > uint8(len(c.pinModes)) && c.pinModes[pin][Output] != nil {
+	if int(pin) >= len(c.pinModes) || int(pin/8) >= len(c.digitalPinState) {
 		return fmt.Errorf("Invalid pin number %v\n", pin)
 	}
 	port := (pin / 8) & 0x7F
+
+	c.digitalLock.Lock()
+	defer c.digitalLock.Unlock()
+
 	portData := &c.digitalPinState[port]
-	pin = pin % 8
+	bit := byte(1) << (pin % 8)
 	if val {
-		(*portData) = (*portData) | (1 << pin)
+		(*portData) = (*portData) | bit
 	} else {
-		(*portData) = (*portData) & ^(1 << pin)
+		(*portData) = (*portData) & ^bit
+	}
+
+	var cmd []byte
+	if c.Supports(FeatureDigitalPinValue) {
+		v := byte(0x00)
+		if val {
+			v = 0x01
+		}
+		cmd = []byte{byte(SetDigitalPinValue), pin & 0x7F, v}
+	} else {
+		data := to7Bit(*(portData))
+		cmd = []byte{byte(DigitalMessage) | byte(port), data[0], data[1]}
 	}
-	data := to7Bit(*(portData))
-	cmd := []byte{byte(DigitalMessage) | byte(port), data[0], data[1]}
 	if err := c.sendCommand(cmd); err != nil {
 		return err
 	}
-	c.Log.Printf("DigitalWrite: pin %d -> %t\r\n", pin, val)
+	c.Log.Debug("DigitalWrite", "pin", pin, "value", val)
 	return nil
 }
 
+// Set the pins selected by mask on a digital port (8 pins) to the matching
+// bits of value. Boards speaking protocol 2.5 or later receive one
+// single-pin update per selected pin. Older boards receive a single port
+// message, which also rewrites the pins outside mask with the value last
+// written to them by this client; only use it there if nothing else drives
+// the port's outputs.
+func (c *FirmataClient) DigitalWritePort(port uint8, mask byte, value byte) error {
+	if int(port) >= len(c.digitalPinState) || int(port)*8 >= len(c.pinModes) {
+		return fmt.Errorf("Invalid port number %v", port)
+	}
+
+	c.digitalLock.Lock()
+	defer c.digitalLock.Unlock()
+
+	portData := &c.digitalPinState[port]
+	(*portData) = ((*portData) & ^mask) | (value & mask)
+
+	if c.Supports(FeatureDigitalPinValue) {
+		for i := uint8(0); i < 8; i++ {
+			bit := byte(1) << i
+			pin := port*8 + i
+			if mask&bit == 0 || int(pin) >= len(c.pinModes) {
+				continue
+			}
+			v := byte(0x00)
+			if value&bit != 0 {
+				v = 0x01
+			}
+			if err := c.sendCommand([]byte{byte(SetDigitalPinValue), pin & 0x7F, v}); err != nil {
+				return err
+			}
+		}
+	} else {
+		data := to7Bit(*(portData))
+		cmd := []byte{byte(DigitalMessage) | byte(port), data[0], data[1]}
+		if err := c.sendCommand(cmd); err != nil {
+			return err
+		}
+	}
+	c.Log.Debug("DigitalWritePort", "port", port, "mask", mask, "value", *portData)
+	return nil
+}
+
+// Get the last value reported by the board for a digital port. Reporting
+// must be enabled for the port with EnableDigitalInput.
+func (c *FirmataClient) DigitalReadPort(port uint8) (value byte, err error) {
+	if int(port) >= len(c.digitalPortValues) || int(port)*8 >= len(c.pinModes) {
+		err = fmt.Errorf("Invalid port number %v", port)
+		return
+	}
+
+	c.digitalLock.Lock()
+	defer c.digitalLock.Unlock()
+	value = c.digitalPortValues[port]
+	return
+}
+
 // Specified if a analog Pin should be watched for input.
 // Values will be streamed back over a channel which can be retrieved by the GetValues() call
 func (c *FirmataClient) EnableAnalogInput(pin uint, val bool) (err error) {
@@ -170,7 +360,7 @@ func (c *FirmataClient) EnableAnalogInput(pin uint, val bool) (err error) {
 	}
 
 	ch := byte(c.analogPinsChannelMap[int(pin)])
-	c.Log.Printf("Enable analog inout on pin %v channel %v", pin, ch)
+	c.Log.Debug("EnableAnalogInput", "pin", pin, "channel", ch, "enable", val)
 	if val {
 		cmd := []byte{byte(EnableAnalogInput) | ch, 0x01}
 		err = c.sendCommand(cmd)
@@ -196,16 +386,15 @@ func (c *FirmataClient) AnalogWrite(pin uint, pinData byte) (err error) {
 }
 
 func (c *FirmataClient) sendCommand(cmd []byte) (err error) {
-	bStr := ""
-	for _, b := range cmd {
-		bStr = bStr + fmt.Sprintf(" %#2x", b)
-	}
-
-	if c.Verbose {
-		c.Log.Printf("Command send%v\n", bStr)
+	if c.tracing() {
+		c.Log.Debug("Command send", "command", FirmataCommand(cmd[0]), "bytes", hexBytes(cmd))
 	}
 
-	_, err = (*c.conn).Write(cmd)
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+	n, err := (*c.conn).Write(cmd)
+	c.metrics.BytesOut(n)
+	c.metrics.MessageOut(messageType(FirmataCommand(cmd[0])))
 	return
 }
 
@@ -216,7 +405,8 @@ func (c *FirmataClient) SetAnalogSamplingInterval(ms byte) (err error) {
 	return
 }
 
-// Get the channel to retrieve analog and digital pin values
+// Get the channel to retrieve analog and digital pin values. The channel is
+// closed when reading from the board stops, e.g. after Close.
 func (c *FirmataClient) GetValues() <-chan FirmataValue {
 	return c.valueChan
 }
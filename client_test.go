@@ -0,0 +1,79 @@
+// Copyright 2014 Krishna Raman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"bytes"
+	"testing"
+)
+
+// Offline client with 16 digital pins speaking the given protocol version
+func newDigitalClient(major byte, minor byte) (*FirmataClient, *recordingConn) {
+	c, rc := newOfflineClient()
+	c.protocolVersion = []byte{major, minor}
+	c.pinModes = make([]map[PinMode]interface{}, 16)
+	return c, rc
+}
+
+func TestDigitalWrite(t *testing.T) {
+	c, rc := newDigitalClient(2, 3)
+	if err := c.DigitalWrite(9, true); err != nil {
+		t.Fatal(err)
+	}
+	if want := []byte{byte(DigitalMessage) | 1, 0x02, 0x00}; !bytes.Equal(rc.Sent(), want) {
+		t.Errorf("protocol 2.3 sent %#v, want %#v", rc.Sent(), want)
+	}
+
+	c, rc = newDigitalClient(2, 5)
+	if err := c.DigitalWrite(9, true); err != nil {
+		t.Fatal(err)
+	}
+	if want := []byte{byte(SetDigitalPinValue), 9, 0x01}; !bytes.Equal(rc.Sent(), want) {
+		t.Errorf("protocol 2.5 sent %#v, want %#v", rc.Sent(), want)
+	}
+
+	if err := c.DigitalWrite(16, true); err == nil {
+		t.Error("write to pin 16 of 16 succeeded")
+	}
+}
+
+func TestDigitalWritePort(t *testing.T) {
+	// old boards get a port message with the shadow for unmasked pins
+	c, rc := newDigitalClient(2, 3)
+	c.digitalPinState[0] = 0x80
+	if err := c.DigitalWritePort(0, 0x03, 0x01); err != nil {
+		t.Fatal(err)
+	}
+	if want := []byte{byte(DigitalMessage), 0x01, 0x01}; !bytes.Equal(rc.Sent(), want) {
+		t.Errorf("protocol 2.3 sent %#v, want %#v", rc.Sent(), want)
+	}
+
+	// newer boards only get the masked pins
+	c, rc = newDigitalClient(2, 5)
+	c.digitalPinState[1] = 0x80
+	if err := c.DigitalWritePort(1, 0x05, 0x04); err != nil {
+		t.Fatal(err)
+	}
+	want := []byte{
+		byte(SetDigitalPinValue), 8, 0x00,
+		byte(SetDigitalPinValue), 10, 0x01,
+	}
+	if !bytes.Equal(rc.Sent(), want) {
+		t.Errorf("protocol 2.5 sent %#v, want %#v", rc.Sent(), want)
+	}
+	if c.digitalPinState[1] != 0x84 {
+		t.Errorf("port shadow %#x, want 0x84", c.digitalPinState[1])
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright 2014 Krishna Raman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"sync"
+
+	"github.com/kraman/go-firmata"
+)
+
+// Fans out values reported by the board to every subscriber. The client
+// stops reading from the board while its value channel is full, so the hub
+// drains it for the whole run even when no command is waiting for values.
+type valueHub struct {
+	lock sync.Mutex
+	subs map[chan firmata.FirmataValue]struct{}
+}
+
+var values *valueHub
+
+func startValueHub(c *firmata.FirmataClient) *valueHub {
+	h := &valueHub{subs: make(map[chan firmata.FirmataValue]struct{})}
+	go func() {
+		for v := range c.GetValues() {
+			h.lock.Lock()
+			for sub := range h.subs {
+				select {
+				case sub <- v:
+				default:
+				}
+			}
+			h.lock.Unlock()
+		}
+	}()
+	return h
+}
+
+// Get a channel receiving values from now on. Values are dropped while the
+// channel is full. Call cancel when done.
+func (h *valueHub) subscribe() (ch <-chan firmata.FirmataValue, cancel func()) {
+	sub := make(chan firmata.FirmataValue, 64)
+	h.lock.Lock()
+	h.subs[sub] = struct{}{}
+	h.lock.Unlock()
+	return sub, func() {
+		h.lock.Lock()
+		defer h.lock.Unlock()
+		delete(h.subs, sub)
+	}
+}
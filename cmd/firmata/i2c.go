@@ -0,0 +1,130 @@
+// Copyright 2014 Krishna Raman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kraman/go-firmata"
+)
+
+// Time to wait for each address during a scan
+const i2cScanTimeout = time.Millisecond * 100
+
+func runI2C(c *firmata.FirmataClient, args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("Expected scan, read or write")
+	}
+	if err := c.I2CConfig(0); err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "scan":
+		return i2cScan(c, args[1:])
+	case "read":
+		return i2cRead(c, args[1:])
+	case "write":
+		return i2cWrite(c, args[1:])
+	}
+	return fmt.Errorf("Unknown i2c command %q. Expected scan, read or write", args[0])
+}
+
+// Probe every 7-bit address outside the reserved ranges with a one byte read
+func i2cScan(c *firmata.FirmataClient, args []string) error {
+	if _, err := parseArgs(newFlagSet("scan"), args, 0); err != nil {
+		return err
+	}
+	found := 0
+	for addr := 0x08; addr <= 0x77; addr++ {
+		ctx, cancel := context.WithTimeout(context.Background(), i2cScanTimeout)
+		_, err := c.I2CRead(ctx, addr, firmata.I2CRegisterNotSpecified, 1)
+		cancel()
+		if err == nil {
+			fmt.Fprintf(out, "%#02x\n", addr)
+			found++
+		}
+	}
+	if found == 0 {
+		fmt.Fprintln(out, "No devices found")
+	}
+	return nil
+}
+
+func i2cRead(c *firmata.FirmataClient, args []string) error {
+	fs := newFlagSet("read")
+	reg := fs.String("reg", "", "register to read from")
+	args, err := parseArgs(fs, args, 2)
+	if err != nil {
+		return err
+	}
+	addr, err := parseNumber(args[0], 0, 0x3FF)
+	if err != nil {
+		return err
+	}
+	count, err := parseNumber(args[1], 1, 0x3FFF)
+	if err != nil {
+		return err
+	}
+	register := firmata.I2CRegisterNotSpecified
+	if *reg != "" {
+		if register, err = parseNumber(*reg, 0, 0x3FFF); err != nil {
+			return err
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	data, err := c.I2CRead(ctx, addr, register, count)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "% x\n", data)
+	return nil
+}
+
+func i2cWrite(c *firmata.FirmataClient, args []string) error {
+	args, err := parseArgs(newFlagSet("write"), args, -1)
+	if err != nil {
+		return err
+	}
+	if len(args) < 2 {
+		return fmt.Errorf("Expected an address and at least one byte")
+	}
+	addr, err := parseNumber(args[0], 0, 0x3FF)
+	if err != nil {
+		return err
+	}
+	data, err := parseBytes(args[1:], 0xFF)
+	if err != nil {
+		return err
+	}
+	return c.I2CWrite(addr, data)
+}
+
+// Parse a list of numbers of at most max
+func parseBytes(args []string, max int) ([]byte, error) {
+	data := make([]byte, 0, len(args))
+	for _, arg := range args {
+		b, err := parseNumber(arg, 0, max)
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, byte(b))
+	}
+	return data, nil
+}
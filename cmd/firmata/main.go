@@ -0,0 +1,202 @@
+// Copyright 2014 Krishna Raman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command line tool for poking at a Firmata board.
+//
+// Usage:
+//
+//	firmata -port /dev/ttyACM0 info
+//	firmata -port /dev/ttyACM0 write 13 1
+//	firmata -port /dev/ttyACM0 monitor 2 14
+//	firmata -port virtual pins
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/kraman/go-firmata"
+	"github.com/kraman/go-firmata/virtual"
+)
+
+type command struct {
+	args string
+	help string
+	run  func(c *firmata.FirmataClient, args []string) error
+}
+
+var commands = map[string]command{
+	"info":    {"", "show firmware, protocol version and capabilities", runInfo},
+	"pins":    {"", "list pins with their modes, resolutions and analog channels", runPins},
+	"mode":    {"<pin> <mode>", "set the mode of a pin", runMode},
+	"read":    {"[-digital] [-pullup] <pin>", "read a digital or analog pin once", runRead},
+	"write":   {"<pin> <0|1>", "set a digital output", runWrite},
+	"pwm":     {"<pin> <0-255>", "set a PWM duty cycle", runPWM},
+	"servo":   {"<pin> <angle>", "move a servo", runServo},
+	"monitor": {"[-for duration] <pin>...", "stream changes of input pins until interrupted", runMonitor},
+	"i2c":     {"scan | read [-reg r] <addr> <count> | write <addr> <byte>...", "talk to I2C devices", runI2C},
+	"sysex":   {"[-wait duration] <cmd> <byte>...", "send a user-defined SysEx message and print replies", runSysEx},
+}
+
+var (
+	port    = flag.String("port", "", "serial port of the board, or \"virtual\" for a simulated Arduino Uno")
+	baud    = flag.Int("baud", 57600, "baud rate")
+	timeout = flag.Duration("timeout", time.Second*2, "time to wait for replies from the board")
+	verbose = flag.Bool("v", false, "log protocol traffic to stderr")
+)
+
+// Destination of command output. The REPL replaces it with its terminal.
+var out io.Writer = os.Stdout
+
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "Usage: firmata -port <device> [flags] <command> [args]\n\nCommands:\n")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		cmd := commands[name]
+		fmt.Fprintf(out, "  %s %s\n    \t%s\n", name, cmd.args, cmd.help)
+	}
+	fmt.Fprintf(out, "\nFlags:\n")
+	flag.PrintDefaults()
+}
+
+func main() {
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() < 1 {
+		usage()
+		os.Exit(2)
+	}
+	cmd, ok := commands[flag.Arg(0)]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n\n", flag.Arg(0))
+		usage()
+		os.Exit(2)
+	}
+
+	c, err := connect()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	values = startValueHub(c)
+	err = cmd.run(c, flag.Args()[1:])
+	c.Close()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func connect() (c *firmata.FirmataClient, err error) {
+	var opts []firmata.ClientOption
+	if *verbose {
+		opts = append(opts, firmata.WithLogHandler(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	}
+	switch *port {
+	case "":
+		return nil, fmt.Errorf("No port given. Use -port <device>")
+	case "virtual":
+		board := virtual.New(virtual.ArduinoUno())
+		c, err = firmata.NewClientWithTransport(board.Transport(), opts...)
+	default:
+		c, err = firmata.NewClient(*port, *baud, opts...)
+	}
+	if c != nil && *verbose {
+		c.Verbose = true
+	}
+	return
+}
+
+// Parse a decimal, 0x prefixed hex or 0b prefixed binary number in [min, max].
+// Leading zeros do not make a number octal.
+func parseNumber(s string, min int, max int) (int, error) {
+	digits, base := s, 10
+	if len(s) > 2 && s[0] == '0' {
+		switch s[1] {
+		case 'x', 'X':
+			digits, base = s[2:], 16
+		case 'b', 'B':
+			digits, base = s[2:], 2
+		}
+	}
+	v, err := strconv.ParseInt(digits, base, 0)
+	if err != nil || int(v) < min || int(v) > max {
+		return 0, fmt.Errorf("Invalid number %q. Expected %v to %v", s, min, max)
+	}
+	return int(v), nil
+}
+
+// Parse a pin number or an analog pin name such as A0
+func parsePin(c *firmata.FirmataClient, s string) (uint8, error) {
+	if len(s) > 1 && (s[0] == 'A' || s[0] == 'a') {
+		if ch, err := strconv.Atoi(s[1:]); err == nil {
+			for pin := 0; pin < c.PinCount(); pin++ {
+				if pinCh, ok := c.AnalogChannel(uint8(pin)); ok && int(pinCh) == ch {
+					return uint8(pin), nil
+				}
+			}
+			return 0, fmt.Errorf("The board has no analog pin %v", s)
+		}
+	}
+	pin, err := parseNumber(s, 0, c.PinCount()-1)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid pin %q. The board has %v pins", s, c.PinCount())
+	}
+	return uint8(pin), nil
+}
+
+// Parse a pin mode by name (OUTPUT, pwm, ...) or number
+func parseMode(s string) (firmata.PinMode, error) {
+	if m, err := parseNumber(s, 0, 0x7F); err == nil {
+		return firmata.PinMode(m), nil
+	}
+	return firmata.ParsePinMode(s)
+}
+
+// Flag set for a sub-command. Errors are reported by the caller.
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(out)
+	return fs
+}
+
+// Parse the arguments of a sub-command, allowing flags after positional arguments
+func parseArgs(fs *flag.FlagSet, args []string, count int) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			break
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+	if count >= 0 && len(positional) != count {
+		return nil, fmt.Errorf("Expected %v arguments, got %v", count, len(positional))
+	}
+	return positional, nil
+}
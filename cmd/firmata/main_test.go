@@ -0,0 +1,60 @@
+// Copyright 2014 Krishna Raman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/kraman/go-firmata"
+)
+
+func TestParseNumber(t *testing.T) {
+	valid := map[string]int{
+		"0":      0,
+		"7":      7,
+		"08":     8,
+		"09":     9,
+		"010":    10,
+		"0x1F":   31,
+		"0X1f":   31,
+		"0b101":  5,
+		"255":    255,
+		"0x00FF": 255,
+	}
+	for s, want := range valid {
+		if got, err := parseNumber(s, 0, 255); err != nil || got != want {
+			t.Errorf("parseNumber(%q) = %v, %v; want %v", s, got, err, want)
+		}
+	}
+	for _, s := range []string{"", "0x", "0b2", "256", "-1", "1.5", "abc"} {
+		if v, err := parseNumber(s, 0, 255); err == nil {
+			t.Errorf("parseNumber(%q) = %v, want error", s, v)
+		}
+	}
+}
+
+func TestParseMode(t *testing.T) {
+	tests := map[string]firmata.PinMode{
+		"OUTPUT": firmata.Output,
+		"pwm":    firmata.PWM,
+		"3":      firmata.PWM,
+		"0x04":   firmata.Servo,
+	}
+	for s, want := range tests {
+		if got, err := parseMode(s); err != nil || got != want {
+			t.Errorf("parseMode(%q) = %v, %v; want %v", s, got, err, want)
+		}
+	}
+}
@@ -0,0 +1,318 @@
+// Copyright 2014 Krishna Raman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/kraman/go-firmata"
+)
+
+func runInfo(c *firmata.FirmataClient, args []string) error {
+	if _, err := parseArgs(newFlagSet("info"), args, 0); err != nil {
+		return err
+	}
+	info := c.BoardInfo()
+	fmt.Fprintf(out, "Firmware:  %v %v.%v\n", info.FirmwareName, info.FirmwareMajor, info.FirmwareMinor)
+	fmt.Fprintf(out, "Protocol:  %v.%v\n", info.ProtocolMajor, info.ProtocolMinor)
+	fmt.Fprintf(out, "Pins:      %v\n", info.Pins)
+	fmt.Fprintf(out, "Analog:    %v channels\n", info.AnalogChannels)
+
+	// pins grouped by supported mode
+	byMode := make(map[firmata.PinMode][]string)
+	for pin := 0; pin < c.PinCount(); pin++ {
+		caps, _ := c.PinCapabilities(uint8(pin))
+		for mode := range caps {
+			byMode[mode] = append(byMode[mode], fmt.Sprint(pin))
+		}
+	}
+	modes := make([]firmata.PinMode, 0, len(byMode))
+	for mode := range byMode {
+		modes = append(modes, mode)
+	}
+	fmt.Fprintf(out, "Modes:\n")
+	for _, mode := range sortModes(modes) {
+		fmt.Fprintf(out, "  %-10v %v\n", mode, strings.Join(byMode[mode], " "))
+	}
+	return nil
+}
+
+func runPins(c *firmata.FirmataClient, args []string) error {
+	if _, err := parseArgs(newFlagSet("pins"), args, 0); err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "PIN\tANALOG\tMODES\n")
+	for pin := 0; pin < c.PinCount(); pin++ {
+		caps, _ := c.PinCapabilities(uint8(pin))
+		analog := "-"
+		if ch, ok := c.AnalogChannel(uint8(pin)); ok {
+			analog = fmt.Sprintf("A%v", ch)
+		}
+		var modes []firmata.PinMode
+		for mode := range caps {
+			modes = append(modes, mode)
+		}
+		var desc []string
+		for _, mode := range sortModes(modes) {
+			desc = append(desc, fmt.Sprintf("%v(%v)", mode, caps[mode]))
+		}
+		fmt.Fprintf(w, "%v\t%v\t%v\n", pin, analog, strings.Join(desc, " "))
+	}
+	return w.Flush()
+}
+
+func runMode(c *firmata.FirmataClient, args []string) error {
+	args, err := parseArgs(newFlagSet("mode"), args, 2)
+	if err != nil {
+		return err
+	}
+	pin, err := parsePin(c, args[0])
+	if err != nil {
+		return err
+	}
+	mode, err := parseMode(args[1])
+	if err != nil {
+		return err
+	}
+	if !c.SupportsPinMode(pin, mode) {
+		return fmt.Errorf("Pin %v does not support mode %v", pin, mode)
+	}
+	return c.SetPinMode(pin, mode)
+}
+
+func runRead(c *firmata.FirmataClient, args []string) error {
+	fs := newFlagSet("read")
+	digital := fs.Bool("digital", false, "read an analog capable pin as a digital input")
+	pullup := fs.Bool("pullup", false, "enable the internal pull-up resistor")
+	args, err := parseArgs(fs, args, 1)
+	if err != nil {
+		return err
+	}
+	pin, err := parsePin(c, args[0])
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	vals, unsubscribe := values.subscribe()
+	defer unsubscribe()
+	if c.SupportsPinMode(pin, firmata.Analog) && !*digital {
+		if err = c.SetPinMode(pin, firmata.Analog); err != nil {
+			return err
+		}
+		if err = c.EnableAnalogInput(uint(pin), true); err != nil {
+			return err
+		}
+		defer c.EnableAnalogInput(uint(pin), false)
+		val, err := waitAnalog(ctx, vals, int(pin))
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(out, val)
+		return nil
+	}
+
+	mode := firmata.Input
+	if *pullup {
+		mode = firmata.InputPullup
+	}
+	if err = c.SetPinMode(pin, mode); err != nil {
+		return err
+	}
+	if err = c.EnableDigitalInput(uint(pin), true); err != nil {
+		return err
+	}
+	defer c.EnableDigitalInput(uint(pin), false)
+	val, err := waitDigital(ctx, vals, pin)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(out, boolToInt(val))
+	return nil
+}
+
+func runWrite(c *firmata.FirmataClient, args []string) error {
+	args, err := parseArgs(newFlagSet("write"), args, 2)
+	if err != nil {
+		return err
+	}
+	pin, err := parsePin(c, args[0])
+	if err != nil {
+		return err
+	}
+	val, err := parseNumber(args[1], 0, 1)
+	if err != nil {
+		return err
+	}
+	if err = c.SetPinMode(pin, firmata.Output); err != nil {
+		return err
+	}
+	return c.DigitalWrite(pin, val == 1)
+}
+
+func runPWM(c *firmata.FirmataClient, args []string) error {
+	return analogOutput(c, "pwm", firmata.PWM, 255, args)
+}
+
+func runServo(c *firmata.FirmataClient, args []string) error {
+	return analogOutput(c, "servo", firmata.Servo, 180, args)
+}
+
+func analogOutput(c *firmata.FirmataClient, name string, mode firmata.PinMode, max int, args []string) error {
+	args, err := parseArgs(newFlagSet(name), args, 2)
+	if err != nil {
+		return err
+	}
+	pin, err := parsePin(c, args[0])
+	if err != nil {
+		return err
+	}
+	val, err := parseNumber(args[1], 0, max)
+	if err != nil {
+		return err
+	}
+	if !c.SupportsPinMode(pin, mode) {
+		return fmt.Errorf("Pin %v does not support mode %v", pin, mode)
+	}
+	if err = c.SetPinMode(pin, mode); err != nil {
+		return err
+	}
+	return c.AnalogWrite(uint(pin), byte(val))
+}
+
+func runMonitor(c *firmata.FirmataClient, args []string) error {
+	fs := newFlagSet("monitor")
+	duration := fs.Duration("for", 0, "stop after this long instead of waiting for an interrupt")
+	pullup := fs.Bool("pullup", false, "enable the internal pull-up resistor on digital pins")
+	args, err := parseArgs(fs, args, -1)
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		return fmt.Errorf("No pins to monitor")
+	}
+
+	// last reported levels, -1 until the first report
+	vals, unsubscribe := values.subscribe()
+	defer unsubscribe()
+	digital := make(map[uint8]int)
+	analog := make(map[int]bool)
+	for _, arg := range args {
+		pin, err := parsePin(c, arg)
+		if err != nil {
+			return err
+		}
+		if c.SupportsPinMode(pin, firmata.Analog) {
+			analog[int(pin)] = true
+			if err = c.SetPinMode(pin, firmata.Analog); err == nil {
+				err = c.EnableAnalogInput(uint(pin), true)
+			}
+		} else {
+			mode := firmata.Input
+			if *pullup {
+				mode = firmata.InputPullup
+			}
+			digital[pin] = -1
+			if err = c.SetPinMode(pin, mode); err == nil {
+				err = c.EnableDigitalInput(uint(pin), true)
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+	if *duration > 0 {
+		ctx, cancel = context.WithTimeout(ctx, *duration)
+		defer cancel()
+	}
+
+	last := make(map[int]int)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case v := <-vals:
+			now := time.Now().Format("15:04:05.000")
+			if v.IsAnalog() {
+				pin, val, _ := v.GetAnalogValue()
+				if prev, ok := last[pin]; analog[pin] && (!ok || prev != val) {
+					last[pin] = val
+					fmt.Fprintf(out, "%v pin %v = %v\n", now, pin, val)
+				}
+				continue
+			}
+			_, levels, _ := v.GetDigitalValue()
+			for pin, prev := range digital {
+				val, ok := levels[pin].(bool)
+				if ok && boolToInt(val) != prev {
+					digital[pin] = boolToInt(val)
+					fmt.Fprintf(out, "%v pin %v = %v\n", now, pin, boolToInt(val))
+				}
+			}
+		}
+	}
+}
+
+// Wait for the first analog report of pin
+func waitAnalog(ctx context.Context, vals <-chan firmata.FirmataValue, pin int) (int, error) {
+	for {
+		select {
+		case <-ctx.Done():
+			return 0, fmt.Errorf("No value reported for pin %v: %w", pin, ctx.Err())
+		case v := <-vals:
+			if p, val, err := v.GetAnalogValue(); err == nil && p == pin {
+				return val, nil
+			}
+		}
+	}
+}
+
+// Wait for the first digital report of the port of pin
+func waitDigital(ctx context.Context, vals <-chan firmata.FirmataValue, pin uint8) (bool, error) {
+	for {
+		select {
+		case <-ctx.Done():
+			return false, fmt.Errorf("No value reported for pin %v: %w", pin, ctx.Err())
+		case v := <-vals:
+			if port, levels, err := v.GetDigitalValue(); err == nil && port == pin/8 {
+				return levels[pin].(bool), nil
+			}
+		}
+	}
+}
+
+func sortModes(modes []firmata.PinMode) []firmata.PinMode {
+	sort.Slice(modes, func(i, j int) bool { return modes[i] < modes[j] })
+	return modes
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
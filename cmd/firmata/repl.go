@@ -0,0 +1,309 @@
+// Copyright 2014 Krishna Raman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kraman/go-firmata"
+	"golang.org/x/term"
+)
+
+// Commands that only exist inside the shell
+var replCommands = map[string]string{
+	"watch":   "<pin>... print values of input pins as they change",
+	"unwatch": "<pin>... stop printing values of pins",
+	"help":    "list commands",
+	"exit":    "leave the shell",
+}
+
+func init() {
+	// registered here as the shell runs the other commands
+	commands["repl"] = command{"", "interactive shell with history and tab completion", runREPL}
+}
+
+// Interactive shell. Reads commands from stdin, with line editing when it
+// is a terminal, and prints values of watched pins as they arrive.
+func runREPL(c *firmata.FirmataClient, args []string) error {
+	if _, err := parseArgs(newFlagSet("repl"), args, 0); err != nil {
+		return err
+	}
+	r := &repl{c: c, digital: make(map[uint8]int), analog: make(map[int]int)}
+	vals, unsubscribe := values.subscribe()
+	defer unsubscribe()
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		// scripted session, e.g. commands piped in from a file
+		go r.printValues(vals, out)
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			if r.exec(scanner.Text()) {
+				break
+			}
+		}
+		return scanner.Err()
+	}
+
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return err
+	}
+	defer term.Restore(fd, state)
+
+	t := term.NewTerminal(struct {
+		io.Reader
+		io.Writer
+	}{os.Stdin, os.Stdout}, "firmata> ")
+	t.AutoCompleteCallback = r.complete
+	out = t
+	defer func() { out = os.Stdout }()
+	go r.printValues(vals, t)
+
+	fmt.Fprintf(out, "%v\nType help for a list of commands. Tab completes commands and pins.\n", c.BoardInfo())
+	for {
+		line, err := t.ReadLine()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if r.exec(line) {
+			return nil
+		}
+	}
+}
+
+type repl struct {
+	c *firmata.FirmataClient
+
+	// last printed values of watched pins, -1 until the first report
+	lock    sync.Mutex
+	digital map[uint8]int
+	analog  map[int]int
+}
+
+// Run a command line. Returns true when the shell should exit.
+func (r *repl) exec(line string) bool {
+	fields := strings.Fields(line)
+	if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+		return false
+	}
+
+	var err error
+	switch name, args := fields[0], fields[1:]; name {
+	case "exit", "quit":
+		return true
+	case "help":
+		r.help()
+	case "watch":
+		err = r.watch(args, true)
+	case "unwatch":
+		err = r.watch(args, false)
+	case "repl", "monitor":
+		err = fmt.Errorf("%v is not available in the shell. Use watch instead", name)
+	default:
+		cmd, ok := commands[name]
+		if !ok {
+			err = fmt.Errorf("Unknown command %q. Type help for a list of commands", name)
+			break
+		}
+		err = cmd.run(r.c, args)
+	}
+	if err != nil {
+		fmt.Fprintln(out, err)
+	}
+	return false
+}
+
+func (r *repl) help() {
+	help := make(map[string]string)
+	for name, cmd := range commands {
+		help[name] = strings.TrimSpace(cmd.args + "  " + cmd.help)
+	}
+	for name, h := range replCommands {
+		help[name] = h
+	}
+	delete(help, "repl")
+	delete(help, "monitor")
+
+	names := make([]string, 0, len(help))
+	for name := range help {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(out, "  %-8v %v\n", name, help[name])
+	}
+}
+
+// Enable or disable reporting for pins and printing of their values
+func (r *repl) watch(args []string, enable bool) error {
+	if len(args) == 0 {
+		return fmt.Errorf("No pins given")
+	}
+	for _, arg := range args {
+		pin, err := parsePin(r.c, arg)
+		if err != nil {
+			return err
+		}
+		analog := r.c.SupportsPinMode(pin, firmata.Analog)
+
+		r.lock.Lock()
+		if !enable {
+			delete(r.analog, int(pin))
+			delete(r.digital, pin)
+		} else if analog {
+			r.analog[int(pin)] = -1
+		} else {
+			r.digital[pin] = -1
+		}
+		r.lock.Unlock()
+
+		switch {
+		case analog && enable:
+			if err = r.c.SetPinMode(pin, firmata.Analog); err == nil {
+				err = r.c.EnableAnalogInput(uint(pin), true)
+			}
+		case analog:
+			err = r.c.EnableAnalogInput(uint(pin), false)
+		case enable:
+			if err = r.c.SetPinMode(pin, firmata.Input); err == nil {
+				err = r.c.EnableDigitalInput(uint(pin), true)
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Print changes of watched pins
+func (r *repl) printValues(vals <-chan firmata.FirmataValue, w io.Writer) {
+	for v := range vals {
+		now := time.Now().Format("15:04:05.000")
+		r.lock.Lock()
+		if v.IsAnalog() {
+			pin, val, _ := v.GetAnalogValue()
+			if prev, ok := r.analog[pin]; ok && prev != val {
+				r.analog[pin] = val
+				fmt.Fprintf(w, "%v pin %v = %v\n", now, pin, val)
+			}
+		} else {
+			_, levels, _ := v.GetDigitalValue()
+			for pin, prev := range r.digital {
+				val, ok := levels[pin].(bool)
+				if ok && boolToInt(val) != prev {
+					r.digital[pin] = boolToInt(val)
+					fmt.Fprintf(w, "%v pin %v = %v\n", now, pin, boolToInt(val))
+				}
+			}
+		}
+		r.lock.Unlock()
+	}
+}
+
+// Tab completion of command names, sub-commands, pin modes and pins
+func (r *repl) complete(line string, pos int, key rune) (newLine string, newPos int, ok bool) {
+	if key != '\t' {
+		return
+	}
+	head := line[:pos]
+	start := strings.LastIndex(head, " ") + 1
+	word := strings.ToLower(head[start:])
+
+	var matches []string
+	for _, cand := range r.candidates(strings.Fields(head[:start])) {
+		if strings.HasPrefix(strings.ToLower(cand), word) {
+			matches = append(matches, cand)
+		}
+	}
+	if len(matches) == 0 {
+		return
+	}
+	completion := matches[0]
+	for _, m := range matches[1:] {
+		for !strings.HasPrefix(strings.ToLower(m), strings.ToLower(completion)) {
+			completion = completion[:len(completion)-1]
+		}
+	}
+	if len(matches) == 1 {
+		completion = completion + " "
+	}
+	newLine = head[:start] + completion + line[pos:]
+	newPos = start + len(completion)
+	ok = true
+	return
+}
+
+func (r *repl) candidates(fields []string) (cands []string) {
+	if len(fields) == 0 {
+		for name := range commands {
+			if name != "repl" && name != "monitor" {
+				cands = append(cands, name)
+			}
+		}
+		for name := range replCommands {
+			cands = append(cands, name)
+		}
+		sort.Strings(cands)
+		return
+	}
+
+	switch cmd := fields[0]; {
+	case cmd == "i2c" && len(fields) == 1:
+		cands = []string{"scan", "read", "write"}
+	case cmd == "mode" && len(fields) == 2:
+		if pin, err := parsePin(r.c, fields[1]); err == nil {
+			caps, _ := r.c.PinCapabilities(pin)
+			for mode := range caps {
+				cands = append(cands, strings.ToLower(mode.String()))
+			}
+			sort.Strings(cands)
+		}
+	case cmd == "pwm" && len(fields) == 1:
+		cands = r.pinNames(firmata.PWM)
+	case cmd == "servo" && len(fields) == 1:
+		cands = r.pinNames(firmata.Servo)
+	case (cmd == "mode" || cmd == "read" || cmd == "write") && len(fields) == 1,
+		cmd == "watch", cmd == "unwatch":
+		cands = r.pinNames(firmata.Ignore)
+	}
+	return
+}
+
+// Names of pins supporting mode, or of all pins if mode is firmata.Ignore.
+// Analog pins are offered both by number and by name.
+func (r *repl) pinNames(mode firmata.PinMode) (names []string) {
+	for pin := 0; pin < r.c.PinCount(); pin++ {
+		if mode != firmata.Ignore && !r.c.SupportsPinMode(uint8(pin), mode) {
+			continue
+		}
+		names = append(names, fmt.Sprint(pin))
+		if ch, ok := r.c.AnalogChannel(uint8(pin)); ok {
+			names = append(names, fmt.Sprintf("A%v", ch))
+		}
+	}
+	return
+}
@@ -0,0 +1,56 @@
+// Copyright 2014 Krishna Raman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/kraman/go-firmata"
+)
+
+// Send a user-defined SysEx message. Payload bytes must be 7-bit.
+func runSysEx(c *firmata.FirmataClient, args []string) error {
+	fs := newFlagSet("sysex")
+	wait := fs.Duration("wait", time.Second, "time to print replies for")
+	args, err := parseArgs(fs, args, -1)
+	if err != nil {
+		return err
+	}
+	if len(args) < 1 {
+		return fmt.Errorf("Expected a command byte")
+	}
+	cmd, err := parseNumber(args[0], int(firmata.UserSysExFirst), int(firmata.UserSysExLast))
+	if err != nil {
+		return err
+	}
+	payload, err := parseBytes(args[1:], 0x7F)
+	if err != nil {
+		return err
+	}
+
+	err = c.RegisterSysExHandler(firmata.SysExCommand(cmd), func(cmd firmata.SysExCommand, data []byte) {
+		fmt.Fprintf(out, "%#02x: % x\n", byte(cmd), data)
+	})
+	if err != nil {
+		return err
+	}
+	defer c.RegisterSysExHandler(firmata.SysExCommand(cmd), nil)
+	if err = c.SendSysEx(firmata.SysExCommand(cmd), payload...); err != nil {
+		return err
+	}
+	time.Sleep(*wait)
+	return nil
+}
@@ -0,0 +1,106 @@
+// Copyright 2014 Krishna Raman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package components
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/kraman/go-firmata"
+	"github.com/kraman/go-firmata/bridge"
+)
+
+// Time between animation steps, about one Firmata sampling interval
+const frameInterval = time.Millisecond * 20
+
+// Runs one background animation of an output at a time. Starting an
+// animation or changing the output directly stops the running one.
+type animator struct {
+	// serializes changes to the output; held while waiting for an animation
+	// to stop, so animations must not take it
+	lock sync.Mutex
+	stop chan struct{}
+	done chan struct{}
+}
+
+// Stop the running animation and wait for it to finish. Caller must hold
+// a.lock.
+func (a *animator) cancel() {
+	if a.stop != nil {
+		close(a.stop)
+		<-a.done
+		a.stop, a.done = nil, nil
+	}
+}
+
+// Start an animation calling step every frame with the time since it
+// started, until step returns false or the animation is stopped. Caller must
+// hold a.lock.
+func (a *animator) start(step func(elapsed time.Duration) bool) {
+	a.cancel()
+	stop, done := make(chan struct{}), make(chan struct{})
+	a.stop, a.done = stop, done
+	go func() {
+		defer close(done)
+		started := time.Now()
+		ticker := time.NewTicker(frameInterval)
+		defer ticker.Stop()
+		if !step(0) {
+			return
+		}
+		for {
+			select {
+			case <-stop:
+				return
+			case now := <-ticker.C:
+				if !step(now.Sub(started)) {
+					return
+				}
+			}
+		}
+	}()
+}
+
+// Get the channel closed when the running animation ends, or nil if none
+// was started. Caller must hold a.lock.
+func (a *animator) finished() <-chan struct{} {
+	return a.done
+}
+
+// Largest PWM value the pin accepts, or 0 if it has no PWM
+func pwmMax(b *bridge.Board, pin int) (max int, err error) {
+	state, err := b.Pin(pin)
+	if err != nil {
+		return
+	}
+	res, ok := state.Modes[firmata.PWM]
+	if !ok {
+		return
+	}
+	// the client writes PWM values as a single byte
+	max = int(math.Min(float64(int(1)<<res-1), 255))
+	return
+}
+
+// PWM value for a brightness from 0 to 1
+func dutyCycle(brightness float64, gamma float64, max int, activeLow bool) int {
+	brightness = math.Max(0, math.Min(1, brightness))
+	value := int(math.Round(math.Pow(brightness, gamma) * float64(max)))
+	if activeLow {
+		value = max - value
+	}
+	return value
+}
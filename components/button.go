@@ -0,0 +1,142 @@
+// Copyright 2014 Krishna Raman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package components
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/kraman/go-firmata/bridge"
+)
+
+type ButtonAction byte
+
+const (
+	Press ButtonAction = iota
+	Release
+	// Button held down for the hold time
+	Hold
+	// Second press within the double click time. Follows the Press event.
+	DoubleClick
+)
+
+func (a ButtonAction) String() string {
+	switch a {
+	case Press:
+		return "press"
+	case Release:
+		return "release"
+	case Hold:
+		return "hold"
+	case DoubleClick:
+		return "double click"
+	}
+	return fmt.Sprintf("ButtonAction(%d)", byte(a))
+}
+
+type ButtonEvent struct {
+	Action ButtonAction
+	Time   time.Time
+}
+
+// Momentary push button on a digital input
+type Button struct {
+	board *bridge.Board
+	pin   int
+	cfg   *config
+	in    *input
+
+	lock      sync.Mutex
+	pressed   bool
+	lastPress time.Time
+	hold      *time.Timer
+	closed    bool
+	events    chan ButtonEvent
+}
+
+// Set up pin as an input for a button. The button is assumed to pull the pin
+// high when pressed, unless WithPullup or WithActiveLow is given.
+func NewButton(b *bridge.Board, pin int, opts ...Option) (button *Button, err error) {
+	button = &Button{board: b, pin: pin, cfg: newConfig(opts), events: make(chan ButtonEvent, eventBuffer)}
+	if button.in, err = newInput(b, pin, button.cfg, button.changed); err != nil {
+		button = nil
+	}
+	return
+}
+
+// Get the channel of button events
+func (b *Button) Events() <-chan ButtonEvent {
+	return b.events
+}
+
+// Check if the button is currently held down
+func (b *Button) Pressed() bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.pressed
+}
+
+// Stop watching the button and close the event channel
+func (b *Button) Close() {
+	b.in.close()
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.hold != nil {
+		b.hold.Stop()
+	}
+	b.closed = true
+	close(b.events)
+}
+
+func (b *Button) changed(pressed bool, at time.Time) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.pressed = pressed
+	if !pressed {
+		if b.hold != nil {
+			b.hold.Stop()
+		}
+		b.send(Release, at)
+		return
+	}
+
+	b.send(Press, at)
+	if !b.lastPress.IsZero() && at.Sub(b.lastPress) <= b.cfg.doubleClick {
+		b.send(DoubleClick, at)
+		// a third press starts a new double click
+		b.lastPress = time.Time{}
+	} else {
+		b.lastPress = at
+	}
+	if b.cfg.holdTime > 0 {
+		b.hold = time.AfterFunc(b.cfg.holdTime, func() {
+			b.lock.Lock()
+			defer b.lock.Unlock()
+			if b.pressed && !b.closed {
+				b.send(Hold, time.Now())
+			}
+		})
+	}
+}
+
+// Caller must hold b.lock
+func (b *Button) send(action ButtonAction, at time.Time) {
+	select {
+	case b.events <- ButtonEvent{action, at}:
+	default:
+		b.board.Log.Warn("Button event buffer overflow. No listener?", "pin", b.pin)
+	}
+}
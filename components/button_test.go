@@ -0,0 +1,147 @@
+// Copyright 2014 Krishna Raman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package components
+
+import (
+	"testing"
+	"time"
+)
+
+// Button without a pin, driven by calling changed directly
+func newDetachedButton(opts ...Option) *Button {
+	return &Button{cfg: newConfig(opts), events: make(chan ButtonEvent, eventBuffer)}
+}
+
+// Drain the button's pending events
+func actions(b *Button) (got []ButtonAction) {
+	for {
+		select {
+		case ev := <-b.events:
+			got = append(got, ev.Action)
+		default:
+			return
+		}
+	}
+}
+
+func equalActions(a, b []ButtonAction) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestButtonDoubleClick(t *testing.T) {
+	t0 := time.Unix(1000, 0)
+	ms := time.Millisecond
+	tests := []struct {
+		name    string
+		presses []time.Duration
+		want    []ButtonAction
+	}{
+		{"single", []time.Duration{0}, []ButtonAction{Press, Release}},
+		{"double", []time.Duration{0, 200 * ms},
+			[]ButtonAction{Press, Release, Press, DoubleClick, Release}},
+		{"too slow", []time.Duration{0, 400 * ms},
+			[]ButtonAction{Press, Release, Press, Release}},
+		{"at the limit", []time.Duration{0, 300 * ms},
+			[]ButtonAction{Press, Release, Press, DoubleClick, Release}},
+		{"third press starts over", []time.Duration{0, 100 * ms, 200 * ms, 300 * ms},
+			[]ButtonAction{Press, Release, Press, DoubleClick, Release,
+				Press, Release, Press, DoubleClick, Release}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			b := newDetachedButton(WithHoldTime(0))
+			for _, at := range test.presses {
+				b.changed(true, t0.Add(at))
+				if !b.Pressed() {
+					t.Fatal("not pressed after press")
+				}
+				b.changed(false, t0.Add(at+10*ms))
+			}
+			if got := actions(b); !equalActions(got, test.want) {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestButtonHold(t *testing.T) {
+	b := newDetachedButton(WithHoldTime(20*time.Millisecond), WithDoubleClickTime(0))
+
+	b.changed(true, time.Now())
+	if got := <-b.events; got.Action != Press {
+		t.Fatalf("got %v, want press", got.Action)
+	}
+	select {
+	case ev := <-b.events:
+		if ev.Action != Hold {
+			t.Fatalf("got %v, want hold", ev.Action)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no hold event")
+	}
+	b.changed(false, time.Now())
+	if got := actions(b); !equalActions(got, []ButtonAction{Release}) {
+		t.Errorf("got %v after release, want only release", got)
+	}
+
+	// released before the hold time
+	b.changed(true, time.Now())
+	b.changed(false, time.Now())
+	time.Sleep(50 * time.Millisecond)
+	if got := actions(b); !equalActions(got, []ButtonAction{Press, Release}) {
+		t.Errorf("got %v for a short press", got)
+	}
+}
+
+func TestButtonOnBoard(t *testing.T) {
+	board, vb := newTestBoard(t)
+	button, err := NewButton(board, 2, WithPullup(), WithDebounce(0), WithHoldTime(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer button.Close()
+
+	next := func(want ButtonAction) {
+		t.Helper()
+		select {
+		case ev := <-button.Events():
+			if ev.Action != want {
+				t.Fatalf("got %v, want %v", ev.Action, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("no %v event", want)
+		}
+	}
+	// the pull-up holds the open input high, so pressing pulls it low
+	eventually(t, "pull-up", func() bool {
+		v, _ := board.Read(2)
+		return v == 1
+	})
+	vb.SetDigitalInput(2, false)
+	next(Press)
+	if !button.Pressed() {
+		t.Error("not pressed while the input is low")
+	}
+	vb.SetDigitalInput(2, true)
+	next(Release)
+}
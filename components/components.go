@@ -0,0 +1,183 @@
+// Copyright 2014 Krishna Raman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Buttons, switches, sensors and other parts wired to a board's pins.
+// Components set up their pins on creation and deliver what happens to them
+// as events, so there is no need to pick values out of port reports.
+//
+// Usage:
+//
+//	b := bridge.New(arduino)
+//	button, err := components.NewButton(b, 2, components.WithPullup())
+//	for ev := range button.Events() {
+//		if ev.Action == components.DoubleClick {
+//			...
+//		}
+//	}
+package components
+
+import (
+	"time"
+
+	"github.com/kraman/go-firmata/bridge"
+)
+
+// Capacity of component event channels
+const eventBuffer = 10
+
+// Shared settings of all components. Each component uses the ones that
+// apply to it.
+type config struct {
+	pullup      bool
+	activeLow   bool
+	debounce    time.Duration
+	holdTime    time.Duration
+	doubleClick time.Duration
+
+	min, max  float64
+	scaled    bool
+	smoothing float64
+	threshold float64
+	filters   []bridge.Filter
+
+	gamma          float64
+	normallyClosed bool
+	interlock      *Interlock
+
+	acceleration float64
+}
+
+type Option func(*config)
+
+func newConfig(opts []Option) *config {
+	cfg := &config{
+		debounce:    time.Millisecond * 20,
+		holdTime:    time.Second,
+		doubleClick: time.Millisecond * 300,
+		smoothing:   1,
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// Enable the board's internal pull-up resistor on an input. The input reads
+// high while open, so it is treated as active low.
+func WithPullup() Option {
+	return func(c *config) {
+		c.pullup = true
+		c.activeLow = true
+	}
+}
+
+// Treat a low level as pressed or on, for inputs with an external pull-up
+// and outputs that sink current
+func WithActiveLow() Option {
+	return func(c *config) {
+		c.activeLow = true
+	}
+}
+
+// Set the time an input must be stable before a change is accepted.
+// Defaults to 20ms; 0 disables debouncing.
+func WithDebounce(d time.Duration) Option {
+	return func(c *config) {
+		c.debounce = d
+	}
+}
+
+// Set the time a button must be held down for a Hold event. Defaults to 1s.
+func WithHoldTime(d time.Duration) Option {
+	return func(c *config) {
+		c.holdTime = d
+	}
+}
+
+// Set the longest time between two presses that counts as a double click.
+// Defaults to 300ms.
+func WithDoubleClickTime(d time.Duration) Option {
+	return func(c *config) {
+		c.doubleClick = d
+	}
+}
+
+// Scale analog readings linearly from the pin's raw range to min..max
+func WithRange(min, max float64) Option {
+	return func(c *config) {
+		c.min, c.max = min, max
+		c.scaled = true
+	}
+}
+
+// Smooth analog readings with an exponential moving average. alpha is the
+// weight of each new reading, from 1 (no smoothing) down towards 0.
+func WithSmoothing(alpha float64) Option {
+	return func(c *config) {
+		c.smoothing = alpha
+	}
+}
+
+// Only report analog values that moved at least delta, in scaled units,
+// since the last reported value
+func WithThreshold(delta float64) Option {
+	return func(c *config) {
+		c.threshold = delta
+	}
+}
+
+// Add stages to an analog sensor's filter pipeline, after smoothing and
+// scaling. Filters keep state, so pass new instances for every sensor.
+func WithFilters(filters ...bridge.Filter) Option {
+	return func(c *config) {
+		c.filters = append(c.filters, filters...)
+	}
+}
+
+// Drive an RGB LED whose common lead is wired to the supply, so each color
+// lights while its pin is low. Same as WithActiveLow.
+func WithCommonAnode() Option {
+	return WithActiveLow()
+}
+
+// Correct brightness for the eye's response: PWM duty cycle is brightness
+// raised to gamma. Defaults to 2.2 for RGB LEDs and 1 (linear) for LEDs.
+func WithGamma(gamma float64) Option {
+	return func(c *config) {
+		c.gamma = gamma
+	}
+}
+
+// Use a relay's normally closed contact, which conducts while the coil is
+// not energized
+func WithNormallyClosed() Option {
+	return func(c *config) {
+		c.normallyClosed = true
+	}
+}
+
+// Add a relay to an interlock group, in which at most one relay is on
+func WithInterlock(group *Interlock) Option {
+	return func(c *config) {
+		c.interlock = group
+	}
+}
+
+// Limit how fast a motor's speed changes, in full speed per second. Speed
+// changes then ramp in the background. By default they are immediate.
+func WithAcceleration(perSecond float64) Option {
+	return func(c *config) {
+		c.acceleration = perSecond
+	}
+}
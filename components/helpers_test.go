@@ -0,0 +1,51 @@
+// Copyright 2014 Krishna Raman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package components
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kraman/go-firmata"
+	"github.com/kraman/go-firmata/bridge"
+	"github.com/kraman/go-firmata/virtual"
+)
+
+func newTestBoard(t *testing.T) (*bridge.Board, *virtual.Board) {
+	t.Helper()
+	vb := virtual.New(virtual.ArduinoUno())
+	c, err := firmata.NewClientWithTransport(vb.Transport())
+	if err != nil {
+		vb.Close()
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		c.Close()
+		vb.Close()
+	})
+	return bridge.New(c), vb
+}
+
+// Wait until cond holds or fail the test after a second
+func eventually(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %v", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
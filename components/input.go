@@ -0,0 +1,68 @@
+// Copyright 2014 Krishna Raman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package components
+
+import (
+	"time"
+
+	"github.com/kraman/go-firmata"
+	"github.com/kraman/go-firmata/bridge"
+)
+
+// Debounced digital input. Calls changed from a single goroutine whenever
+// the active state of the pin settles at a new value.
+type input struct {
+	board  *bridge.Board
+	pin    int
+	cfg    *config
+	cancel func()
+	done   chan struct{}
+}
+
+func newInput(b *bridge.Board, pin int, cfg *config, changed func(active bool, at time.Time)) (in *input, err error) {
+	mode := firmata.Input
+	if cfg.pullup {
+		mode = firmata.InputPullup
+	}
+	// the board holds back changes until they have lasted the debounce time
+	if err = b.SetDebounce(pin, cfg.debounce); err != nil {
+		return
+	}
+	if err = b.SetMode(pin, mode); err != nil {
+		return
+	}
+	events, cancel := b.Subscribe(pin)
+	in = &input{board: b, pin: pin, cfg: cfg, cancel: cancel, done: make(chan struct{})}
+	go in.run(events, changed)
+	return
+}
+
+func (in *input) run(events <-chan bridge.PinEvent, changed func(active bool, at time.Time)) {
+	defer close(in.done)
+	var stable bool
+	for ev := range events {
+		active := (ev.Value != 0) != in.cfg.activeLow
+		if active != stable {
+			stable = active
+			changed(stable, ev.Time)
+		}
+	}
+}
+
+// Stop watching the pin and wait for pending callbacks to finish
+func (in *input) close() {
+	in.cancel()
+	<-in.done
+}
@@ -0,0 +1,225 @@
+// Copyright 2014 Krishna Raman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package components
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/kraman/go-firmata"
+	"github.com/kraman/go-firmata/bridge"
+)
+
+// LED on a digital or PWM output. Brightness can only be set on PWM pins;
+// other pins are switched fully on or off.
+type LED struct {
+	board  *bridge.Board
+	pin    int
+	cfg    *config
+	pwmMax int
+
+	anim animator
+
+	lock       sync.Mutex
+	brightness float64
+}
+
+// Set up pin as an output for an LED, in PWM mode if the pin supports it
+func NewLED(b *bridge.Board, pin int, opts ...Option) (l *LED, err error) {
+	l = &LED{board: b, pin: pin, cfg: newConfig(opts)}
+	if l.cfg.gamma == 0 {
+		l.cfg.gamma = 1
+	}
+	if l.pwmMax, err = pwmMax(b, pin); err != nil {
+		return nil, err
+	}
+	mode := firmata.Output
+	if l.pwmMax > 0 {
+		mode = firmata.PWM
+	}
+	if err = b.SetMode(pin, mode); err != nil {
+		return nil, err
+	}
+	// the pin is low after the mode change, which is on for active low LEDs
+	if err = l.set(0); err != nil {
+		return nil, err
+	}
+	return
+}
+
+// Check if the LED's brightness can be set
+func (l *LED) PWM() bool {
+	return l.pwmMax > 0
+}
+
+// Turn the LED fully on, stopping any animation
+func (l *LED) On() error {
+	return l.SetBrightness(1)
+}
+
+// Turn the LED off, stopping any animation
+func (l *LED) Off() error {
+	return l.SetBrightness(0)
+}
+
+// Turn the LED off if it is on and fully on otherwise, stopping any animation
+func (l *LED) Toggle() error {
+	l.anim.lock.Lock()
+	defer l.anim.lock.Unlock()
+	l.anim.cancel()
+	if l.IsOn() {
+		return l.set(0)
+	}
+	return l.set(1)
+}
+
+// Check if the LED is lit at all
+func (l *LED) IsOn() bool {
+	return l.Brightness() > 0
+}
+
+// Get the brightness from 0 to 1
+func (l *LED) Brightness() float64 {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return l.brightness
+}
+
+// Set the brightness from 0 to 1, stopping any animation. Pins without PWM
+// only accept 0 and 1.
+func (l *LED) SetBrightness(brightness float64) error {
+	if brightness < 0 || brightness > 1 {
+		return fmt.Errorf("Brightness %v out of range 0 to 1", brightness)
+	}
+	if !l.PWM() && brightness != 0 && brightness != 1 {
+		return fmt.Errorf("Pin %v does not support PWM. Brightness must be 0 or 1", l.pin)
+	}
+	l.anim.lock.Lock()
+	defer l.anim.lock.Unlock()
+	l.anim.cancel()
+	return l.set(brightness)
+}
+
+// Blink the LED in the background, on for half of each period
+func (l *LED) Blink(period time.Duration) error {
+	if period < frameInterval*2 {
+		return fmt.Errorf("Blink period %v shorter than %v", period, frameInterval*2)
+	}
+	l.anim.lock.Lock()
+	defer l.anim.lock.Unlock()
+	l.animate(func(elapsed time.Duration) float64 {
+		if elapsed%period < period/2 {
+			return 1
+		}
+		return 0
+	}, 0)
+	return nil
+}
+
+// Change the brightness to the given level over d in the background
+func (l *LED) Fade(brightness float64, d time.Duration) error {
+	if brightness < 0 || brightness > 1 {
+		return fmt.Errorf("Brightness %v out of range 0 to 1", brightness)
+	}
+	if !l.PWM() {
+		return fmt.Errorf("Pin %v does not support PWM", l.pin)
+	}
+	l.anim.lock.Lock()
+	defer l.anim.lock.Unlock()
+	// start from where the running animation stops
+	l.anim.cancel()
+	from := l.Brightness()
+	l.animate(func(elapsed time.Duration) float64 {
+		if elapsed >= d {
+			return brightness
+		}
+		return from + (brightness-from)*float64(elapsed)/float64(d)
+	}, d)
+	return nil
+}
+
+// Fade the LED in and out in the background, once per period
+func (l *LED) Pulse(period time.Duration) error {
+	if !l.PWM() {
+		return fmt.Errorf("Pin %v does not support PWM", l.pin)
+	}
+	if period < frameInterval*2 {
+		return fmt.Errorf("Pulse period %v shorter than %v", period, frameInterval*2)
+	}
+	l.anim.lock.Lock()
+	defer l.anim.lock.Unlock()
+	l.animate(func(elapsed time.Duration) float64 {
+		return (1 - math.Cos(2*math.Pi*float64(elapsed)/float64(period))) / 2
+	}, 0)
+	return nil
+}
+
+// Stop any animation, leaving the LED at its current brightness
+func (l *LED) Stop() {
+	l.anim.lock.Lock()
+	defer l.anim.lock.Unlock()
+	l.anim.cancel()
+}
+
+// Wait for a fade to finish. Returns immediately if no animation is
+// running; blinking and pulsing run until stopped.
+func (l *LED) Wait() {
+	l.anim.lock.Lock()
+	done := l.anim.finished()
+	l.anim.lock.Unlock()
+	if done != nil {
+		<-done
+	}
+}
+
+// Stop any animation and turn the LED off
+func (l *LED) Close() error {
+	return l.Off()
+}
+
+// Run an animation setting the brightness returned by level until d has
+// passed, or forever if d is 0. Caller must hold l.anim.lock.
+func (l *LED) animate(level func(elapsed time.Duration) float64, d time.Duration) {
+	l.anim.start(func(elapsed time.Duration) bool {
+		if err := l.set(level(elapsed)); err != nil {
+			l.board.Log.Warn("LED animation stopped", "pin", l.pin, "err", err)
+			return false
+		}
+		return d == 0 || elapsed < d
+	})
+}
+
+func (l *LED) set(brightness float64) (err error) {
+	if l.PWM() {
+		err = l.board.Write(l.pin, dutyCycle(brightness, l.cfg.gamma, l.pwmMax, l.cfg.activeLow))
+	} else {
+		on := brightness >= 0.5
+		if on != l.cfg.activeLow {
+			err = l.board.Write(l.pin, 1)
+		} else {
+			err = l.board.Write(l.pin, 0)
+		}
+		brightness = math.Round(brightness)
+	}
+	if err != nil {
+		return
+	}
+	l.lock.Lock()
+	l.brightness = brightness
+	l.lock.Unlock()
+	return
+}
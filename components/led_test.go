@@ -0,0 +1,144 @@
+// Copyright 2014 Krishna Raman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package components
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kraman/go-firmata"
+)
+
+func TestLEDOnOffToggle(t *testing.T) {
+	board, vb := newTestBoard(t)
+	l, err := NewLED(board, 13)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.PWM() || board.Pins()[13].Mode != firmata.Output {
+		t.Errorf("pin 13 set up in mode %v", board.Pins()[13].Mode)
+	}
+	events, cancel := board.Subscribe(13)
+	defer cancel()
+
+	if err = l.On(); err != nil {
+		t.Fatal(err)
+	}
+	expectWrites(t, events, pinWrite{13, 1})
+	if !l.IsOn() {
+		t.Error("LED off after On")
+	}
+	if err = l.Toggle(); err != nil {
+		t.Fatal(err)
+	}
+	expectWrites(t, events, pinWrite{13, 0})
+	if err = l.Toggle(); err != nil {
+		t.Fatal(err)
+	}
+	expectWrites(t, events, pinWrite{13, 1})
+	if err = l.Off(); err != nil {
+		t.Fatal(err)
+	}
+	expectWrites(t, events, pinWrite{13, 0})
+	eventually(t, "pin 13 low", func() bool { return !vb.DigitalOutput(13) })
+
+	if err = l.SetBrightness(0.5); err == nil {
+		t.Error("brightness 0.5 accepted on a pin without PWM")
+	}
+	if err = l.Fade(1, time.Millisecond*50); err == nil {
+		t.Error("fade accepted on a pin without PWM")
+	}
+}
+
+func TestLEDBrightness(t *testing.T) {
+	board, _ := newTestBoard(t)
+	l, err := NewLED(board, 9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inverted, err := NewLED(board, 10, WithActiveLow())
+	if err != nil {
+		t.Fatal(err)
+	}
+	events, cancel := board.Subscribe(9, 10)
+	defer cancel()
+
+	if err = l.SetBrightness(0.5); err != nil {
+		t.Fatal(err)
+	}
+	if err = inverted.SetBrightness(0.5); err != nil {
+		t.Fatal(err)
+	}
+	expectWrites(t, events, pinWrite{9, 128}, pinWrite{10, 127})
+	if l.Brightness() != 0.5 {
+		t.Errorf("brightness %v", l.Brightness())
+	}
+	if err = inverted.Off(); err != nil {
+		t.Fatal(err)
+	}
+	expectWrites(t, events, pinWrite{10, 255})
+
+	if err = l.SetBrightness(1.5); err == nil {
+		t.Error("brightness 1.5 accepted")
+	}
+	expectWrites(t, events)
+}
+
+func TestLEDFade(t *testing.T) {
+	board, vb := newTestBoard(t)
+	l, err := NewLED(board, 9)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = l.Fade(0.5, time.Millisecond*100); err != nil {
+		t.Fatal(err)
+	}
+	l.Wait()
+	if l.Brightness() != 0.5 {
+		t.Errorf("brightness %v after fade", l.Brightness())
+	}
+	eventually(t, "duty 128", func() bool { return vb.AnalogOutput(9) == 128 })
+}
+
+func TestLEDAnimationStopsPrevious(t *testing.T) {
+	board, _ := newTestBoard(t)
+	l, err := NewLED(board, 9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	events, cancel := board.Subscribe(9)
+	defer cancel()
+
+	// blinking never ends, so Wait only returns once the fade replaced it
+	if err = l.Blink(time.Millisecond * 60); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(frameInterval * 3)
+	if err = l.Fade(0.2, time.Millisecond*60); err != nil {
+		t.Fatal(err)
+	}
+	l.Wait()
+	if l.Brightness() != 0.2 {
+		t.Errorf("brightness %v after fade", l.Brightness())
+	}
+	got := drainWrites(events)
+	if len(got) == 0 || got[len(got)-1] != (pinWrite{9, 51}) {
+		t.Errorf("writes ended with %v", got)
+	}
+
+	time.Sleep(frameInterval * 3)
+	expectWrites(t, events)
+}
@@ -0,0 +1,283 @@
+// Copyright 2014 Krishna Raman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package components
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/kraman/go-firmata"
+	"github.com/kraman/go-firmata/bridge"
+)
+
+type motorWiring byte
+
+const (
+	// One PWM pin switching a transistor or MOSFET
+	singleDirection motorWiring = iota
+	// Two PWM inputs of a bridge, such as the IN pins of a DRV8833 or an
+	// L298N with its enable pin tied high
+	dualPin
+	// PWM enable pin plus one or two direction pins, as on an L298N or
+	// TB6612
+	pwmDir
+)
+
+// Direction of a motor whose pins are set to brake
+const braking = 2
+
+// DC motor driven through a transistor or an H-bridge. Speed runs from -1
+// (full reverse) to 1 (full forward).
+type Motor struct {
+	board  *bridge.Board
+	cfg    *config
+	wiring motorWiring
+	// PWM pins: the speed pin, or IN1 and IN2 for dual pin wiring
+	pwm    [2]int
+	pwmMax [2]int
+	// direction pins for PWM and direction wiring, -1 if absent
+	dir [2]int
+	// sign of the speed the pins are set up for, or braking. Only used
+	// while driving, which the animator serializes.
+	direction int
+
+	anim animator
+
+	lock  sync.Mutex
+	speed float64
+}
+
+// Set up a motor switched by a transistor on a PWM pin. It only runs
+// forward and coasts when stopped.
+func NewMotor(b *bridge.Board, pin int, opts ...Option) (*Motor, error) {
+	return newMotor(b, singleDirection, [2]int{pin, -1}, [2]int{-1, -1}, opts)
+}
+
+// Set up a motor on an H-bridge driven by two PWM pins, one per direction
+func NewDualPinMotor(b *bridge.Board, in1, in2 int, opts ...Option) (*Motor, error) {
+	return newMotor(b, dualPin, [2]int{in1, in2}, [2]int{-1, -1}, opts)
+}
+
+// Set up a motor on an H-bridge with a PWM speed pin and the direction
+// pins in1 and in2, such as an L298N or TB6612. Pass -1 for in2 on drivers
+// with a single direction input; those cannot brake.
+func NewHBridgeMotor(b *bridge.Board, pwm, in1, in2 int, opts ...Option) (*Motor, error) {
+	return newMotor(b, pwmDir, [2]int{pwm, -1}, [2]int{in1, in2}, opts)
+}
+
+// Get the current speed. While ramping it lags the speed last set.
+func (m *Motor) Speed() float64 {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.speed
+}
+
+// Set the speed from -1 to 1, ramping to it in the background if
+// WithAcceleration is given. Stops any running ramp.
+func (m *Motor) SetSpeed(speed float64) error {
+	if speed < -1 || speed > 1 {
+		return fmt.Errorf("Speed %v out of range -1 to 1", speed)
+	}
+	if speed < 0 && m.wiring == singleDirection {
+		return fmt.Errorf("Motor on pin %v only runs forward", m.pwm[0])
+	}
+
+	m.anim.lock.Lock()
+	defer m.anim.lock.Unlock()
+	if m.cfg.acceleration <= 0 {
+		m.anim.cancel()
+		return m.drive(speed)
+	}
+	from := m.Speed()
+	m.anim.start(func(elapsed time.Duration) bool {
+		step := m.cfg.acceleration * elapsed.Seconds()
+		current := speed
+		if math.Abs(speed-from) > step {
+			current = from + math.Copysign(step, speed-from)
+		}
+		if err := m.drive(current); err != nil {
+			m.board.Log.Warn("Motor ramp stopped", "pin", m.pwm[0], "err", err)
+			return false
+		}
+		return current != speed
+	})
+	return nil
+}
+
+// Let the motor spin down freely, stopping any ramp
+func (m *Motor) Coast() error {
+	m.anim.lock.Lock()
+	defer m.anim.lock.Unlock()
+	m.anim.cancel()
+	return m.drive(0)
+}
+
+// Stop the motor quickly by shorting its terminals, stopping any ramp.
+// Single direction motors and drivers with one direction pin cannot brake.
+func (m *Motor) Brake() error {
+	m.anim.lock.Lock()
+	defer m.anim.lock.Unlock()
+	m.anim.cancel()
+	return m.brake()
+}
+
+// Wait for a ramp to finish. Returns immediately if none is running.
+func (m *Motor) Wait() {
+	m.anim.lock.Lock()
+	done := m.anim.finished()
+	m.anim.lock.Unlock()
+	if done != nil {
+		<-done
+	}
+}
+
+// Stop the motor immediately without ramping, braking if the wiring allows
+// and coasting otherwise
+func (m *Motor) Close() error {
+	m.anim.lock.Lock()
+	defer m.anim.lock.Unlock()
+	m.anim.cancel()
+	if m.canBrake() {
+		return m.brake()
+	}
+	return m.drive(0)
+}
+
+func newMotor(b *bridge.Board, wiring motorWiring, pwm, dir [2]int, opts []Option) (m *Motor, err error) {
+	m = &Motor{board: b, cfg: newConfig(opts), wiring: wiring, pwm: pwm, dir: dir, direction: braking}
+	if err = m.setup(); err != nil {
+		return nil, err
+	}
+	return
+}
+
+func (m *Motor) setup() (err error) {
+	for i, pin := range m.pwm {
+		if pin < 0 {
+			continue
+		}
+		if m.pwmMax[i], err = pwmMax(m.board, pin); err != nil {
+			return
+		}
+		if m.pwmMax[i] == 0 {
+			return fmt.Errorf("Pin %v does not support PWM", pin)
+		}
+		if err = m.board.SetMode(pin, firmata.PWM); err != nil {
+			return
+		}
+	}
+	for _, pin := range m.dir {
+		if pin < 0 {
+			continue
+		}
+		if err = m.board.SetMode(pin, firmata.Output); err != nil {
+			return
+		}
+	}
+	return m.drive(0)
+}
+
+func (m *Motor) canBrake() bool {
+	return m.wiring == dualPin || (m.wiring == pwmDir && m.dir[1] >= 0)
+}
+
+// Run at speed, coasting at 0. When the direction changes, pins that stop
+// driving are written first so both sides of a bridge are never on at once.
+func (m *Motor) drive(speed float64) (err error) {
+	direction := 0
+	switch {
+	case speed > 0:
+		direction = 1
+	case speed < 0:
+		direction = -1
+	}
+	duty := func(i int) int {
+		return int(math.Round(math.Abs(speed) * float64(m.pwmMax[i])))
+	}
+
+	switch m.wiring {
+	case singleDirection:
+		err = m.write([]int{m.pwm[0]}, []int{duty(0)})
+	case dualPin:
+		switch direction {
+		case 1:
+			err = m.write([]int{m.pwm[1], m.pwm[0]}, []int{0, duty(0)})
+		case -1:
+			err = m.write([]int{m.pwm[0], m.pwm[1]}, []int{0, duty(1)})
+		default:
+			err = m.write(m.pwm[:], []int{0, 0})
+		}
+	case pwmDir:
+		if direction != m.direction {
+			if err = m.write([]int{m.pwm[0]}, []int{0}); err != nil {
+				return
+			}
+			// a single direction input is high for forward
+			in := []int{0, 0}
+			if direction == 1 {
+				in[0] = 1
+			} else if direction == -1 {
+				in[1] = 1
+			}
+			for i, pin := range m.dir {
+				if pin < 0 {
+					continue
+				}
+				if err = m.board.Write(pin, in[i]); err != nil {
+					return
+				}
+			}
+		}
+		err = m.write([]int{m.pwm[0]}, []int{duty(0)})
+	}
+	if err != nil {
+		return
+	}
+	m.direction = direction
+	m.lock.Lock()
+	m.speed = speed
+	m.lock.Unlock()
+	return
+}
+
+func (m *Motor) brake() (err error) {
+	switch {
+	case m.wiring == dualPin:
+		err = m.write(m.pwm[:], []int{m.pwmMax[0], m.pwmMax[1]})
+	case m.canBrake():
+		err = m.write([]int{m.dir[0], m.dir[1], m.pwm[0]}, []int{1, 1, m.pwmMax[0]})
+	default:
+		return fmt.Errorf("Motor on pin %v cannot brake", m.pwm[0])
+	}
+	if err != nil {
+		return
+	}
+	m.direction = braking
+	m.lock.Lock()
+	m.speed = 0
+	m.lock.Unlock()
+	return
+}
+
+func (m *Motor) write(pins []int, values []int) error {
+	for i, pin := range pins {
+		if err := m.board.Write(pin, values[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
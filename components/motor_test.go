@@ -0,0 +1,204 @@
+// Copyright 2014 Krishna Raman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package components
+
+import (
+	"testing"
+
+	"github.com/kraman/go-firmata/bridge"
+)
+
+type pinWrite struct {
+	pin   int
+	value int
+}
+
+// Drain the writes recorded on events. Board.Write notifies subscribers
+// before returning, so all writes of a completed call are queued.
+func drainWrites(events <-chan bridge.PinEvent) (got []pinWrite) {
+	for {
+		select {
+		case ev := <-events:
+			got = append(got, pinWrite{ev.Pin, ev.Value})
+		default:
+			return
+		}
+	}
+}
+
+func expectWrites(t *testing.T, events <-chan bridge.PinEvent, want ...pinWrite) {
+	t.Helper()
+	got := drainWrites(events)
+	if len(got) != len(want) {
+		t.Fatalf("got writes %v, want %v", got, want)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Fatalf("got writes %v, want %v", got, want)
+		}
+	}
+}
+
+func TestHBridgeMotorDirection(t *testing.T) {
+	board, _ := newTestBoard(t)
+	m, err := NewHBridgeMotor(board, 9, 7, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	events, cancel := board.Subscribe(9, 7, 8)
+	defer cancel()
+
+	// the direction pins only change while the PWM pin is off
+	if err = m.SetSpeed(0.5); err != nil {
+		t.Fatal(err)
+	}
+	expectWrites(t, events, pinWrite{7, 1}, pinWrite{9, 128})
+	if err = m.SetSpeed(0.25); err != nil {
+		t.Fatal(err)
+	}
+	expectWrites(t, events, pinWrite{9, 64})
+	if err = m.SetSpeed(-1); err != nil {
+		t.Fatal(err)
+	}
+	expectWrites(t, events, pinWrite{9, 0}, pinWrite{7, 0}, pinWrite{8, 1}, pinWrite{9, 255})
+	if m.Speed() != -1 {
+		t.Errorf("speed %v after reversing", m.Speed())
+	}
+
+	if err = m.Brake(); err != nil {
+		t.Fatal(err)
+	}
+	expectWrites(t, events, pinWrite{7, 1})
+	if m.Speed() != 0 {
+		t.Errorf("speed %v while braking", m.Speed())
+	}
+	if err = m.SetSpeed(0.5); err != nil {
+		t.Fatal(err)
+	}
+	expectWrites(t, events, pinWrite{9, 0}, pinWrite{8, 0}, pinWrite{9, 128})
+
+	if err = m.Coast(); err != nil {
+		t.Fatal(err)
+	}
+	expectWrites(t, events, pinWrite{9, 0}, pinWrite{7, 0})
+}
+
+func TestDualPinMotorDirection(t *testing.T) {
+	board, _ := newTestBoard(t)
+	m, err := NewDualPinMotor(board, 5, 6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	events, cancel := board.Subscribe(5, 6)
+	defer cancel()
+
+	// the input that stops driving is written first
+	if err = m.SetSpeed(0.5); err != nil {
+		t.Fatal(err)
+	}
+	expectWrites(t, events, pinWrite{5, 128})
+	if err = m.SetSpeed(-0.5); err != nil {
+		t.Fatal(err)
+	}
+	expectWrites(t, events, pinWrite{5, 0}, pinWrite{6, 128})
+	if err = m.SetSpeed(1); err != nil {
+		t.Fatal(err)
+	}
+	expectWrites(t, events, pinWrite{6, 0}, pinWrite{5, 255})
+
+	if err = m.Brake(); err != nil {
+		t.Fatal(err)
+	}
+	expectWrites(t, events, pinWrite{6, 255})
+	if err = m.Close(); err != nil {
+		t.Fatal(err)
+	}
+	expectWrites(t, events)
+}
+
+func TestMotorRampReverses(t *testing.T) {
+	board, _ := newTestBoard(t)
+	m, err := NewHBridgeMotor(board, 9, 7, 8, WithAcceleration(20))
+	if err != nil {
+		t.Fatal(err)
+	}
+	events, cancel := board.Subscribe(9, 7, 8)
+	defer cancel()
+
+	if err = m.SetSpeed(1); err != nil {
+		t.Fatal(err)
+	}
+	m.Wait()
+	if err = m.SetSpeed(-1); err != nil {
+		t.Fatal(err)
+	}
+	m.Wait()
+	if m.Speed() != -1 {
+		t.Fatalf("speed %v after ramp", m.Speed())
+	}
+
+	// the ramp passes through zero and never switches direction under power
+	duty := 0
+	for _, w := range drainWrites(events) {
+		switch w.pin {
+		case 9:
+			duty = w.value
+		default:
+			if duty != 0 {
+				t.Errorf("direction pin %v set to %v at duty cycle %v", w.pin, w.value, duty)
+			}
+		}
+	}
+}
+
+func TestMotorLimits(t *testing.T) {
+	board, _ := newTestBoard(t)
+
+	single, err := NewMotor(board, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = single.SetSpeed(-0.5); err == nil {
+		t.Error("single direction motor ran in reverse")
+	}
+	if err = single.Brake(); err == nil {
+		t.Error("single direction motor braked")
+	}
+	if err = single.SetSpeed(1.5); err == nil {
+		t.Error("speed out of range accepted")
+	}
+
+	oneDir, err := NewHBridgeMotor(board, 10, 12, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = oneDir.SetSpeed(-1); err != nil {
+		t.Fatal(err)
+	}
+	if err = oneDir.Brake(); err == nil {
+		t.Error("motor with one direction pin braked")
+	}
+	// coasts instead of braking
+	if err = oneDir.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := board.Read(10); v != 0 {
+		t.Errorf("PWM pin at %v after close", v)
+	}
+
+	if _, err = NewMotor(board, 4); err == nil {
+		t.Error("motor set up on a pin without PWM")
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright 2014 Krishna Raman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package components
+
+import (
+	"sync"
+	"time"
+
+	"github.com/kraman/go-firmata"
+	"github.com/kraman/go-firmata/bridge"
+)
+
+// Group of relays of which at most one is on, such as the forward and
+// reverse relays of a motor. Turning a relay on turns the others off first.
+type Interlock struct {
+	deadTime time.Duration
+
+	lock   sync.Mutex
+	active *Relay
+}
+
+// Create an interlock group that waits deadTime between turning one relay
+// off and the next on, giving the contacts time to open
+func NewInterlock(deadTime time.Duration) *Interlock {
+	return &Interlock{deadTime: deadTime}
+}
+
+// Relay on a digital output
+type Relay struct {
+	board *bridge.Board
+	pin   int
+	cfg   *config
+
+	lock sync.Mutex
+	on   bool
+}
+
+// Set up pin as an output for a relay and switch it off. The relay is
+// assumed to energize its coil while the pin is high and to be wired to its
+// normally open contact, unless WithActiveLow or WithNormallyClosed is
+// given.
+func NewRelay(b *bridge.Board, pin int, opts ...Option) (r *Relay, err error) {
+	r = &Relay{board: b, pin: pin, cfg: newConfig(opts)}
+	if err = b.SetMode(pin, firmata.Output); err != nil {
+		return nil, err
+	}
+	if err = r.set(false); err != nil {
+		return nil, err
+	}
+	return
+}
+
+// Close the circuit. Within an interlock group, the relay that was on is
+// switched off first.
+func (r *Relay) On() error {
+	group := r.cfg.interlock
+	if group == nil {
+		return r.set(true)
+	}
+
+	group.lock.Lock()
+	defer group.lock.Unlock()
+	if group.active == r {
+		return nil
+	}
+	if group.active != nil {
+		if err := group.active.set(false); err != nil {
+			return err
+		}
+		group.active = nil
+		time.Sleep(group.deadTime)
+	}
+	if err := r.set(true); err != nil {
+		return err
+	}
+	group.active = r
+	return nil
+}
+
+// Open the circuit
+func (r *Relay) Off() error {
+	group := r.cfg.interlock
+	if group == nil {
+		return r.set(false)
+	}
+
+	group.lock.Lock()
+	defer group.lock.Unlock()
+	if err := r.set(false); err != nil {
+		return err
+	}
+	if group.active == r {
+		group.active = nil
+	}
+	return nil
+}
+
+// Switch the relay off if it is on and on otherwise
+func (r *Relay) Toggle() error {
+	if r.IsOn() {
+		return r.Off()
+	}
+	return r.On()
+}
+
+// Check if the circuit is closed
+func (r *Relay) IsOn() bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.on
+}
+
+// Switch the relay off
+func (r *Relay) Close() error {
+	return r.Off()
+}
+
+func (r *Relay) set(on bool) error {
+	energized := on != r.cfg.normallyClosed
+	value := 0
+	if energized != r.cfg.activeLow {
+		value = 1
+	}
+	if err := r.board.Write(r.pin, value); err != nil {
+		return err
+	}
+	r.lock.Lock()
+	r.on = on
+	r.lock.Unlock()
+	return nil
+}
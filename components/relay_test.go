@@ -0,0 +1,85 @@
+// Copyright 2014 Krishna Raman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package components
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRelay(t *testing.T) {
+	board, _ := newTestBoard(t)
+	events, cancel := board.Subscribe(7, 8, 12)
+	defer cancel()
+
+	plain, err := NewRelay(board, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	closed, err := NewRelay(board, 8, WithNormallyClosed())
+	if err != nil {
+		t.Fatal(err)
+	}
+	both, err := NewRelay(board, 12, WithNormallyClosed(), WithActiveLow())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// off energizes the coil of a normally closed relay to open the circuit
+	expectWrites(t, events, pinWrite{7, 0}, pinWrite{8, 1}, pinWrite{12, 0})
+
+	for _, r := range []*Relay{plain, closed, both} {
+		if err = r.On(); err != nil {
+			t.Fatal(err)
+		}
+		if !r.IsOn() {
+			t.Errorf("relay on pin %v off after On", r.pin)
+		}
+	}
+	expectWrites(t, events, pinWrite{7, 1}, pinWrite{8, 0}, pinWrite{12, 1})
+
+	if err = closed.Toggle(); err != nil {
+		t.Fatal(err)
+	}
+	expectWrites(t, events, pinWrite{8, 1})
+	if closed.IsOn() {
+		t.Error("normally closed relay on after toggling off")
+	}
+}
+
+func TestRelayInterlock(t *testing.T) {
+	board, _ := newTestBoard(t)
+	group := NewInterlock(time.Millisecond)
+	forward, err := NewRelay(board, 7, WithInterlock(group))
+	if err != nil {
+		t.Fatal(err)
+	}
+	reverse, err := NewRelay(board, 8, WithInterlock(group))
+	if err != nil {
+		t.Fatal(err)
+	}
+	events, cancel := board.Subscribe(7, 8)
+	defer cancel()
+
+	if err = forward.On(); err != nil {
+		t.Fatal(err)
+	}
+	if err = reverse.On(); err != nil {
+		t.Fatal(err)
+	}
+	expectWrites(t, events, pinWrite{7, 1}, pinWrite{7, 0}, pinWrite{8, 1})
+	if forward.IsOn() || !reverse.IsOn() {
+		t.Errorf("forward on %v, reverse on %v", forward.IsOn(), reverse.IsOn())
+	}
+}
@@ -0,0 +1,218 @@
+// Copyright 2014 Krishna Raman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package components
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kraman/go-firmata"
+	"github.com/kraman/go-firmata/bridge"
+)
+
+// Red, green and blue intensity from 0 to 255
+type Color struct {
+	R, G, B uint8
+}
+
+// Parse a color in #rrggbb or #rgb notation. The # is optional.
+func ParseColor(s string) (c Color, err error) {
+	hex := strings.TrimPrefix(s, "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil || len(hex) != 6 {
+		err = fmt.Errorf("Invalid color %q. Expected #rrggbb or #rgb", s)
+		return
+	}
+	c = Color{uint8(v >> 16), uint8(v >> 8), uint8(v)}
+	return
+}
+
+// Color for a hue in degrees and saturation and value from 0 to 1
+func HSV(hue, saturation, value float64) Color {
+	hue = math.Mod(hue, 360)
+	if hue < 0 {
+		hue += 360
+	}
+	saturation = math.Max(0, math.Min(1, saturation))
+	value = math.Max(0, math.Min(1, value))
+
+	chroma := value * saturation
+	x := chroma * (1 - math.Abs(math.Mod(hue/60, 2)-1))
+	var r, g, b float64
+	switch {
+	case hue < 60:
+		r, g = chroma, x
+	case hue < 120:
+		r, g = x, chroma
+	case hue < 180:
+		g, b = chroma, x
+	case hue < 240:
+		g, b = x, chroma
+	case hue < 300:
+		r, b = x, chroma
+	default:
+		r, b = chroma, x
+	}
+	m := value - chroma
+	return Color{
+		uint8(math.Round((r + m) * 255)),
+		uint8(math.Round((g + m) * 255)),
+		uint8(math.Round((b + m) * 255)),
+	}
+}
+
+func (c Color) String() string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// RGB LED on three PWM pins
+type RGBLED struct {
+	board  *bridge.Board
+	pins   [3]int
+	pwmMax [3]int
+	cfg    *config
+
+	anim animator
+
+	lock  sync.Mutex
+	color Color
+}
+
+// Set up the red, green and blue pins of an RGB LED in PWM mode. The LED is
+// assumed to have a common cathode unless WithCommonAnode is given.
+func NewRGBLED(b *bridge.Board, red, green, blue int, opts ...Option) (l *RGBLED, err error) {
+	l = &RGBLED{board: b, pins: [3]int{red, green, blue}, cfg: newConfig(opts)}
+	if l.cfg.gamma == 0 {
+		l.cfg.gamma = 2.2
+	}
+	// check all pins before changing the mode of any
+	for i, pin := range l.pins {
+		if l.pwmMax[i], err = pwmMax(b, pin); err != nil {
+			return nil, err
+		}
+		if l.pwmMax[i] == 0 {
+			return nil, fmt.Errorf("Pin %v does not support PWM", pin)
+		}
+	}
+	for _, pin := range l.pins {
+		if err = b.SetMode(pin, firmata.PWM); err != nil {
+			return nil, err
+		}
+	}
+	if err = l.set(Color{}); err != nil {
+		return nil, err
+	}
+	return
+}
+
+// Get the current color
+func (l *RGBLED) Color() Color {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return l.color
+}
+
+// Set the color, stopping any animation
+func (l *RGBLED) SetColor(c Color) error {
+	l.anim.lock.Lock()
+	defer l.anim.lock.Unlock()
+	l.anim.cancel()
+	return l.set(c)
+}
+
+// Set the color in #rrggbb or #rgb notation, stopping any animation
+func (l *RGBLED) SetHex(s string) error {
+	c, err := ParseColor(s)
+	if err != nil {
+		return err
+	}
+	return l.SetColor(c)
+}
+
+// Set the color by hue in degrees and saturation and value from 0 to 1,
+// stopping any animation
+func (l *RGBLED) SetHSV(hue, saturation, value float64) error {
+	return l.SetColor(HSV(hue, saturation, value))
+}
+
+// Turn the LED off, stopping any animation
+func (l *RGBLED) Off() error {
+	return l.SetColor(Color{})
+}
+
+// Change to the given color over d in the background
+func (l *RGBLED) Fade(c Color, d time.Duration) {
+	l.anim.lock.Lock()
+	defer l.anim.lock.Unlock()
+	// start from where the running animation stops
+	l.anim.cancel()
+	from := l.Color()
+	l.anim.start(func(elapsed time.Duration) bool {
+		t := 1.0
+		if elapsed < d {
+			t = float64(elapsed) / float64(d)
+		}
+		mix := func(a, b uint8) uint8 {
+			return uint8(math.Round(float64(a) + (float64(b)-float64(a))*t))
+		}
+		if err := l.set(Color{mix(from.R, c.R), mix(from.G, c.G), mix(from.B, c.B)}); err != nil {
+			l.board.Log.Warn("RGB LED animation stopped", "pins", l.pins, "err", err)
+			return false
+		}
+		return t < 1
+	})
+}
+
+// Stop any animation, leaving the LED at its current color
+func (l *RGBLED) Stop() {
+	l.anim.lock.Lock()
+	defer l.anim.lock.Unlock()
+	l.anim.cancel()
+}
+
+// Wait for a fade to finish. Returns immediately if none is running.
+func (l *RGBLED) Wait() {
+	l.anim.lock.Lock()
+	done := l.anim.finished()
+	l.anim.lock.Unlock()
+	if done != nil {
+		<-done
+	}
+}
+
+// Stop any animation and turn the LED off
+func (l *RGBLED) Close() error {
+	return l.Off()
+}
+
+func (l *RGBLED) set(c Color) error {
+	for i, v := range []uint8{c.R, c.G, c.B} {
+		duty := dutyCycle(float64(v)/255, l.cfg.gamma, l.pwmMax[i], l.cfg.activeLow)
+		if err := l.board.Write(l.pins[i], duty); err != nil {
+			return err
+		}
+	}
+	l.lock.Lock()
+	l.color = c
+	l.lock.Unlock()
+	return nil
+}
@@ -0,0 +1,104 @@
+// Copyright 2014 Krishna Raman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package components
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRGBLEDGamma(t *testing.T) {
+	board, _ := newTestBoard(t)
+	l, err := NewRGBLED(board, 9, 10, 11)
+	if err != nil {
+		t.Fatal(err)
+	}
+	linear, err := NewRGBLED(board, 3, 5, 6, WithGamma(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	events, cancel := board.Subscribe(9, 10, 11, 3, 5, 6)
+	defer cancel()
+
+	if err = l.SetColor(Color{255, 128, 64}); err != nil {
+		t.Fatal(err)
+	}
+	expectWrites(t, events, pinWrite{9, 255}, pinWrite{10, 56}, pinWrite{11, 12})
+	if err = linear.SetHex("#ff8040"); err != nil {
+		t.Fatal(err)
+	}
+	expectWrites(t, events, pinWrite{3, 255}, pinWrite{5, 128}, pinWrite{6, 64})
+	if c := l.Color(); c != (Color{255, 128, 64}) {
+		t.Errorf("color %v", c)
+	}
+}
+
+func TestRGBLEDCommonAnode(t *testing.T) {
+	board, _ := newTestBoard(t)
+	events, cancel := board.Subscribe(9, 10, 11)
+	defer cancel()
+
+	l, err := NewRGBLED(board, 9, 10, 11, WithCommonAnode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// off is a full duty cycle when the pins sink the current
+	expectWrites(t, events, pinWrite{9, 255}, pinWrite{10, 255}, pinWrite{11, 255})
+
+	if err = l.SetColor(Color{255, 128, 0}); err != nil {
+		t.Fatal(err)
+	}
+	// blue stays at its full duty cycle, so only red and green change
+	expectWrites(t, events, pinWrite{9, 0}, pinWrite{10, 199})
+	if v, _ := board.Read(11); v != 255 {
+		t.Errorf("blue pin at %v", v)
+	}
+}
+
+func TestRGBLEDFade(t *testing.T) {
+	board, vb := newTestBoard(t)
+	l, err := NewRGBLED(board, 9, 10, 11, WithGamma(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = l.SetColor(Color{255, 0, 0}); err != nil {
+		t.Fatal(err)
+	}
+
+	l.Fade(Color{0, 0, 200}, time.Millisecond*100)
+	l.Wait()
+	if c := l.Color(); c != (Color{0, 0, 200}) {
+		t.Errorf("color %v after fade", c)
+	}
+	eventually(t, "blue at 200", func() bool {
+		return vb.AnalogOutput(9) == 0 && vb.AnalogOutput(10) == 0 && vb.AnalogOutput(11) == 200
+	})
+}
+
+func TestRGBLEDPinsCheckedFirst(t *testing.T) {
+	board, _ := newTestBoard(t)
+	before := board.Pins()
+
+	// pin 13 has no PWM, so pins 9 and 10 must be left alone
+	if _, err := NewRGBLED(board, 9, 10, 13); err == nil {
+		t.Fatal("RGB LED on a pin without PWM created")
+	}
+	after := board.Pins()
+	for _, pin := range []int{9, 10} {
+		if after[pin].Mode != before[pin].Mode {
+			t.Errorf("pin %v changed from mode %v to %v", pin, before[pin].Mode, after[pin].Mode)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+// Copyright 2014 Krishna Raman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package components
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/kraman/go-firmata"
+	"github.com/kraman/go-firmata/bridge"
+)
+
+type SensorEvent struct {
+	// Scaled and smoothed value
+	Value float64
+	// Reading the value was computed from
+	Raw  int
+	Time time.Time
+}
+
+// Analog sensor such as a potentiometer, photoresistor or thermistor
+type Sensor struct {
+	board  *bridge.Board
+	pin    int
+	cfg    *config
+	cancel func()
+	done   chan struct{}
+
+	lock     sync.Mutex
+	raw      int
+	value    float64
+	reported float64
+	valid    bool
+	events   chan SensorEvent
+}
+
+// Set up pin as an analog input. Values are raw readings unless WithRange
+// is given.
+func NewSensor(b *bridge.Board, pin int, opts ...Option) (s *Sensor, err error) {
+	cfg := newConfig(opts)
+	if cfg.smoothing <= 0 || cfg.smoothing > 1 {
+		err = fmt.Errorf("Smoothing factor %v out of range (0, 1]", cfg.smoothing)
+		return
+	}
+	state, err := b.Pin(pin)
+	if err != nil {
+		return
+	}
+	rawMax := 1<<state.Modes[firmata.Analog] - 1
+
+	// the board smooths and scales every reading, not just the changes
+	var filters []bridge.Filter
+	if cfg.smoothing < 1 {
+		filters = append(filters, bridge.Exponential(cfg.smoothing))
+	}
+	if cfg.scaled && rawMax > 0 {
+		filters = append(filters, bridge.Linear(0, float64(rawMax), cfg.min, cfg.max))
+	}
+	filters = append(filters, cfg.filters...)
+	if err = b.SetFilters(pin, filters...); err != nil {
+		return
+	}
+	if err = b.SetMode(pin, firmata.Analog); err != nil {
+		return
+	}
+
+	s = &Sensor{
+		board:  b,
+		pin:    pin,
+		cfg:    cfg,
+		done:   make(chan struct{}),
+		events: make(chan SensorEvent, eventBuffer),
+	}
+	var events <-chan bridge.PinEvent
+	events, s.cancel = b.Subscribe(pin)
+	go s.run(events)
+	return
+}
+
+// Set up pin as an analog input for a potentiometer, with values from 0 to
+// 1 unless WithRange is given
+func NewPotentiometer(b *bridge.Board, pin int, opts ...Option) (*Sensor, error) {
+	return NewSensor(b, pin, append([]Option{WithRange(0, 1)}, opts...)...)
+}
+
+// Get the channel of value changes beyond the threshold
+func (s *Sensor) Events() <-chan SensorEvent {
+	return s.events
+}
+
+// Get the current scaled and smoothed value, or 0 before the first reading
+func (s *Sensor) Value() float64 {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.value
+}
+
+// Get the last raw reading
+func (s *Sensor) Raw() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.raw
+}
+
+// Stop watching the pin, remove its filters and close the event channel
+func (s *Sensor) Close() {
+	s.cancel()
+	<-s.done
+	s.board.SetFilters(s.pin)
+	close(s.events)
+}
+
+func (s *Sensor) run(events <-chan bridge.PinEvent) {
+	defer close(s.done)
+	for ev := range events {
+		s.update(ev.Conditioned(), ev.Value, ev.Time)
+	}
+}
+
+func (s *Sensor) update(value float64, raw int, at time.Time) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.raw = raw
+	s.value = value
+	if s.valid && (value == s.reported || math.Abs(value-s.reported) < s.cfg.threshold) {
+		return
+	}
+	s.valid = true
+	s.reported = value
+	select {
+	case s.events <- SensorEvent{value, raw, at}:
+	default:
+		s.board.Log.Warn("Sensor event buffer overflow. No listener?", "pin", s.pin)
+	}
+}
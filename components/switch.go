@@ -0,0 +1,78 @@
+// Copyright 2014 Krishna Raman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package components
+
+import (
+	"sync"
+	"time"
+
+	"github.com/kraman/go-firmata/bridge"
+)
+
+type SwitchEvent struct {
+	On   bool
+	Time time.Time
+}
+
+// Toggle or slide switch on a digital input
+type Switch struct {
+	board *bridge.Board
+	pin   int
+	in    *input
+
+	lock   sync.Mutex
+	on     bool
+	events chan SwitchEvent
+}
+
+// Set up pin as an input for a switch. The switch is assumed to pull the pin
+// high when on, unless WithPullup or WithActiveLow is given.
+func NewSwitch(b *bridge.Board, pin int, opts ...Option) (sw *Switch, err error) {
+	sw = &Switch{board: b, pin: pin, events: make(chan SwitchEvent, eventBuffer)}
+	if sw.in, err = newInput(b, pin, newConfig(opts), sw.changed); err != nil {
+		sw = nil
+	}
+	return
+}
+
+// Get the channel of switch changes. The first report of a switch that is
+// on at startup is delivered as a change too.
+func (s *Switch) Events() <-chan SwitchEvent {
+	return s.events
+}
+
+// Check if the switch is on
+func (s *Switch) On() bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.on
+}
+
+// Stop watching the switch and close the event channel
+func (s *Switch) Close() {
+	s.in.close()
+	close(s.events)
+}
+
+func (s *Switch) changed(on bool, at time.Time) {
+	s.lock.Lock()
+	s.on = on
+	s.lock.Unlock()
+	select {
+	case s.events <- SwitchEvent{on, at}:
+	default:
+		s.board.Log.Warn("Switch event buffer overflow. No listener?", "pin", s.pin)
+	}
+}
@@ -16,12 +16,14 @@ package firmata
 
 import (
 	"fmt"
+	"strings"
 )
 
 type FirmataCommand byte
 type SysExCommand byte
 type PinMode byte
 type SerialPort byte
+type I2CMode byte
 
 const (
 	ProtocolMajorVersion = 2
@@ -37,6 +39,7 @@ const (
 	EnableAnalogInput  FirmataCommand = 0xC0 // enable analog input by pin #
 	EnableDigitalInput FirmataCommand = 0xD0 // enable digital input by port pair
 	SetPinMode         FirmataCommand = 0xF4 // set a pin to INPUT/OUTPUT/PWM/etc
+	SetDigitalPinValue FirmataCommand = 0xF5 // set value of an individual digital pin
 	ReportVersion      FirmataCommand = 0xF9 // report protocol version
 	SystemReset        FirmataCommand = 0xFF // reset from MIDI
 	StartSysEx         FirmataCommand = 0xF0 // start a MIDI Sysex message
@@ -44,8 +47,14 @@ const (
 
 	// extended command set using sysex (0-127/0x00-0x7F)
 	/* 0x00-0x0F reserved for user-defined commands */
+	UserSysExFirst        SysExCommand = 0x00 // first user-defined command
+	UserSysExLast         SysExCommand = 0x0F // last user-defined command
 	ServoConfig           SysExCommand = 0x70 // set max angle, minPulse, maxPulse, freq
 	StringData            SysExCommand = 0x71 // a string message with 14-bits per char
+	EncoderData           SysExCommand = 0x61 // attach, reset and query rotary encoders
+	AccelStepperData      SysExCommand = 0x62 // control a stepper motor using AccelStepper
+	StepperData           SysExCommand = 0x72 // control a stepper motor
+	OneWireData           SysExCommand = 0x73 // send a OneWire request or reply
 	ShiftData             SysExCommand = 0x75 // a bitstream to/from a shift register
 	I2CRequest            SysExCommand = 0x76 // send an I2C read/write request
 	I2CReply              SysExCommand = 0x77 // a reply to an I2C read request
@@ -59,6 +68,7 @@ const (
 	AnalogMappingResponse SysExCommand = 0x6A // reply with mapping info
 	ReportFirmware        SysExCommand = 0x79 // report name and version of the firmware
 	SamplingInterval      SysExCommand = 0x7A // set the poll rate of the main loop
+	SchedulerData         SysExCommand = 0x7B // create, schedule and query firmware tasks
 	SysExNonRealtime      SysExCommand = 0x7E // MIDI Reserved for non-realtime messages
 	SysExRealtime         SysExCommand = 0x7F // MIDI Reserved for realtime messages
 	Serial                SysExCommand = 0x60
@@ -72,6 +82,92 @@ const (
 	SPIConfig SPISubCommand = 0x10
 	SPIComm   SPISubCommand = 0x20
 
+	// I2C request mode bits, sent in the second byte of an I2C request
+	I2CWrite                I2CMode = 0x00
+	I2CRead                 I2CMode = 0x08
+	I2CReadContinuously     I2CMode = 0x10
+	I2CStopReading          I2CMode = 0x18
+	I2CReadWriteModeMask    I2CMode = 0x18
+	I2C10BitAddressMode     I2CMode = 0x20
+	I2CAutoRestart          I2CMode = 0x40
+	I2CRegisterNotSpecified         = -1
+
+	// OneWire request bits, combined to form a single transaction
+	OneWireResetRequest  OneWireSubCommand = 0x01
+	OneWireSkipRequest   OneWireSubCommand = 0x02
+	OneWireSelectRequest OneWireSubCommand = 0x04
+	OneWireReadRequest   OneWireSubCommand = 0x08
+	OneWireDelayRequest  OneWireSubCommand = 0x10
+	OneWireWriteRequest  OneWireSubCommand = 0x20
+
+	OneWireSearchRequest       OneWireSubCommand = 0x40
+	OneWireConfigRequest       OneWireSubCommand = 0x41
+	OneWireSearchReply         OneWireSubCommand = 0x42
+	OneWireReadReply           OneWireSubCommand = 0x43
+	OneWireSearchAlarmsRequest OneWireSubCommand = 0x44
+	OneWireSearchAlarmsReply   OneWireSubCommand = 0x45
+
+	StepperConfigCmd StepperSubCommand = 0x00
+	StepperStepCmd   StepperSubCommand = 0x01
+
+	AccelStepperConfigCmd          AccelStepperSubCommand = 0x00
+	AccelStepperZeroCmd            AccelStepperSubCommand = 0x01
+	AccelStepperStepCmd            AccelStepperSubCommand = 0x02
+	AccelStepperToCmd              AccelStepperSubCommand = 0x03
+	AccelStepperEnableCmd          AccelStepperSubCommand = 0x04
+	AccelStepperStopCmd            AccelStepperSubCommand = 0x05
+	AccelStepperReportPositionCmd  AccelStepperSubCommand = 0x06
+	AccelStepperSetAccelerationCmd AccelStepperSubCommand = 0x08
+	AccelStepperSetSpeedCmd        AccelStepperSubCommand = 0x09
+	AccelStepperMoveCompleteReply  AccelStepperSubCommand = 0x0A
+	MultiStepperConfigCmd          AccelStepperSubCommand = 0x20
+	MultiStepperToCmd              AccelStepperSubCommand = 0x21
+	MultiStepperStopCmd            AccelStepperSubCommand = 0x23
+	MultiStepperMoveCompleteReply  AccelStepperSubCommand = 0x24
+
+	StepperDriver    StepperInterface = 0x01
+	StepperTwoWire   StepperInterface = 0x02
+	StepperThreeWire StepperInterface = 0x03
+	StepperFourWire  StepperInterface = 0x04
+
+	StepperWholeStep   StepperStepSize = 0x00
+	StepperHalfStep    StepperStepSize = 0x01
+	StepperQuarterStep StepperStepSize = 0x02
+
+	StepperCW  StepperDirection = 0x00
+	StepperCCW StepperDirection = 0x01
+
+	StepperMoveComplete      StepperEventType = 0x00
+	StepperPosition          StepperEventType = 0x01
+	MultiStepperMoveComplete StepperEventType = 0x02
+
+	EncoderAttach          EncoderSubCommand = 0x00
+	EncoderReportPosition  EncoderSubCommand = 0x01
+	EncoderReportPositions EncoderSubCommand = 0x02
+	EncoderResetPosition   EncoderSubCommand = 0x03
+	EncoderReportAuto      EncoderSubCommand = 0x04
+	EncoderDetach          EncoderSubCommand = 0x05
+
+	SchedulerCreateTask         SchedulerSubCommand = 0x00
+	SchedulerDeleteTask         SchedulerSubCommand = 0x01
+	SchedulerAddToTask          SchedulerSubCommand = 0x02
+	SchedulerDelayTask          SchedulerSubCommand = 0x03
+	SchedulerScheduleTask       SchedulerSubCommand = 0x04
+	SchedulerQueryAllTasks      SchedulerSubCommand = 0x05
+	SchedulerQueryTask          SchedulerSubCommand = 0x06
+	SchedulerReset              SchedulerSubCommand = 0x07
+	SchedulerErrorTaskReply     SchedulerSubCommand = 0x08
+	SchedulerQueryAllTasksReply SchedulerSubCommand = 0x09
+	SchedulerQueryTaskReply     SchedulerSubCommand = 0x0A
+
+	FeatureDigitalPinValue Feature = 0x00 // single pin digital writes
+	FeatureInputPullup     Feature = 0x01 // INPUT_PULLUP pin mode
+	FeatureScheduler       Feature = 0x02 // scheduler tasks
+	FeatureSerial          Feature = 0x03 // serial ports
+	FeatureAccelStepper    Feature = 0x04 // AccelStepper devices
+
+	oneWireWithDataRequest = OneWireSelectRequest | OneWireReadRequest | OneWireDelayRequest | OneWireWriteRequest
+
 	SPI_MODE0 = 0x00
 	SPI_MODE1 = 0x04
 	SPI_MODE2 = 0x08
@@ -83,14 +179,24 @@ const (
 	HardSerial3 SerialPort = 0x03
 
 	// pin modes
-	Input  PinMode = 0x00
-	Output PinMode = 0x01
-	Analog PinMode = 0x02
-	PWM    PinMode = 0x03
-	Servo  PinMode = 0x04
-	Shift  PinMode = 0x05
-	I2C    PinMode = 0x06
-	SPI    PinMode = 0x07
+	Input       PinMode = 0x00
+	Output      PinMode = 0x01
+	Analog      PinMode = 0x02
+	PWM         PinMode = 0x03
+	Servo       PinMode = 0x04
+	Shift       PinMode = 0x05
+	I2C         PinMode = 0x06
+	OneWire     PinMode = 0x07
+	Stepper     PinMode = 0x08
+	Encoder     PinMode = 0x09
+	SerialMode  PinMode = 0x0A // pin used by a hardware or software serial port
+	InputPullup PinMode = 0x0B
+	SPI         PinMode = 0x0C
+	Sonar       PinMode = 0x0D
+	Tone        PinMode = 0x0E
+	DHT         PinMode = 0x0F
+	Frequency   PinMode = 0x10
+	Ignore      PinMode = 0x7F // pin is excluded from reporting and mode changes
 )
 
 func (m PinMode) String() string {
@@ -109,10 +215,51 @@ func (m PinMode) String() string {
 		return "SHIFT"
 	case m == I2C:
 		return "I2C"
+	case m == OneWire:
+		return "ONEWIRE"
+	case m == Stepper:
+		return "STEPPER"
+	case m == Encoder:
+		return "ENCODER"
+	case m == SerialMode:
+		return "SERIAL"
+	case m == InputPullup:
+		return "PULLUP"
+	case m == SPI:
+		return "SPI"
+	case m == Sonar:
+		return "SONAR"
+	case m == Tone:
+		return "TONE"
+	case m == DHT:
+		return "DHT"
+	case m == Frequency:
+		return "FREQUENCY"
+	case m == Ignore:
+		return "IGNORE"
 	}
 	return "UNKNOWN"
 }
 
+// Parse a pin mode name as returned by String, ignoring case
+func ParsePinMode(s string) (m PinMode, err error) {
+	for m = Input; m <= Ignore; m++ {
+		if name := m.String(); name != "UNKNOWN" && strings.EqualFold(name, s) {
+			return
+		}
+	}
+	return 0, fmt.Errorf("Unknown pin mode %q", s)
+}
+
+func (m PinMode) MarshalText() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+func (m *PinMode) UnmarshalText(text []byte) (err error) {
+	*m, err = ParsePinMode(string(text))
+	return
+}
+
 func (c FirmataCommand) String() string {
 	switch {
 	case (c & 0xF0) == DigitalMessage:
@@ -125,6 +272,8 @@ func (c FirmataCommand) String() string {
 		return fmt.Sprintf("EnableDigitalInput (0x%x)", byte(c))
 	case c == SetPinMode:
 		return fmt.Sprintf("SetPinMode (0x%x)", byte(c))
+	case c == SetDigitalPinValue:
+		return fmt.Sprintf("SetDigitalPinValue (0x%x)", byte(c))
 	case c == ReportVersion:
 		return fmt.Sprintf("ReportVersion (0x%x)", byte(c))
 	case c == SystemReset:
@@ -143,6 +292,14 @@ func (c SysExCommand) String() string {
 		return fmt.Sprintf("ServoConfig (0x%x)", byte(c))
 	case c == StringData:
 		return fmt.Sprintf("StringData (0x%x)", byte(c))
+	case c == EncoderData:
+		return fmt.Sprintf("EncoderData (0x%x)", byte(c))
+	case c == AccelStepperData:
+		return fmt.Sprintf("AccelStepperData (0x%x)", byte(c))
+	case c == StepperData:
+		return fmt.Sprintf("StepperData (0x%x)", byte(c))
+	case c == OneWireData:
+		return fmt.Sprintf("OneWireData (0x%x)", byte(c))
 	case c == ShiftData:
 		return fmt.Sprintf("ShiftData (0x%x)", byte(c))
 	case c == I2CRequest:
@@ -169,6 +326,8 @@ func (c SysExCommand) String() string {
 		return fmt.Sprintf("ReportFirmware (0x%x)", byte(c))
 	case c == SamplingInterval:
 		return fmt.Sprintf("SamplingInterval (0x%x)", byte(c))
+	case c == SchedulerData:
+		return fmt.Sprintf("SchedulerData (0x%x)", byte(c))
 	case c == SysExNonRealtime:
 		return fmt.Sprintf("SysExNonRealtime (0x%x)", byte(c))
 	case c == SysExRealtime:
@@ -178,5 +337,8 @@ func (c SysExCommand) String() string {
 	case c == SysExSPI:
 		return fmt.Sprintf("SPI (0x%x)", byte(c))
 	}
+	if c <= UserSysExLast {
+		return fmt.Sprintf("User SysEx command (0x%x)", byte(c))
+	}
 	return fmt.Sprintf("Unexpected SysEx command (0x%x)", byte(c))
 }
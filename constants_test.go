@@ -0,0 +1,100 @@
+// Copyright 2014 Krishna Raman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// Wire values from the Firmata protocol
+var pinModeNames = []struct {
+	mode PinMode
+	wire byte
+	name string
+}{
+	{Input, 0x00, "INPUT"},
+	{Output, 0x01, "OUTPUT"},
+	{Analog, 0x02, "ANALOG"},
+	{PWM, 0x03, "PWM"},
+	{Servo, 0x04, "SERVO"},
+	{Shift, 0x05, "SHIFT"},
+	{I2C, 0x06, "I2C"},
+	{OneWire, 0x07, "ONEWIRE"},
+	{Stepper, 0x08, "STEPPER"},
+	{Encoder, 0x09, "ENCODER"},
+	{SerialMode, 0x0A, "SERIAL"},
+	{InputPullup, 0x0B, "PULLUP"},
+	{SPI, 0x0C, "SPI"},
+	{Sonar, 0x0D, "SONAR"},
+	{Tone, 0x0E, "TONE"},
+	{DHT, 0x0F, "DHT"},
+	{Frequency, 0x10, "FREQUENCY"},
+	{Ignore, 0x7F, "IGNORE"},
+}
+
+func TestPinModeNames(t *testing.T) {
+	for _, tt := range pinModeNames {
+		if byte(tt.mode) != tt.wire {
+			t.Errorf("%v is %#x on the wire, want %#x", tt.name, byte(tt.mode), tt.wire)
+		}
+		if s := tt.mode.String(); s != tt.name {
+			t.Errorf("mode %#x is named %q, want %q", tt.wire, s, tt.name)
+		}
+		if m, err := ParsePinMode(tt.name); err != nil || m != tt.mode {
+			t.Errorf("%q parsed as %v, %v", tt.name, m, err)
+		}
+	}
+	if s := PinMode(0x11).String(); s != "UNKNOWN" {
+		t.Errorf("mode 0x11 is named %q", s)
+	}
+}
+
+func TestParsePinMode(t *testing.T) {
+	for _, s := range []string{"pullup", "Spi", "oneWire", "input"} {
+		if _, err := ParsePinMode(s); err != nil {
+			t.Errorf("%q: %v", s, err)
+		}
+	}
+	for _, s := range []string{"", "UNKNOWN", "FLYING", "INPUT ", "0x0C"} {
+		if m, err := ParsePinMode(s); err == nil {
+			t.Errorf("%q parsed as %v", s, m)
+		}
+	}
+}
+
+func TestPinModeJSON(t *testing.T) {
+	for _, tt := range pinModeNames {
+		data, err := json.Marshal(map[string]PinMode{"mode": tt.mode})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := `{"mode":"` + tt.name + `"}`; string(data) != want {
+			t.Errorf("%v marshalled as %s, want %s", tt.name, data, want)
+		}
+		var back map[string]PinMode
+		if err = json.Unmarshal(data, &back); err != nil || back["mode"] != tt.mode {
+			t.Errorf("%s unmarshalled as %v, %v", data, back["mode"], err)
+		}
+	}
+
+	var m PinMode
+	if err := json.Unmarshal([]byte(`"analog"`), &m); err != nil || m != Analog {
+		t.Errorf("lower case name unmarshalled as %v, %v", m, err)
+	}
+	if err := json.Unmarshal([]byte(`"FLYING"`), &m); err == nil {
+		t.Error("unknown name unmarshalled")
+	}
+}
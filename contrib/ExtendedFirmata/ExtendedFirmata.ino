@@ -67,8 +67,8 @@
 #define SPI_CONFIG 0x10
 #define SPI_COMM 0x20
 
-#define PIN_SPI 0x08 // pin included in SPI setup
-#define TOTAL_PIN_MODES 8
+#define PIN_SPI 0x0C // pin included in SPI setup
+#define TOTAL_PIN_MODES 13
 
 /*==============================================================================
  * GLOBAL VARIABLES
@@ -346,6 +346,16 @@ void digitalWriteCallback(byte port, int value) {
   }
 }
 
+void setPinValueCallback(byte pin, int value) {
+  if (pin < TOTAL_PINS && IS_PIN_DIGITAL(pin)) {
+    // same rules as digitalWriteCallback, for a single pin
+    if (pinConfig[pin] == OUTPUT || pinConfig[pin] == INPUT) {
+      pinState[pin] = value ? 1 : 0;
+      digitalWrite(PIN_TO_DIGITAL(pin), value ? HIGH : LOW);
+    }
+  }
+}
+
 // -----------------------------------------------------------------------------
 /* sets bits in a bit array (int) to toggle the reporting of the analogIns
  */
@@ -632,8 +642,8 @@ void sysexCallback(byte command, byte argc, byte *argv) {
       pinMode(SCK, OUTPUT);
       pinMode(SS, OUTPUT);
 
-      byte csPin = 40; //argv[1] | (argv[2] << 7);
-      byte mode = SPI_MODE3; //argv[3] | (argv[4] << 7);
+      byte csPin = argv[1] | (argv[2] << 7);
+      byte mode = argv[3] | (argv[4] << 7);
       pinMode(csPin, OUTPUT);
       digitalWrite(csPin, HIGH);
       SPI.begin();
@@ -641,10 +651,11 @@ void sysexCallback(byte command, byte argc, byte *argv) {
       break;
     }
     case SPI_COMM: {
-      byte csPin = 40; //argv[1] | (argv[2] << 7);
+      byte csPin = argv[1] | (argv[2] << 7);
       Serial.write(START_SYSEX);
       Serial.write(SYSEX_SPI);
-      Serial.write(SPI_COMM);
+      // echo the sub-command so the low nibble (request id) is returned
+      Serial.write(argv[0]);
       Serial.write(csPin & 0x7F);
       Serial.write((csPin >> 7) & 0x7F);
 			
@@ -746,6 +757,9 @@ void setup() {
 
   Firmata.attach(ANALOG_MESSAGE, analogWriteCallback);
   Firmata.attach(DIGITAL_MESSAGE, digitalWriteCallback);
+#ifdef SET_DIGITAL_PIN_VALUE
+  Firmata.attach(SET_DIGITAL_PIN_VALUE, setPinValueCallback);
+#endif
   Firmata.attach(REPORT_ANALOG, reportAnalogCallback);
   Firmata.attach(REPORT_DIGITAL, reportDigitalCallback);
   Firmata.attach(SET_PIN_MODE, setPinModeCallback);
@@ -0,0 +1,142 @@
+// Copyright 2014 Krishna Raman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+type EncoderSubCommand byte
+
+// Position report for a rotary encoder, delivered on the channel returned
+// by GetEncoderEvents()
+type EncoderEvent struct {
+	Encoder  byte
+	Position int32
+	// Change since the previous report for this encoder
+	Delta int32
+}
+
+func (e EncoderEvent) String() string {
+	return fmt.Sprintf("Encoder %v = %v (%+d)", e.Encoder, e.Position, e.Delta)
+}
+
+// Attach a quadrature encoder on pinA and pinB. The firmware supports
+// encoder ids 0 to 4.
+func (c *FirmataClient) AttachEncoder(id byte, pinA byte, pinB byte) error {
+	return c.sendSysEx(EncoderData, byte(EncoderAttach), id&0x3F, pinA&0x7F, pinB&0x7F)
+}
+
+// Detach an encoder and release its pins
+func (c *FirmataClient) DetachEncoder(id byte) error {
+	c.encoderLock.Lock()
+	delete(c.encoderPositions, id)
+	c.encoderLock.Unlock()
+	return c.sendSysEx(EncoderData, byte(EncoderDetach), id&0x3F)
+}
+
+// Reset the position of an encoder to zero
+func (c *FirmataClient) ResetEncoder(id byte) error {
+	c.encoderLock.Lock()
+	if _, ok := c.encoderPositions[id]; ok {
+		c.encoderPositions[id] = 0
+	}
+	c.encoderLock.Unlock()
+	return c.sendSysEx(EncoderData, byte(EncoderResetPosition), id&0x3F)
+}
+
+// Enable or disable reporting of all encoder positions on every sampling
+// interval. Reports are delivered as events on GetEncoderEvents().
+func (c *FirmataClient) EnableEncoderReporting(val bool) error {
+	enable := byte(0x00)
+	if val {
+		enable = 0x01
+	}
+	return c.sendSysEx(EncoderData, byte(EncoderReportAuto), enable)
+}
+
+// Query the positions of all attached encoders
+func (c *FirmataClient) QueryEncoderPositions(ctx context.Context) (positions map[byte]int32, err error) {
+	defer c.observeQuery("encoder_positions", time.Now(), &err)
+	reply := make(chan map[byte]int32, 1)
+
+	c.encoderLock.Lock()
+	c.encoderQueries = append(c.encoderQueries, reply)
+	c.encoderLock.Unlock()
+
+	defer func() {
+		c.encoderLock.Lock()
+		defer c.encoderLock.Unlock()
+		for i, q := range c.encoderQueries {
+			if q == reply {
+				c.encoderQueries = append(c.encoderQueries[:i:i], c.encoderQueries[i+1:]...)
+				break
+			}
+		}
+	}()
+
+	if err = c.sendSysEx(EncoderData, byte(EncoderReportPositions)); err != nil {
+		return
+	}
+
+	select {
+	case positions = <-reply:
+	case <-ctx.Done():
+		err = fmt.Errorf("Encoder position query: %w", ctx.Err())
+	}
+	return
+}
+
+// Get channel for encoder position reports
+func (c *FirmataClient) GetEncoderEvents() <-chan EncoderEvent {
+	return c.encoderChan
+}
+
+func (c *FirmataClient) parseEncoderResponse(data []byte) {
+	positions := make(map[byte]int32)
+	events := make([]EncoderEvent, 0)
+
+	c.encoderLock.Lock()
+	if c.encoderPositions == nil {
+		c.encoderPositions = make(map[byte]int32)
+	}
+	for i := 0; i+5 <= len(data); i = i + 5 {
+		id := data[i] & 0x3F
+		pos := int32(data[i+1]&0x7F) | int32(data[i+2]&0x7F)<<7 | int32(data[i+3]&0x7F)<<14 | int32(data[i+4]&0x7F)<<21
+		if data[i]&0x40 != 0 {
+			pos = -pos
+		}
+		positions[id] = pos
+		events = append(events, EncoderEvent{Encoder: id, Position: pos, Delta: pos - c.encoderPositions[id]})
+		c.encoderPositions[id] = pos
+	}
+	queries := c.encoderQueries
+	c.encoderQueries = nil
+	c.encoderLock.Unlock()
+
+	for _, q := range queries {
+		q <- positions
+	}
+	for _, ev := range events {
+		select {
+		case c.encoderChan <- ev:
+		default:
+			c.Log.Warn("Encoder event buffer overflow. No listener?", "encoder", ev.Encoder)
+			c.metrics.DroppedEvent("encoder")
+		}
+	}
+}
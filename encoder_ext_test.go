@@ -0,0 +1,136 @@
+// Copyright 2014 Krishna Raman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// Encoder position as reported by the firmware: id with the sign in bit 6,
+// then the magnitude in four 7-bit groups
+func encoderPosition(id byte, pos int32) []byte {
+	if pos < 0 {
+		id |= 0x40
+		pos = -pos
+	}
+	return []byte{id, byte(pos & 0x7F), byte(pos >> 7 & 0x7F), byte(pos >> 14 & 0x7F), byte(pos >> 21 & 0x7F)}
+}
+
+func newEncoderClient() *FirmataClient {
+	c, _ := newOfflineClient()
+	c.encoderChan = make(chan EncoderEvent, 10)
+	return c
+}
+
+func nextEncoderEvent(t *testing.T, c *FirmataClient) EncoderEvent {
+	t.Helper()
+	select {
+	case ev := <-c.encoderChan:
+		return ev
+	default:
+		t.Fatal("no encoder event")
+		return EncoderEvent{}
+	}
+}
+
+func TestEncoderSign(t *testing.T) {
+	c := newEncoderClient()
+	tests := []struct {
+		data []byte
+		want int32
+	}{
+		{[]byte{0x00, 0x05, 0x00, 0x00, 0x00}, 5},
+		{[]byte{0x40, 0x05, 0x00, 0x00, 0x00}, -5},
+		{[]byte{0x00, 0x7F, 0x7F, 0x7F, 0x7F}, 1<<28 - 1},
+		{[]byte{0x40, 0x00, 0x01, 0x00, 0x00}, -128},
+	}
+	for _, tt := range tests {
+		c.parseEncoderResponse(tt.data)
+		if ev := nextEncoderEvent(t, c); ev.Encoder != 0 || ev.Position != tt.want {
+			t.Errorf("%x parsed as %v, want %v", tt.data, ev, tt.want)
+		}
+	}
+}
+
+func TestEncoderReportAndDelta(t *testing.T) {
+	c := newEncoderClient()
+
+	report := append(encoderPosition(0, 10), encoderPosition(3, -20)...)
+	c.parseEncoderResponse(report)
+	for _, want := range []EncoderEvent{{0, 10, 10}, {3, -20, -20}} {
+		if ev := nextEncoderEvent(t, c); ev != want {
+			t.Errorf("got %v, want %v", ev, want)
+		}
+	}
+
+	report = append(encoderPosition(0, 7), encoderPosition(3, -15)...)
+	c.parseEncoderResponse(report)
+	for _, want := range []EncoderEvent{{0, 7, -3}, {3, -15, 5}} {
+		if ev := nextEncoderEvent(t, c); ev != want {
+			t.Errorf("got %v, want %v", ev, want)
+		}
+	}
+
+	// a reset starts the delta from zero
+	c.ResetEncoder(0)
+	c.parseEncoderResponse(encoderPosition(0, 2))
+	if ev := nextEncoderEvent(t, c); ev.Delta != 2 {
+		t.Errorf("delta %v after reset", ev.Delta)
+	}
+}
+
+func TestQueryEncoderPositions(t *testing.T) {
+	c := newEncoderClient()
+
+	done := make(chan map[byte]int32, 1)
+	go func() {
+		positions, _ := c.QueryEncoderPositions(context.Background())
+		done <- positions
+	}()
+	for c.pendingEncoderQueries() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	c.parseEncoderResponse(append(encoderPosition(1, 100), encoderPosition(2, -1)...))
+	select {
+	case positions := <-done:
+		if len(positions) != 2 || positions[1] != 100 || positions[2] != -1 {
+			t.Errorf("positions %v", positions)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("query did not return")
+	}
+}
+
+func TestQueryEncoderPositionsCancel(t *testing.T) {
+	c := newEncoderClient()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if _, err := c.QueryEncoderPositions(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("query returned %v", err)
+	}
+	if n := c.pendingEncoderQueries(); n != 0 {
+		t.Errorf("%v queries left pending", n)
+	}
+}
+
+func (c *FirmataClient) pendingEncoderQueries() int {
+	c.encoderLock.Lock()
+	defer c.encoderLock.Unlock()
+	return len(c.encoderQueries)
+}
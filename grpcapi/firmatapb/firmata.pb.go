@@ -0,0 +1,1724 @@
+// Copyright 2014 Krishna Raman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        (unknown)
+// source: firmata.proto
+
+package firmatapb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Pin modes, numbered as in the Firmata protocol
+type PinMode int32
+
+const (
+	PinMode_PIN_MODE_INPUT     PinMode = 0
+	PinMode_PIN_MODE_OUTPUT    PinMode = 1
+	PinMode_PIN_MODE_ANALOG    PinMode = 2
+	PinMode_PIN_MODE_PWM       PinMode = 3
+	PinMode_PIN_MODE_SERVO     PinMode = 4
+	PinMode_PIN_MODE_SHIFT     PinMode = 5
+	PinMode_PIN_MODE_I2C       PinMode = 6
+	PinMode_PIN_MODE_ONEWIRE   PinMode = 7
+	PinMode_PIN_MODE_STEPPER   PinMode = 8
+	PinMode_PIN_MODE_ENCODER   PinMode = 9
+	PinMode_PIN_MODE_SERIAL    PinMode = 10
+	PinMode_PIN_MODE_PULLUP    PinMode = 11
+	PinMode_PIN_MODE_SPI       PinMode = 12
+	PinMode_PIN_MODE_SONAR     PinMode = 13
+	PinMode_PIN_MODE_TONE      PinMode = 14
+	PinMode_PIN_MODE_DHT       PinMode = 15
+	PinMode_PIN_MODE_FREQUENCY PinMode = 16
+	PinMode_PIN_MODE_IGNORE    PinMode = 127
+)
+
+// Enum value maps for PinMode.
+var (
+	PinMode_name = map[int32]string{
+		0:   "PIN_MODE_INPUT",
+		1:   "PIN_MODE_OUTPUT",
+		2:   "PIN_MODE_ANALOG",
+		3:   "PIN_MODE_PWM",
+		4:   "PIN_MODE_SERVO",
+		5:   "PIN_MODE_SHIFT",
+		6:   "PIN_MODE_I2C",
+		7:   "PIN_MODE_ONEWIRE",
+		8:   "PIN_MODE_STEPPER",
+		9:   "PIN_MODE_ENCODER",
+		10:  "PIN_MODE_SERIAL",
+		11:  "PIN_MODE_PULLUP",
+		12:  "PIN_MODE_SPI",
+		13:  "PIN_MODE_SONAR",
+		14:  "PIN_MODE_TONE",
+		15:  "PIN_MODE_DHT",
+		16:  "PIN_MODE_FREQUENCY",
+		127: "PIN_MODE_IGNORE",
+	}
+	PinMode_value = map[string]int32{
+		"PIN_MODE_INPUT":     0,
+		"PIN_MODE_OUTPUT":    1,
+		"PIN_MODE_ANALOG":    2,
+		"PIN_MODE_PWM":       3,
+		"PIN_MODE_SERVO":     4,
+		"PIN_MODE_SHIFT":     5,
+		"PIN_MODE_I2C":       6,
+		"PIN_MODE_ONEWIRE":   7,
+		"PIN_MODE_STEPPER":   8,
+		"PIN_MODE_ENCODER":   9,
+		"PIN_MODE_SERIAL":    10,
+		"PIN_MODE_PULLUP":    11,
+		"PIN_MODE_SPI":       12,
+		"PIN_MODE_SONAR":     13,
+		"PIN_MODE_TONE":      14,
+		"PIN_MODE_DHT":       15,
+		"PIN_MODE_FREQUENCY": 16,
+		"PIN_MODE_IGNORE":    127,
+	}
+)
+
+func (x PinMode) Enum() *PinMode {
+	p := new(PinMode)
+	*p = x
+	return p
+}
+
+func (x PinMode) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (PinMode) Descriptor() protoreflect.EnumDescriptor {
+	return file_firmata_proto_enumTypes[0].Descriptor()
+}
+
+func (PinMode) Type() protoreflect.EnumType {
+	return &file_firmata_proto_enumTypes[0]
+}
+
+func (x PinMode) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use PinMode.Descriptor instead.
+func (PinMode) EnumDescriptor() ([]byte, []int) {
+	return file_firmata_proto_rawDescGZIP(), []int{0}
+}
+
+// Direction of a digital input change
+type Edge int32
+
+const (
+	Edge_EDGE_NONE    Edge = 0
+	Edge_EDGE_RISING  Edge = 1
+	Edge_EDGE_FALLING Edge = 2
+)
+
+// Enum value maps for Edge.
+var (
+	Edge_name = map[int32]string{
+		0: "EDGE_NONE",
+		1: "EDGE_RISING",
+		2: "EDGE_FALLING",
+	}
+	Edge_value = map[string]int32{
+		"EDGE_NONE":    0,
+		"EDGE_RISING":  1,
+		"EDGE_FALLING": 2,
+	}
+)
+
+func (x Edge) Enum() *Edge {
+	p := new(Edge)
+	*p = x
+	return p
+}
+
+func (x Edge) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Edge) Descriptor() protoreflect.EnumDescriptor {
+	return file_firmata_proto_enumTypes[1].Descriptor()
+}
+
+func (Edge) Type() protoreflect.EnumType {
+	return &file_firmata_proto_enumTypes[1]
+}
+
+func (x Edge) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Edge.Descriptor instead.
+func (Edge) EnumDescriptor() ([]byte, []int) {
+	return file_firmata_proto_rawDescGZIP(), []int{1}
+}
+
+type SerialPort int32
+
+const (
+	SerialPort_SERIAL_PORT_SOFT SerialPort = 0
+	SerialPort_SERIAL_PORT_HW1  SerialPort = 1
+	SerialPort_SERIAL_PORT_HW2  SerialPort = 2
+	SerialPort_SERIAL_PORT_HW3  SerialPort = 3
+)
+
+// Enum value maps for SerialPort.
+var (
+	SerialPort_name = map[int32]string{
+		0: "SERIAL_PORT_SOFT",
+		1: "SERIAL_PORT_HW1",
+		2: "SERIAL_PORT_HW2",
+		3: "SERIAL_PORT_HW3",
+	}
+	SerialPort_value = map[string]int32{
+		"SERIAL_PORT_SOFT": 0,
+		"SERIAL_PORT_HW1":  1,
+		"SERIAL_PORT_HW2":  2,
+		"SERIAL_PORT_HW3":  3,
+	}
+)
+
+func (x SerialPort) Enum() *SerialPort {
+	p := new(SerialPort)
+	*p = x
+	return p
+}
+
+func (x SerialPort) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (SerialPort) Descriptor() protoreflect.EnumDescriptor {
+	return file_firmata_proto_enumTypes[2].Descriptor()
+}
+
+func (SerialPort) Type() protoreflect.EnumType {
+	return &file_firmata_proto_enumTypes[2]
+}
+
+func (x SerialPort) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use SerialPort.Descriptor instead.
+func (SerialPort) EnumDescriptor() ([]byte, []int) {
+	return file_firmata_proto_rawDescGZIP(), []int{2}
+}
+
+type GetInfoRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetInfoRequest) Reset() {
+	*x = GetInfoRequest{}
+	mi := &file_firmata_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetInfoRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetInfoRequest) ProtoMessage() {}
+
+func (x *GetInfoRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_firmata_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetInfoRequest.ProtoReflect.Descriptor instead.
+func (*GetInfoRequest) Descriptor() ([]byte, []int) {
+	return file_firmata_proto_rawDescGZIP(), []int{0}
+}
+
+type BoardInfo struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	FirmwareName   string                 `protobuf:"bytes,1,opt,name=firmware_name,json=firmwareName,proto3" json:"firmware_name,omitempty"`
+	FirmwareMajor  uint32                 `protobuf:"varint,2,opt,name=firmware_major,json=firmwareMajor,proto3" json:"firmware_major,omitempty"`
+	FirmwareMinor  uint32                 `protobuf:"varint,3,opt,name=firmware_minor,json=firmwareMinor,proto3" json:"firmware_minor,omitempty"`
+	ProtocolMajor  uint32                 `protobuf:"varint,4,opt,name=protocol_major,json=protocolMajor,proto3" json:"protocol_major,omitempty"`
+	ProtocolMinor  uint32                 `protobuf:"varint,5,opt,name=protocol_minor,json=protocolMinor,proto3" json:"protocol_minor,omitempty"`
+	Pins           uint32                 `protobuf:"varint,6,opt,name=pins,proto3" json:"pins,omitempty"`
+	AnalogChannels uint32                 `protobuf:"varint,7,opt,name=analog_channels,json=analogChannels,proto3" json:"analog_channels,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *BoardInfo) Reset() {
+	*x = BoardInfo{}
+	mi := &file_firmata_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BoardInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BoardInfo) ProtoMessage() {}
+
+func (x *BoardInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_firmata_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BoardInfo.ProtoReflect.Descriptor instead.
+func (*BoardInfo) Descriptor() ([]byte, []int) {
+	return file_firmata_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *BoardInfo) GetFirmwareName() string {
+	if x != nil {
+		return x.FirmwareName
+	}
+	return ""
+}
+
+func (x *BoardInfo) GetFirmwareMajor() uint32 {
+	if x != nil {
+		return x.FirmwareMajor
+	}
+	return 0
+}
+
+func (x *BoardInfo) GetFirmwareMinor() uint32 {
+	if x != nil {
+		return x.FirmwareMinor
+	}
+	return 0
+}
+
+func (x *BoardInfo) GetProtocolMajor() uint32 {
+	if x != nil {
+		return x.ProtocolMajor
+	}
+	return 0
+}
+
+func (x *BoardInfo) GetProtocolMinor() uint32 {
+	if x != nil {
+		return x.ProtocolMinor
+	}
+	return 0
+}
+
+func (x *BoardInfo) GetPins() uint32 {
+	if x != nil {
+		return x.Pins
+	}
+	return 0
+}
+
+func (x *BoardInfo) GetAnalogChannels() uint32 {
+	if x != nil {
+		return x.AnalogChannels
+	}
+	return 0
+}
+
+type Capability struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Mode  PinMode                `protobuf:"varint,1,opt,name=mode,proto3,enum=firmata.v1.PinMode" json:"mode,omitempty"`
+	// Resolution in bits
+	Resolution    uint32 `protobuf:"varint,2,opt,name=resolution,proto3" json:"resolution,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Capability) Reset() {
+	*x = Capability{}
+	mi := &file_firmata_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Capability) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Capability) ProtoMessage() {}
+
+func (x *Capability) ProtoReflect() protoreflect.Message {
+	mi := &file_firmata_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Capability.ProtoReflect.Descriptor instead.
+func (*Capability) Descriptor() ([]byte, []int) {
+	return file_firmata_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Capability) GetMode() PinMode {
+	if x != nil {
+		return x.Mode
+	}
+	return PinMode_PIN_MODE_INPUT
+}
+
+func (x *Capability) GetResolution() uint32 {
+	if x != nil {
+		return x.Resolution
+	}
+	return 0
+}
+
+type Pin struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Pin   uint32                 `protobuf:"varint,1,opt,name=pin,proto3" json:"pin,omitempty"`
+	Mode  PinMode                `protobuf:"varint,2,opt,name=mode,proto3,enum=firmata.v1.PinMode" json:"mode,omitempty"`
+	// Level of digital pins, raw reading of analog inputs or last written PWM
+	// duty cycle or servo angle
+	Value        int32         `protobuf:"varint,3,opt,name=value,proto3" json:"value,omitempty"`
+	Capabilities []*Capability `protobuf:"bytes,4,rep,name=capabilities,proto3" json:"capabilities,omitempty"`
+	// Analog channel of the pin, or -1 if it has none
+	AnalogChannel int32 `protobuf:"varint,5,opt,name=analog_channel,json=analogChannel,proto3" json:"analog_channel,omitempty"`
+	// Filtered reading of analog inputs with filters
+	Reading       *float64 `protobuf:"fixed64,6,opt,name=reading,proto3,oneof" json:"reading,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Pin) Reset() {
+	*x = Pin{}
+	mi := &file_firmata_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Pin) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Pin) ProtoMessage() {}
+
+func (x *Pin) ProtoReflect() protoreflect.Message {
+	mi := &file_firmata_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Pin.ProtoReflect.Descriptor instead.
+func (*Pin) Descriptor() ([]byte, []int) {
+	return file_firmata_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Pin) GetPin() uint32 {
+	if x != nil {
+		return x.Pin
+	}
+	return 0
+}
+
+func (x *Pin) GetMode() PinMode {
+	if x != nil {
+		return x.Mode
+	}
+	return PinMode_PIN_MODE_INPUT
+}
+
+func (x *Pin) GetValue() int32 {
+	if x != nil {
+		return x.Value
+	}
+	return 0
+}
+
+func (x *Pin) GetCapabilities() []*Capability {
+	if x != nil {
+		return x.Capabilities
+	}
+	return nil
+}
+
+func (x *Pin) GetAnalogChannel() int32 {
+	if x != nil {
+		return x.AnalogChannel
+	}
+	return 0
+}
+
+func (x *Pin) GetReading() float64 {
+	if x != nil && x.Reading != nil {
+		return *x.Reading
+	}
+	return 0
+}
+
+type ListPinsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListPinsRequest) Reset() {
+	*x = ListPinsRequest{}
+	mi := &file_firmata_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListPinsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPinsRequest) ProtoMessage() {}
+
+func (x *ListPinsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_firmata_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPinsRequest.ProtoReflect.Descriptor instead.
+func (*ListPinsRequest) Descriptor() ([]byte, []int) {
+	return file_firmata_proto_rawDescGZIP(), []int{4}
+}
+
+type ListPinsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Pins          []*Pin                 `protobuf:"bytes,1,rep,name=pins,proto3" json:"pins,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListPinsResponse) Reset() {
+	*x = ListPinsResponse{}
+	mi := &file_firmata_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListPinsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPinsResponse) ProtoMessage() {}
+
+func (x *ListPinsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_firmata_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPinsResponse.ProtoReflect.Descriptor instead.
+func (*ListPinsResponse) Descriptor() ([]byte, []int) {
+	return file_firmata_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ListPinsResponse) GetPins() []*Pin {
+	if x != nil {
+		return x.Pins
+	}
+	return nil
+}
+
+type GetPinRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Pin           uint32                 `protobuf:"varint,1,opt,name=pin,proto3" json:"pin,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetPinRequest) Reset() {
+	*x = GetPinRequest{}
+	mi := &file_firmata_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetPinRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetPinRequest) ProtoMessage() {}
+
+func (x *GetPinRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_firmata_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetPinRequest.ProtoReflect.Descriptor instead.
+func (*GetPinRequest) Descriptor() ([]byte, []int) {
+	return file_firmata_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *GetPinRequest) GetPin() uint32 {
+	if x != nil {
+		return x.Pin
+	}
+	return 0
+}
+
+type SetPinModeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Pin           uint32                 `protobuf:"varint,1,opt,name=pin,proto3" json:"pin,omitempty"`
+	Mode          PinMode                `protobuf:"varint,2,opt,name=mode,proto3,enum=firmata.v1.PinMode" json:"mode,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetPinModeRequest) Reset() {
+	*x = SetPinModeRequest{}
+	mi := &file_firmata_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetPinModeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetPinModeRequest) ProtoMessage() {}
+
+func (x *SetPinModeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_firmata_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetPinModeRequest.ProtoReflect.Descriptor instead.
+func (*SetPinModeRequest) Descriptor() ([]byte, []int) {
+	return file_firmata_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *SetPinModeRequest) GetPin() uint32 {
+	if x != nil {
+		return x.Pin
+	}
+	return 0
+}
+
+func (x *SetPinModeRequest) GetMode() PinMode {
+	if x != nil {
+		return x.Mode
+	}
+	return PinMode_PIN_MODE_INPUT
+}
+
+type ReadPinRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Pin           uint32                 `protobuf:"varint,1,opt,name=pin,proto3" json:"pin,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReadPinRequest) Reset() {
+	*x = ReadPinRequest{}
+	mi := &file_firmata_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReadPinRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReadPinRequest) ProtoMessage() {}
+
+func (x *ReadPinRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_firmata_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReadPinRequest.ProtoReflect.Descriptor instead.
+func (*ReadPinRequest) Descriptor() ([]byte, []int) {
+	return file_firmata_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ReadPinRequest) GetPin() uint32 {
+	if x != nil {
+		return x.Pin
+	}
+	return 0
+}
+
+type WritePinRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Pin   uint32                 `protobuf:"varint,1,opt,name=pin,proto3" json:"pin,omitempty"`
+	// 0 or 1 for OUTPUT pins, a duty cycle for PWM pins or an angle of 0 to
+	// 180 for SERVO pins
+	Value         int32 `protobuf:"varint,2,opt,name=value,proto3" json:"value,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WritePinRequest) Reset() {
+	*x = WritePinRequest{}
+	mi := &file_firmata_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WritePinRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WritePinRequest) ProtoMessage() {}
+
+func (x *WritePinRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_firmata_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WritePinRequest.ProtoReflect.Descriptor instead.
+func (*WritePinRequest) Descriptor() ([]byte, []int) {
+	return file_firmata_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *WritePinRequest) GetPin() uint32 {
+	if x != nil {
+		return x.Pin
+	}
+	return 0
+}
+
+func (x *WritePinRequest) GetValue() int32 {
+	if x != nil {
+		return x.Value
+	}
+	return 0
+}
+
+type PinValue struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Pin   uint32                 `protobuf:"varint,1,opt,name=pin,proto3" json:"pin,omitempty"`
+	Value int32                  `protobuf:"varint,2,opt,name=value,proto3" json:"value,omitempty"`
+	// Filtered reading of analog inputs with filters
+	Reading       *float64 `protobuf:"fixed64,3,opt,name=reading,proto3,oneof" json:"reading,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PinValue) Reset() {
+	*x = PinValue{}
+	mi := &file_firmata_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PinValue) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PinValue) ProtoMessage() {}
+
+func (x *PinValue) ProtoReflect() protoreflect.Message {
+	mi := &file_firmata_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PinValue.ProtoReflect.Descriptor instead.
+func (*PinValue) Descriptor() ([]byte, []int) {
+	return file_firmata_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *PinValue) GetPin() uint32 {
+	if x != nil {
+		return x.Pin
+	}
+	return 0
+}
+
+func (x *PinValue) GetValue() int32 {
+	if x != nil {
+		return x.Value
+	}
+	return 0
+}
+
+func (x *PinValue) GetReading() float64 {
+	if x != nil && x.Reading != nil {
+		return *x.Reading
+	}
+	return 0
+}
+
+type SubscribeRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Pins to stream, or all pins if empty
+	Pins          []uint32 `protobuf:"varint,1,rep,packed,name=pins,proto3" json:"pins,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubscribeRequest) Reset() {
+	*x = SubscribeRequest{}
+	mi := &file_firmata_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubscribeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubscribeRequest) ProtoMessage() {}
+
+func (x *SubscribeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_firmata_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubscribeRequest.ProtoReflect.Descriptor instead.
+func (*SubscribeRequest) Descriptor() ([]byte, []int) {
+	return file_firmata_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *SubscribeRequest) GetPins() []uint32 {
+	if x != nil {
+		return x.Pins
+	}
+	return nil
+}
+
+type PinEvent struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Pin   uint32                 `protobuf:"varint,1,opt,name=pin,proto3" json:"pin,omitempty"`
+	Mode  PinMode                `protobuf:"varint,2,opt,name=mode,proto3,enum=firmata.v1.PinMode" json:"mode,omitempty"`
+	Value int32                  `protobuf:"varint,3,opt,name=value,proto3" json:"value,omitempty"`
+	Time  *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=time,proto3" json:"time,omitempty"`
+	// Filtered reading of analog inputs with filters
+	Reading *float64 `protobuf:"fixed64,5,opt,name=reading,proto3,oneof" json:"reading,omitempty"`
+	// Direction of a digital input change. Not set for other pins or for the
+	// first report after a mode change.
+	Edge          Edge `protobuf:"varint,6,opt,name=edge,proto3,enum=firmata.v1.Edge" json:"edge,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PinEvent) Reset() {
+	*x = PinEvent{}
+	mi := &file_firmata_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PinEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PinEvent) ProtoMessage() {}
+
+func (x *PinEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_firmata_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PinEvent.ProtoReflect.Descriptor instead.
+func (*PinEvent) Descriptor() ([]byte, []int) {
+	return file_firmata_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *PinEvent) GetPin() uint32 {
+	if x != nil {
+		return x.Pin
+	}
+	return 0
+}
+
+func (x *PinEvent) GetMode() PinMode {
+	if x != nil {
+		return x.Mode
+	}
+	return PinMode_PIN_MODE_INPUT
+}
+
+func (x *PinEvent) GetValue() int32 {
+	if x != nil {
+		return x.Value
+	}
+	return 0
+}
+
+func (x *PinEvent) GetTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+func (x *PinEvent) GetReading() float64 {
+	if x != nil && x.Reading != nil {
+		return *x.Reading
+	}
+	return 0
+}
+
+func (x *PinEvent) GetEdge() Edge {
+	if x != nil {
+		return x.Edge
+	}
+	return Edge_EDGE_NONE
+}
+
+type I2CTransferRequest struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Address uint32                 `protobuf:"varint,1,opt,name=address,proto3" json:"address,omitempty"`
+	// Bytes to write before reading
+	Write []byte `protobuf:"bytes,2,opt,name=write,proto3" json:"write,omitempty"`
+	// Register to read from. The current register is read if unset.
+	Register *uint32 `protobuf:"varint,3,opt,name=register,proto3,oneof" json:"register,omitempty"`
+	// Number of bytes to read
+	Read          uint32 `protobuf:"varint,4,opt,name=read,proto3" json:"read,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *I2CTransferRequest) Reset() {
+	*x = I2CTransferRequest{}
+	mi := &file_firmata_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *I2CTransferRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*I2CTransferRequest) ProtoMessage() {}
+
+func (x *I2CTransferRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_firmata_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use I2CTransferRequest.ProtoReflect.Descriptor instead.
+func (*I2CTransferRequest) Descriptor() ([]byte, []int) {
+	return file_firmata_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *I2CTransferRequest) GetAddress() uint32 {
+	if x != nil {
+		return x.Address
+	}
+	return 0
+}
+
+func (x *I2CTransferRequest) GetWrite() []byte {
+	if x != nil {
+		return x.Write
+	}
+	return nil
+}
+
+func (x *I2CTransferRequest) GetRegister() uint32 {
+	if x != nil && x.Register != nil {
+		return *x.Register
+	}
+	return 0
+}
+
+func (x *I2CTransferRequest) GetRead() uint32 {
+	if x != nil {
+		return x.Read
+	}
+	return 0
+}
+
+type I2CTransferResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Data          []byte                 `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *I2CTransferResponse) Reset() {
+	*x = I2CTransferResponse{}
+	mi := &file_firmata_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *I2CTransferResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*I2CTransferResponse) ProtoMessage() {}
+
+func (x *I2CTransferResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_firmata_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use I2CTransferResponse.ProtoReflect.Descriptor instead.
+func (*I2CTransferResponse) Descriptor() ([]byte, []int) {
+	return file_firmata_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *I2CTransferResponse) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+type SPITransferRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	CsPin uint32                 `protobuf:"varint,1,opt,name=cs_pin,json=csPin,proto3" json:"cs_pin,omitempty"`
+	// SPI mode 0 to 3. The device is configured on its first transfer and
+	// whenever the mode changes.
+	Mode          uint32 `protobuf:"varint,2,opt,name=mode,proto3" json:"mode,omitempty"`
+	Data          []byte `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SPITransferRequest) Reset() {
+	*x = SPITransferRequest{}
+	mi := &file_firmata_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SPITransferRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SPITransferRequest) ProtoMessage() {}
+
+func (x *SPITransferRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_firmata_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SPITransferRequest.ProtoReflect.Descriptor instead.
+func (*SPITransferRequest) Descriptor() ([]byte, []int) {
+	return file_firmata_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *SPITransferRequest) GetCsPin() uint32 {
+	if x != nil {
+		return x.CsPin
+	}
+	return 0
+}
+
+func (x *SPITransferRequest) GetMode() uint32 {
+	if x != nil {
+		return x.Mode
+	}
+	return 0
+}
+
+func (x *SPITransferRequest) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+type SPITransferResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Bytes clocked in while data was written
+	Data          []byte `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SPITransferResponse) Reset() {
+	*x = SPITransferResponse{}
+	mi := &file_firmata_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SPITransferResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SPITransferResponse) ProtoMessage() {}
+
+func (x *SPITransferResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_firmata_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SPITransferResponse.ProtoReflect.Descriptor instead.
+func (*SPITransferResponse) Descriptor() ([]byte, []int) {
+	return file_firmata_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *SPITransferResponse) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+type SerialConfigRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Port  SerialPort             `protobuf:"varint,1,opt,name=port,proto3,enum=firmata.v1.SerialPort" json:"port,omitempty"`
+	Baud  uint32                 `protobuf:"varint,2,opt,name=baud,proto3" json:"baud,omitempty"`
+	// Pins of a software serial port, 0 for hardware ports
+	TxPin         uint32 `protobuf:"varint,3,opt,name=tx_pin,json=txPin,proto3" json:"tx_pin,omitempty"`
+	RxPin         uint32 `protobuf:"varint,4,opt,name=rx_pin,json=rxPin,proto3" json:"rx_pin,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SerialConfigRequest) Reset() {
+	*x = SerialConfigRequest{}
+	mi := &file_firmata_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SerialConfigRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SerialConfigRequest) ProtoMessage() {}
+
+func (x *SerialConfigRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_firmata_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SerialConfigRequest.ProtoReflect.Descriptor instead.
+func (*SerialConfigRequest) Descriptor() ([]byte, []int) {
+	return file_firmata_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *SerialConfigRequest) GetPort() SerialPort {
+	if x != nil {
+		return x.Port
+	}
+	return SerialPort_SERIAL_PORT_SOFT
+}
+
+func (x *SerialConfigRequest) GetBaud() uint32 {
+	if x != nil {
+		return x.Baud
+	}
+	return 0
+}
+
+func (x *SerialConfigRequest) GetTxPin() uint32 {
+	if x != nil {
+		return x.TxPin
+	}
+	return 0
+}
+
+func (x *SerialConfigRequest) GetRxPin() uint32 {
+	if x != nil {
+		return x.RxPin
+	}
+	return 0
+}
+
+type SerialConfigResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SerialConfigResponse) Reset() {
+	*x = SerialConfigResponse{}
+	mi := &file_firmata_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SerialConfigResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SerialConfigResponse) ProtoMessage() {}
+
+func (x *SerialConfigResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_firmata_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SerialConfigResponse.ProtoReflect.Descriptor instead.
+func (*SerialConfigResponse) Descriptor() ([]byte, []int) {
+	return file_firmata_proto_rawDescGZIP(), []int{18}
+}
+
+type SerialWriteRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Port          SerialPort             `protobuf:"varint,1,opt,name=port,proto3,enum=firmata.v1.SerialPort" json:"port,omitempty"`
+	Data          []byte                 `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SerialWriteRequest) Reset() {
+	*x = SerialWriteRequest{}
+	mi := &file_firmata_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SerialWriteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SerialWriteRequest) ProtoMessage() {}
+
+func (x *SerialWriteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_firmata_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SerialWriteRequest.ProtoReflect.Descriptor instead.
+func (*SerialWriteRequest) Descriptor() ([]byte, []int) {
+	return file_firmata_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *SerialWriteRequest) GetPort() SerialPort {
+	if x != nil {
+		return x.Port
+	}
+	return SerialPort_SERIAL_PORT_SOFT
+}
+
+func (x *SerialWriteRequest) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+type SerialWriteResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SerialWriteResponse) Reset() {
+	*x = SerialWriteResponse{}
+	mi := &file_firmata_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SerialWriteResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SerialWriteResponse) ProtoMessage() {}
+
+func (x *SerialWriteResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_firmata_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SerialWriteResponse.ProtoReflect.Descriptor instead.
+func (*SerialWriteResponse) Descriptor() ([]byte, []int) {
+	return file_firmata_proto_rawDescGZIP(), []int{20}
+}
+
+type SerialReadRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SerialReadRequest) Reset() {
+	*x = SerialReadRequest{}
+	mi := &file_firmata_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SerialReadRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SerialReadRequest) ProtoMessage() {}
+
+func (x *SerialReadRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_firmata_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SerialReadRequest.ProtoReflect.Descriptor instead.
+func (*SerialReadRequest) Descriptor() ([]byte, []int) {
+	return file_firmata_proto_rawDescGZIP(), []int{21}
+}
+
+type SerialData struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Data  []byte                 `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+	// Port the data was received on
+	Port          SerialPort `protobuf:"varint,2,opt,name=port,proto3,enum=firmata.v1.SerialPort" json:"port,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SerialData) Reset() {
+	*x = SerialData{}
+	mi := &file_firmata_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SerialData) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SerialData) ProtoMessage() {}
+
+func (x *SerialData) ProtoReflect() protoreflect.Message {
+	mi := &file_firmata_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SerialData.ProtoReflect.Descriptor instead.
+func (*SerialData) Descriptor() ([]byte, []int) {
+	return file_firmata_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *SerialData) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *SerialData) GetPort() SerialPort {
+	if x != nil {
+		return x.Port
+	}
+	return SerialPort_SERIAL_PORT_SOFT
+}
+
+var File_firmata_proto protoreflect.FileDescriptor
+
+const file_firmata_proto_rawDesc = "" +
+	"\n" +
+	"\rfirmata.proto\x12\n" +
+	"firmata.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\x10\n" +
+	"\x0eGetInfoRequest\"\x89\x02\n" +
+	"\tBoardInfo\x12#\n" +
+	"\rfirmware_name\x18\x01 \x01(\tR\ffirmwareName\x12%\n" +
+	"\x0efirmware_major\x18\x02 \x01(\rR\rfirmwareMajor\x12%\n" +
+	"\x0efirmware_minor\x18\x03 \x01(\rR\rfirmwareMinor\x12%\n" +
+	"\x0eprotocol_major\x18\x04 \x01(\rR\rprotocolMajor\x12%\n" +
+	"\x0eprotocol_minor\x18\x05 \x01(\rR\rprotocolMinor\x12\x12\n" +
+	"\x04pins\x18\x06 \x01(\rR\x04pins\x12'\n" +
+	"\x0fanalog_channels\x18\a \x01(\rR\x0eanalogChannels\"U\n" +
+	"\n" +
+	"Capability\x12'\n" +
+	"\x04mode\x18\x01 \x01(\x0e2\x13.firmata.v1.PinModeR\x04mode\x12\x1e\n" +
+	"\n" +
+	"resolution\x18\x02 \x01(\rR\n" +
+	"resolution\"\xe4\x01\n" +
+	"\x03Pin\x12\x10\n" +
+	"\x03pin\x18\x01 \x01(\rR\x03pin\x12'\n" +
+	"\x04mode\x18\x02 \x01(\x0e2\x13.firmata.v1.PinModeR\x04mode\x12\x14\n" +
+	"\x05value\x18\x03 \x01(\x05R\x05value\x12:\n" +
+	"\fcapabilities\x18\x04 \x03(\v2\x16.firmata.v1.CapabilityR\fcapabilities\x12%\n" +
+	"\x0eanalog_channel\x18\x05 \x01(\x05R\ranalogChannel\x12\x1d\n" +
+	"\areading\x18\x06 \x01(\x01H\x00R\areading\x88\x01\x01B\n" +
+	"\n" +
+	"\b_reading\"\x11\n" +
+	"\x0fListPinsRequest\"7\n" +
+	"\x10ListPinsResponse\x12#\n" +
+	"\x04pins\x18\x01 \x03(\v2\x0f.firmata.v1.PinR\x04pins\"!\n" +
+	"\rGetPinRequest\x12\x10\n" +
+	"\x03pin\x18\x01 \x01(\rR\x03pin\"N\n" +
+	"\x11SetPinModeRequest\x12\x10\n" +
+	"\x03pin\x18\x01 \x01(\rR\x03pin\x12'\n" +
+	"\x04mode\x18\x02 \x01(\x0e2\x13.firmata.v1.PinModeR\x04mode\"\"\n" +
+	"\x0eReadPinRequest\x12\x10\n" +
+	"\x03pin\x18\x01 \x01(\rR\x03pin\"9\n" +
+	"\x0fWritePinRequest\x12\x10\n" +
+	"\x03pin\x18\x01 \x01(\rR\x03pin\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x05R\x05value\"]\n" +
+	"\bPinValue\x12\x10\n" +
+	"\x03pin\x18\x01 \x01(\rR\x03pin\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x05R\x05value\x12\x1d\n" +
+	"\areading\x18\x03 \x01(\x01H\x00R\areading\x88\x01\x01B\n" +
+	"\n" +
+	"\b_reading\"&\n" +
+	"\x10SubscribeRequest\x12\x12\n" +
+	"\x04pins\x18\x01 \x03(\rR\x04pins\"\xdc\x01\n" +
+	"\bPinEvent\x12\x10\n" +
+	"\x03pin\x18\x01 \x01(\rR\x03pin\x12'\n" +
+	"\x04mode\x18\x02 \x01(\x0e2\x13.firmata.v1.PinModeR\x04mode\x12\x14\n" +
+	"\x05value\x18\x03 \x01(\x05R\x05value\x12.\n" +
+	"\x04time\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\x04time\x12\x1d\n" +
+	"\areading\x18\x05 \x01(\x01H\x00R\areading\x88\x01\x01\x12$\n" +
+	"\x04edge\x18\x06 \x01(\x0e2\x10.firmata.v1.EdgeR\x04edgeB\n" +
+	"\n" +
+	"\b_reading\"\x86\x01\n" +
+	"\x12I2CTransferRequest\x12\x18\n" +
+	"\aaddress\x18\x01 \x01(\rR\aaddress\x12\x14\n" +
+	"\x05write\x18\x02 \x01(\fR\x05write\x12\x1f\n" +
+	"\bregister\x18\x03 \x01(\rH\x00R\bregister\x88\x01\x01\x12\x12\n" +
+	"\x04read\x18\x04 \x01(\rR\x04readB\v\n" +
+	"\t_register\")\n" +
+	"\x13I2CTransferResponse\x12\x12\n" +
+	"\x04data\x18\x01 \x01(\fR\x04data\"S\n" +
+	"\x12SPITransferRequest\x12\x15\n" +
+	"\x06cs_pin\x18\x01 \x01(\rR\x05csPin\x12\x12\n" +
+	"\x04mode\x18\x02 \x01(\rR\x04mode\x12\x12\n" +
+	"\x04data\x18\x03 \x01(\fR\x04data\")\n" +
+	"\x13SPITransferResponse\x12\x12\n" +
+	"\x04data\x18\x01 \x01(\fR\x04data\"\x83\x01\n" +
+	"\x13SerialConfigRequest\x12*\n" +
+	"\x04port\x18\x01 \x01(\x0e2\x16.firmata.v1.SerialPortR\x04port\x12\x12\n" +
+	"\x04baud\x18\x02 \x01(\rR\x04baud\x12\x15\n" +
+	"\x06tx_pin\x18\x03 \x01(\rR\x05txPin\x12\x15\n" +
+	"\x06rx_pin\x18\x04 \x01(\rR\x05rxPin\"\x16\n" +
+	"\x14SerialConfigResponse\"T\n" +
+	"\x12SerialWriteRequest\x12*\n" +
+	"\x04port\x18\x01 \x01(\x0e2\x16.firmata.v1.SerialPortR\x04port\x12\x12\n" +
+	"\x04data\x18\x02 \x01(\fR\x04data\"\x15\n" +
+	"\x13SerialWriteResponse\"\x13\n" +
+	"\x11SerialReadRequest\"L\n" +
+	"\n" +
+	"SerialData\x12\x12\n" +
+	"\x04data\x18\x01 \x01(\fR\x04data\x12*\n" +
+	"\x04port\x18\x02 \x01(\x0e2\x16.firmata.v1.SerialPortR\x04port*\xf7\x02\n" +
+	"\aPinMode\x12\x12\n" +
+	"\x0ePIN_MODE_INPUT\x10\x00\x12\x13\n" +
+	"\x0fPIN_MODE_OUTPUT\x10\x01\x12\x13\n" +
+	"\x0fPIN_MODE_ANALOG\x10\x02\x12\x10\n" +
+	"\fPIN_MODE_PWM\x10\x03\x12\x12\n" +
+	"\x0ePIN_MODE_SERVO\x10\x04\x12\x12\n" +
+	"\x0ePIN_MODE_SHIFT\x10\x05\x12\x10\n" +
+	"\fPIN_MODE_I2C\x10\x06\x12\x14\n" +
+	"\x10PIN_MODE_ONEWIRE\x10\a\x12\x14\n" +
+	"\x10PIN_MODE_STEPPER\x10\b\x12\x14\n" +
+	"\x10PIN_MODE_ENCODER\x10\t\x12\x13\n" +
+	"\x0fPIN_MODE_SERIAL\x10\n" +
+	"\x12\x13\n" +
+	"\x0fPIN_MODE_PULLUP\x10\v\x12\x10\n" +
+	"\fPIN_MODE_SPI\x10\f\x12\x12\n" +
+	"\x0ePIN_MODE_SONAR\x10\r\x12\x11\n" +
+	"\rPIN_MODE_TONE\x10\x0e\x12\x10\n" +
+	"\fPIN_MODE_DHT\x10\x0f\x12\x16\n" +
+	"\x12PIN_MODE_FREQUENCY\x10\x10\x12\x13\n" +
+	"\x0fPIN_MODE_IGNORE\x10\x7f*8\n" +
+	"\x04Edge\x12\r\n" +
+	"\tEDGE_NONE\x10\x00\x12\x0f\n" +
+	"\vEDGE_RISING\x10\x01\x12\x10\n" +
+	"\fEDGE_FALLING\x10\x02*a\n" +
+	"\n" +
+	"SerialPort\x12\x14\n" +
+	"\x10SERIAL_PORT_SOFT\x10\x00\x12\x13\n" +
+	"\x0fSERIAL_PORT_HW1\x10\x01\x12\x13\n" +
+	"\x0fSERIAL_PORT_HW2\x10\x02\x12\x13\n" +
+	"\x0fSERIAL_PORT_HW3\x10\x032\xcb\x06\n" +
+	"\aFirmata\x12<\n" +
+	"\aGetInfo\x12\x1a.firmata.v1.GetInfoRequest\x1a\x15.firmata.v1.BoardInfo\x12E\n" +
+	"\bListPins\x12\x1b.firmata.v1.ListPinsRequest\x1a\x1c.firmata.v1.ListPinsResponse\x124\n" +
+	"\x06GetPin\x12\x19.firmata.v1.GetPinRequest\x1a\x0f.firmata.v1.Pin\x12<\n" +
+	"\n" +
+	"SetPinMode\x12\x1d.firmata.v1.SetPinModeRequest\x1a\x0f.firmata.v1.Pin\x12;\n" +
+	"\aReadPin\x12\x1a.firmata.v1.ReadPinRequest\x1a\x14.firmata.v1.PinValue\x12=\n" +
+	"\bWritePin\x12\x1b.firmata.v1.WritePinRequest\x1a\x14.firmata.v1.PinValue\x12A\n" +
+	"\tSubscribe\x12\x1c.firmata.v1.SubscribeRequest\x1a\x14.firmata.v1.PinEvent0\x01\x12N\n" +
+	"\vI2CTransfer\x12\x1e.firmata.v1.I2CTransferRequest\x1a\x1f.firmata.v1.I2CTransferResponse\x12N\n" +
+	"\vSPITransfer\x12\x1e.firmata.v1.SPITransferRequest\x1a\x1f.firmata.v1.SPITransferResponse\x12Q\n" +
+	"\fSerialConfig\x12\x1f.firmata.v1.SerialConfigRequest\x1a .firmata.v1.SerialConfigResponse\x12N\n" +
+	"\vSerialWrite\x12\x1e.firmata.v1.SerialWriteRequest\x1a\x1f.firmata.v1.SerialWriteResponse\x12E\n" +
+	"\n" +
+	"SerialRead\x12\x1d.firmata.v1.SerialReadRequest\x1a\x16.firmata.v1.SerialData0\x01B0Z.github.com/kraman/go-firmata/grpcapi/firmatapbb\x06proto3"
+
+var (
+	file_firmata_proto_rawDescOnce sync.Once
+	file_firmata_proto_rawDescData []byte
+)
+
+func file_firmata_proto_rawDescGZIP() []byte {
+	file_firmata_proto_rawDescOnce.Do(func() {
+		file_firmata_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_firmata_proto_rawDesc), len(file_firmata_proto_rawDesc)))
+	})
+	return file_firmata_proto_rawDescData
+}
+
+var file_firmata_proto_enumTypes = make([]protoimpl.EnumInfo, 3)
+var file_firmata_proto_msgTypes = make([]protoimpl.MessageInfo, 23)
+var file_firmata_proto_goTypes = []any{
+	(PinMode)(0),                  // 0: firmata.v1.PinMode
+	(Edge)(0),                     // 1: firmata.v1.Edge
+	(SerialPort)(0),               // 2: firmata.v1.SerialPort
+	(*GetInfoRequest)(nil),        // 3: firmata.v1.GetInfoRequest
+	(*BoardInfo)(nil),             // 4: firmata.v1.BoardInfo
+	(*Capability)(nil),            // 5: firmata.v1.Capability
+	(*Pin)(nil),                   // 6: firmata.v1.Pin
+	(*ListPinsRequest)(nil),       // 7: firmata.v1.ListPinsRequest
+	(*ListPinsResponse)(nil),      // 8: firmata.v1.ListPinsResponse
+	(*GetPinRequest)(nil),         // 9: firmata.v1.GetPinRequest
+	(*SetPinModeRequest)(nil),     // 10: firmata.v1.SetPinModeRequest
+	(*ReadPinRequest)(nil),        // 11: firmata.v1.ReadPinRequest
+	(*WritePinRequest)(nil),       // 12: firmata.v1.WritePinRequest
+	(*PinValue)(nil),              // 13: firmata.v1.PinValue
+	(*SubscribeRequest)(nil),      // 14: firmata.v1.SubscribeRequest
+	(*PinEvent)(nil),              // 15: firmata.v1.PinEvent
+	(*I2CTransferRequest)(nil),    // 16: firmata.v1.I2CTransferRequest
+	(*I2CTransferResponse)(nil),   // 17: firmata.v1.I2CTransferResponse
+	(*SPITransferRequest)(nil),    // 18: firmata.v1.SPITransferRequest
+	(*SPITransferResponse)(nil),   // 19: firmata.v1.SPITransferResponse
+	(*SerialConfigRequest)(nil),   // 20: firmata.v1.SerialConfigRequest
+	(*SerialConfigResponse)(nil),  // 21: firmata.v1.SerialConfigResponse
+	(*SerialWriteRequest)(nil),    // 22: firmata.v1.SerialWriteRequest
+	(*SerialWriteResponse)(nil),   // 23: firmata.v1.SerialWriteResponse
+	(*SerialReadRequest)(nil),     // 24: firmata.v1.SerialReadRequest
+	(*SerialData)(nil),            // 25: firmata.v1.SerialData
+	(*timestamppb.Timestamp)(nil), // 26: google.protobuf.Timestamp
+}
+var file_firmata_proto_depIdxs = []int32{
+	0,  // 0: firmata.v1.Capability.mode:type_name -> firmata.v1.PinMode
+	0,  // 1: firmata.v1.Pin.mode:type_name -> firmata.v1.PinMode
+	5,  // 2: firmata.v1.Pin.capabilities:type_name -> firmata.v1.Capability
+	6,  // 3: firmata.v1.ListPinsResponse.pins:type_name -> firmata.v1.Pin
+	0,  // 4: firmata.v1.SetPinModeRequest.mode:type_name -> firmata.v1.PinMode
+	0,  // 5: firmata.v1.PinEvent.mode:type_name -> firmata.v1.PinMode
+	26, // 6: firmata.v1.PinEvent.time:type_name -> google.protobuf.Timestamp
+	1,  // 7: firmata.v1.PinEvent.edge:type_name -> firmata.v1.Edge
+	2,  // 8: firmata.v1.SerialConfigRequest.port:type_name -> firmata.v1.SerialPort
+	2,  // 9: firmata.v1.SerialWriteRequest.port:type_name -> firmata.v1.SerialPort
+	2,  // 10: firmata.v1.SerialData.port:type_name -> firmata.v1.SerialPort
+	3,  // 11: firmata.v1.Firmata.GetInfo:input_type -> firmata.v1.GetInfoRequest
+	7,  // 12: firmata.v1.Firmata.ListPins:input_type -> firmata.v1.ListPinsRequest
+	9,  // 13: firmata.v1.Firmata.GetPin:input_type -> firmata.v1.GetPinRequest
+	10, // 14: firmata.v1.Firmata.SetPinMode:input_type -> firmata.v1.SetPinModeRequest
+	11, // 15: firmata.v1.Firmata.ReadPin:input_type -> firmata.v1.ReadPinRequest
+	12, // 16: firmata.v1.Firmata.WritePin:input_type -> firmata.v1.WritePinRequest
+	14, // 17: firmata.v1.Firmata.Subscribe:input_type -> firmata.v1.SubscribeRequest
+	16, // 18: firmata.v1.Firmata.I2CTransfer:input_type -> firmata.v1.I2CTransferRequest
+	18, // 19: firmata.v1.Firmata.SPITransfer:input_type -> firmata.v1.SPITransferRequest
+	20, // 20: firmata.v1.Firmata.SerialConfig:input_type -> firmata.v1.SerialConfigRequest
+	22, // 21: firmata.v1.Firmata.SerialWrite:input_type -> firmata.v1.SerialWriteRequest
+	24, // 22: firmata.v1.Firmata.SerialRead:input_type -> firmata.v1.SerialReadRequest
+	4,  // 23: firmata.v1.Firmata.GetInfo:output_type -> firmata.v1.BoardInfo
+	8,  // 24: firmata.v1.Firmata.ListPins:output_type -> firmata.v1.ListPinsResponse
+	6,  // 25: firmata.v1.Firmata.GetPin:output_type -> firmata.v1.Pin
+	6,  // 26: firmata.v1.Firmata.SetPinMode:output_type -> firmata.v1.Pin
+	13, // 27: firmata.v1.Firmata.ReadPin:output_type -> firmata.v1.PinValue
+	13, // 28: firmata.v1.Firmata.WritePin:output_type -> firmata.v1.PinValue
+	15, // 29: firmata.v1.Firmata.Subscribe:output_type -> firmata.v1.PinEvent
+	17, // 30: firmata.v1.Firmata.I2CTransfer:output_type -> firmata.v1.I2CTransferResponse
+	19, // 31: firmata.v1.Firmata.SPITransfer:output_type -> firmata.v1.SPITransferResponse
+	21, // 32: firmata.v1.Firmata.SerialConfig:output_type -> firmata.v1.SerialConfigResponse
+	23, // 33: firmata.v1.Firmata.SerialWrite:output_type -> firmata.v1.SerialWriteResponse
+	25, // 34: firmata.v1.Firmata.SerialRead:output_type -> firmata.v1.SerialData
+	23, // [23:35] is the sub-list for method output_type
+	11, // [11:23] is the sub-list for method input_type
+	11, // [11:11] is the sub-list for extension type_name
+	11, // [11:11] is the sub-list for extension extendee
+	0,  // [0:11] is the sub-list for field type_name
+}
+
+func init() { file_firmata_proto_init() }
+func file_firmata_proto_init() {
+	if File_firmata_proto != nil {
+		return
+	}
+	file_firmata_proto_msgTypes[3].OneofWrappers = []any{}
+	file_firmata_proto_msgTypes[10].OneofWrappers = []any{}
+	file_firmata_proto_msgTypes[12].OneofWrappers = []any{}
+	file_firmata_proto_msgTypes[13].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_firmata_proto_rawDesc), len(file_firmata_proto_rawDesc)),
+			NumEnums:      3,
+			NumMessages:   23,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_firmata_proto_goTypes,
+		DependencyIndexes: file_firmata_proto_depIdxs,
+		EnumInfos:         file_firmata_proto_enumTypes,
+		MessageInfos:      file_firmata_proto_msgTypes,
+	}.Build()
+	File_firmata_proto = out.File
+	file_firmata_proto_goTypes = nil
+	file_firmata_proto_depIdxs = nil
+}
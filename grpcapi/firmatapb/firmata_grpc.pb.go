@@ -0,0 +1,594 @@
+// Copyright 2014 Krishna Raman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: firmata.proto
+
+package firmatapb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Firmata_GetInfo_FullMethodName      = "/firmata.v1.Firmata/GetInfo"
+	Firmata_ListPins_FullMethodName     = "/firmata.v1.Firmata/ListPins"
+	Firmata_GetPin_FullMethodName       = "/firmata.v1.Firmata/GetPin"
+	Firmata_SetPinMode_FullMethodName   = "/firmata.v1.Firmata/SetPinMode"
+	Firmata_ReadPin_FullMethodName      = "/firmata.v1.Firmata/ReadPin"
+	Firmata_WritePin_FullMethodName     = "/firmata.v1.Firmata/WritePin"
+	Firmata_Subscribe_FullMethodName    = "/firmata.v1.Firmata/Subscribe"
+	Firmata_I2CTransfer_FullMethodName  = "/firmata.v1.Firmata/I2CTransfer"
+	Firmata_SPITransfer_FullMethodName  = "/firmata.v1.Firmata/SPITransfer"
+	Firmata_SerialConfig_FullMethodName = "/firmata.v1.Firmata/SerialConfig"
+	Firmata_SerialWrite_FullMethodName  = "/firmata.v1.Firmata/SerialWrite"
+	Firmata_SerialRead_FullMethodName   = "/firmata.v1.Firmata/SerialRead"
+)
+
+// FirmataClient is the client API for Firmata service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Remote control of a Firmata board
+type FirmataClient interface {
+	// Firmware and protocol versions reported by the board
+	GetInfo(ctx context.Context, in *GetInfoRequest, opts ...grpc.CallOption) (*BoardInfo, error)
+	// State of all pins
+	ListPins(ctx context.Context, in *ListPinsRequest, opts ...grpc.CallOption) (*ListPinsResponse, error)
+	// State of a pin
+	GetPin(ctx context.Context, in *GetPinRequest, opts ...grpc.CallOption) (*Pin, error)
+	// Set the mode of a pin. Fails with INVALID_ARGUMENT if the pin does not
+	// support the mode.
+	SetPinMode(ctx context.Context, in *SetPinModeRequest, opts ...grpc.CallOption) (*Pin, error)
+	// Current value of a pin
+	ReadPin(ctx context.Context, in *ReadPinRequest, opts ...grpc.CallOption) (*PinValue, error)
+	// Write to an OUTPUT, PWM or SERVO pin
+	WritePin(ctx context.Context, in *WritePinRequest, opts ...grpc.CallOption) (*PinValue, error)
+	// Stream value changes of pins until the call is cancelled. The current
+	// value of each pin is sent first.
+	Subscribe(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[PinEvent], error)
+	// Write to and/or read from an I2C device
+	I2CTransfer(ctx context.Context, in *I2CTransferRequest, opts ...grpc.CallOption) (*I2CTransferResponse, error)
+	// Full-duplex transfer with the SPI device on a chip-select pin
+	SPITransfer(ctx context.Context, in *SPITransferRequest, opts ...grpc.CallOption) (*SPITransferResponse, error)
+	// Configure a serial port of the board
+	SerialConfig(ctx context.Context, in *SerialConfigRequest, opts ...grpc.CallOption) (*SerialConfigResponse, error)
+	// Send data out of a serial port
+	SerialWrite(ctx context.Context, in *SerialWriteRequest, opts ...grpc.CallOption) (*SerialWriteResponse, error)
+	// Stream data received on the configured serial ports. Every stream
+	// receives all data.
+	SerialRead(ctx context.Context, in *SerialReadRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[SerialData], error)
+}
+
+type firmataClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewFirmataClient(cc grpc.ClientConnInterface) FirmataClient {
+	return &firmataClient{cc}
+}
+
+func (c *firmataClient) GetInfo(ctx context.Context, in *GetInfoRequest, opts ...grpc.CallOption) (*BoardInfo, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BoardInfo)
+	err := c.cc.Invoke(ctx, Firmata_GetInfo_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *firmataClient) ListPins(ctx context.Context, in *ListPinsRequest, opts ...grpc.CallOption) (*ListPinsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListPinsResponse)
+	err := c.cc.Invoke(ctx, Firmata_ListPins_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *firmataClient) GetPin(ctx context.Context, in *GetPinRequest, opts ...grpc.CallOption) (*Pin, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Pin)
+	err := c.cc.Invoke(ctx, Firmata_GetPin_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *firmataClient) SetPinMode(ctx context.Context, in *SetPinModeRequest, opts ...grpc.CallOption) (*Pin, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Pin)
+	err := c.cc.Invoke(ctx, Firmata_SetPinMode_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *firmataClient) ReadPin(ctx context.Context, in *ReadPinRequest, opts ...grpc.CallOption) (*PinValue, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PinValue)
+	err := c.cc.Invoke(ctx, Firmata_ReadPin_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *firmataClient) WritePin(ctx context.Context, in *WritePinRequest, opts ...grpc.CallOption) (*PinValue, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PinValue)
+	err := c.cc.Invoke(ctx, Firmata_WritePin_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *firmataClient) Subscribe(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[PinEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Firmata_ServiceDesc.Streams[0], Firmata_Subscribe_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[SubscribeRequest, PinEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Firmata_SubscribeClient = grpc.ServerStreamingClient[PinEvent]
+
+func (c *firmataClient) I2CTransfer(ctx context.Context, in *I2CTransferRequest, opts ...grpc.CallOption) (*I2CTransferResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(I2CTransferResponse)
+	err := c.cc.Invoke(ctx, Firmata_I2CTransfer_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *firmataClient) SPITransfer(ctx context.Context, in *SPITransferRequest, opts ...grpc.CallOption) (*SPITransferResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SPITransferResponse)
+	err := c.cc.Invoke(ctx, Firmata_SPITransfer_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *firmataClient) SerialConfig(ctx context.Context, in *SerialConfigRequest, opts ...grpc.CallOption) (*SerialConfigResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SerialConfigResponse)
+	err := c.cc.Invoke(ctx, Firmata_SerialConfig_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *firmataClient) SerialWrite(ctx context.Context, in *SerialWriteRequest, opts ...grpc.CallOption) (*SerialWriteResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SerialWriteResponse)
+	err := c.cc.Invoke(ctx, Firmata_SerialWrite_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *firmataClient) SerialRead(ctx context.Context, in *SerialReadRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[SerialData], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Firmata_ServiceDesc.Streams[1], Firmata_SerialRead_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[SerialReadRequest, SerialData]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Firmata_SerialReadClient = grpc.ServerStreamingClient[SerialData]
+
+// FirmataServer is the server API for Firmata service.
+// All implementations must embed UnimplementedFirmataServer
+// for forward compatibility.
+//
+// Remote control of a Firmata board
+type FirmataServer interface {
+	// Firmware and protocol versions reported by the board
+	GetInfo(context.Context, *GetInfoRequest) (*BoardInfo, error)
+	// State of all pins
+	ListPins(context.Context, *ListPinsRequest) (*ListPinsResponse, error)
+	// State of a pin
+	GetPin(context.Context, *GetPinRequest) (*Pin, error)
+	// Set the mode of a pin. Fails with INVALID_ARGUMENT if the pin does not
+	// support the mode.
+	SetPinMode(context.Context, *SetPinModeRequest) (*Pin, error)
+	// Current value of a pin
+	ReadPin(context.Context, *ReadPinRequest) (*PinValue, error)
+	// Write to an OUTPUT, PWM or SERVO pin
+	WritePin(context.Context, *WritePinRequest) (*PinValue, error)
+	// Stream value changes of pins until the call is cancelled. The current
+	// value of each pin is sent first.
+	Subscribe(*SubscribeRequest, grpc.ServerStreamingServer[PinEvent]) error
+	// Write to and/or read from an I2C device
+	I2CTransfer(context.Context, *I2CTransferRequest) (*I2CTransferResponse, error)
+	// Full-duplex transfer with the SPI device on a chip-select pin
+	SPITransfer(context.Context, *SPITransferRequest) (*SPITransferResponse, error)
+	// Configure a serial port of the board
+	SerialConfig(context.Context, *SerialConfigRequest) (*SerialConfigResponse, error)
+	// Send data out of a serial port
+	SerialWrite(context.Context, *SerialWriteRequest) (*SerialWriteResponse, error)
+	// Stream data received on the configured serial ports. Every stream
+	// receives all data.
+	SerialRead(*SerialReadRequest, grpc.ServerStreamingServer[SerialData]) error
+	mustEmbedUnimplementedFirmataServer()
+}
+
+// UnimplementedFirmataServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedFirmataServer struct{}
+
+func (UnimplementedFirmataServer) GetInfo(context.Context, *GetInfoRequest) (*BoardInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetInfo not implemented")
+}
+func (UnimplementedFirmataServer) ListPins(context.Context, *ListPinsRequest) (*ListPinsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListPins not implemented")
+}
+func (UnimplementedFirmataServer) GetPin(context.Context, *GetPinRequest) (*Pin, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPin not implemented")
+}
+func (UnimplementedFirmataServer) SetPinMode(context.Context, *SetPinModeRequest) (*Pin, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetPinMode not implemented")
+}
+func (UnimplementedFirmataServer) ReadPin(context.Context, *ReadPinRequest) (*PinValue, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReadPin not implemented")
+}
+func (UnimplementedFirmataServer) WritePin(context.Context, *WritePinRequest) (*PinValue, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method WritePin not implemented")
+}
+func (UnimplementedFirmataServer) Subscribe(*SubscribeRequest, grpc.ServerStreamingServer[PinEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method Subscribe not implemented")
+}
+func (UnimplementedFirmataServer) I2CTransfer(context.Context, *I2CTransferRequest) (*I2CTransferResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method I2CTransfer not implemented")
+}
+func (UnimplementedFirmataServer) SPITransfer(context.Context, *SPITransferRequest) (*SPITransferResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SPITransfer not implemented")
+}
+func (UnimplementedFirmataServer) SerialConfig(context.Context, *SerialConfigRequest) (*SerialConfigResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SerialConfig not implemented")
+}
+func (UnimplementedFirmataServer) SerialWrite(context.Context, *SerialWriteRequest) (*SerialWriteResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SerialWrite not implemented")
+}
+func (UnimplementedFirmataServer) SerialRead(*SerialReadRequest, grpc.ServerStreamingServer[SerialData]) error {
+	return status.Errorf(codes.Unimplemented, "method SerialRead not implemented")
+}
+func (UnimplementedFirmataServer) mustEmbedUnimplementedFirmataServer() {}
+func (UnimplementedFirmataServer) testEmbeddedByValue()                 {}
+
+// UnsafeFirmataServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to FirmataServer will
+// result in compilation errors.
+type UnsafeFirmataServer interface {
+	mustEmbedUnimplementedFirmataServer()
+}
+
+func RegisterFirmataServer(s grpc.ServiceRegistrar, srv FirmataServer) {
+	// If the following call pancis, it indicates UnimplementedFirmataServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Firmata_ServiceDesc, srv)
+}
+
+func _Firmata_GetInfo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetInfoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FirmataServer).GetInfo(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Firmata_GetInfo_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FirmataServer).GetInfo(ctx, req.(*GetInfoRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Firmata_ListPins_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListPinsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FirmataServer).ListPins(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Firmata_ListPins_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FirmataServer).ListPins(ctx, req.(*ListPinsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Firmata_GetPin_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetPinRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FirmataServer).GetPin(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Firmata_GetPin_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FirmataServer).GetPin(ctx, req.(*GetPinRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Firmata_SetPinMode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetPinModeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FirmataServer).SetPinMode(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Firmata_SetPinMode_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FirmataServer).SetPinMode(ctx, req.(*SetPinModeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Firmata_ReadPin_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReadPinRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FirmataServer).ReadPin(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Firmata_ReadPin_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FirmataServer).ReadPin(ctx, req.(*ReadPinRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Firmata_WritePin_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WritePinRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FirmataServer).WritePin(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Firmata_WritePin_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FirmataServer).WritePin(ctx, req.(*WritePinRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Firmata_Subscribe_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SubscribeRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(FirmataServer).Subscribe(m, &grpc.GenericServerStream[SubscribeRequest, PinEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Firmata_SubscribeServer = grpc.ServerStreamingServer[PinEvent]
+
+func _Firmata_I2CTransfer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(I2CTransferRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FirmataServer).I2CTransfer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Firmata_I2CTransfer_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FirmataServer).I2CTransfer(ctx, req.(*I2CTransferRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Firmata_SPITransfer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SPITransferRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FirmataServer).SPITransfer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Firmata_SPITransfer_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FirmataServer).SPITransfer(ctx, req.(*SPITransferRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Firmata_SerialConfig_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SerialConfigRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FirmataServer).SerialConfig(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Firmata_SerialConfig_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FirmataServer).SerialConfig(ctx, req.(*SerialConfigRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Firmata_SerialWrite_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SerialWriteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FirmataServer).SerialWrite(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Firmata_SerialWrite_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FirmataServer).SerialWrite(ctx, req.(*SerialWriteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Firmata_SerialRead_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SerialReadRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(FirmataServer).SerialRead(m, &grpc.GenericServerStream[SerialReadRequest, SerialData]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Firmata_SerialReadServer = grpc.ServerStreamingServer[SerialData]
+
+// Firmata_ServiceDesc is the grpc.ServiceDesc for Firmata service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Firmata_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "firmata.v1.Firmata",
+	HandlerType: (*FirmataServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetInfo",
+			Handler:    _Firmata_GetInfo_Handler,
+		},
+		{
+			MethodName: "ListPins",
+			Handler:    _Firmata_ListPins_Handler,
+		},
+		{
+			MethodName: "GetPin",
+			Handler:    _Firmata_GetPin_Handler,
+		},
+		{
+			MethodName: "SetPinMode",
+			Handler:    _Firmata_SetPinMode_Handler,
+		},
+		{
+			MethodName: "ReadPin",
+			Handler:    _Firmata_ReadPin_Handler,
+		},
+		{
+			MethodName: "WritePin",
+			Handler:    _Firmata_WritePin_Handler,
+		},
+		{
+			MethodName: "I2CTransfer",
+			Handler:    _Firmata_I2CTransfer_Handler,
+		},
+		{
+			MethodName: "SPITransfer",
+			Handler:    _Firmata_SPITransfer_Handler,
+		},
+		{
+			MethodName: "SerialConfig",
+			Handler:    _Firmata_SerialConfig_Handler,
+		},
+		{
+			MethodName: "SerialWrite",
+			Handler:    _Firmata_SerialWrite_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Subscribe",
+			Handler:       _Firmata_Subscribe_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "SerialRead",
+			Handler:       _Firmata_SerialRead_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "firmata.proto",
+}
@@ -0,0 +1,367 @@
+// Copyright 2014 Krishna Raman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// gRPC service for a board. The API is defined in firmatapb/firmata.proto;
+// clients in other languages are generated from that file.
+//
+// Usage:
+//
+//	s := grpc.NewServer()
+//	firmatapb.RegisterFirmataServer(s, grpcapi.NewServer(bridge.New(arduino)))
+//	s.Serve(listener)
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/kraman/go-firmata"
+	"github.com/kraman/go-firmata/bridge"
+	"github.com/kraman/go-firmata/grpcapi/firmatapb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Time to wait for the board to reply to an I2C read or SPI transfer when
+// the call has no deadline
+const DefaultTimeout = time.Second * 2
+
+// Implementation of the Firmata service for a bridged board
+type Server struct {
+	firmatapb.UnimplementedFirmataServer
+
+	board *bridge.Board
+
+	// serial data is read from the client once and copied to every stream
+	serialOnce    sync.Once
+	serialLock    sync.Mutex
+	serialStreams map[chan *firmatapb.SerialData]struct{}
+	serialClosed  bool
+
+	// SPI devices by chip-select pin, reconfigured when the mode changes
+	spiLock    sync.Mutex
+	spiDevices map[byte]*firmata.SPIDevice
+}
+
+// Firmata mode bytes of SPI modes 0 to 3
+var spiModes = []byte{firmata.SPI_MODE0, firmata.SPI_MODE1, firmata.SPI_MODE2, firmata.SPI_MODE3}
+
+// Create a server controlling the board of b
+func NewServer(b *bridge.Board) *Server {
+	return &Server{
+		board:         b,
+		serialStreams: make(map[chan *firmatapb.SerialData]struct{}),
+		spiDevices:    make(map[byte]*firmata.SPIDevice),
+	}
+}
+
+func (s *Server) GetInfo(ctx context.Context, req *firmatapb.GetInfoRequest) (*firmatapb.BoardInfo, error) {
+	info := s.board.Client().BoardInfo()
+	return &firmatapb.BoardInfo{
+		FirmwareName:   info.FirmwareName,
+		FirmwareMajor:  uint32(info.FirmwareMajor),
+		FirmwareMinor:  uint32(info.FirmwareMinor),
+		ProtocolMajor:  uint32(info.ProtocolMajor),
+		ProtocolMinor:  uint32(info.ProtocolMinor),
+		Pins:           uint32(info.Pins),
+		AnalogChannels: uint32(info.AnalogChannels),
+	}, nil
+}
+
+func (s *Server) ListPins(ctx context.Context, req *firmatapb.ListPinsRequest) (*firmatapb.ListPinsResponse, error) {
+	resp := &firmatapb.ListPinsResponse{}
+	for _, state := range s.board.Pins() {
+		resp.Pins = append(resp.Pins, pinMessage(state))
+	}
+	return resp, nil
+}
+
+func (s *Server) GetPin(ctx context.Context, req *firmatapb.GetPinRequest) (*firmatapb.Pin, error) {
+	state, err := s.board.Pin(int(req.Pin))
+	if err != nil {
+		return nil, statusOf(err)
+	}
+	return pinMessage(state), nil
+}
+
+func (s *Server) SetPinMode(ctx context.Context, req *firmatapb.SetPinModeRequest) (*firmatapb.Pin, error) {
+	if req.Mode < 0 || req.Mode > firmatapb.PinMode(firmata.Ignore) {
+		return nil, status.Errorf(codes.InvalidArgument, "Unknown pin mode %v", req.Mode)
+	}
+	if err := s.board.SetMode(int(req.Pin), firmata.PinMode(req.Mode)); err != nil {
+		return nil, statusOf(err)
+	}
+	return s.GetPin(ctx, &firmatapb.GetPinRequest{Pin: req.Pin})
+}
+
+func (s *Server) ReadPin(ctx context.Context, req *firmatapb.ReadPinRequest) (*firmatapb.PinValue, error) {
+	state, err := s.board.Pin(int(req.Pin))
+	if err != nil {
+		return nil, statusOf(err)
+	}
+	return &firmatapb.PinValue{Pin: req.Pin, Value: int32(state.Value), Reading: state.Reading}, nil
+}
+
+func (s *Server) WritePin(ctx context.Context, req *firmatapb.WritePinRequest) (*firmatapb.PinValue, error) {
+	if err := s.board.Write(int(req.Pin), int(req.Value)); err != nil {
+		return nil, statusOf(err)
+	}
+	return &firmatapb.PinValue{Pin: req.Pin, Value: req.Value}, nil
+}
+
+func (s *Server) Subscribe(req *firmatapb.SubscribeRequest, stream firmatapb.Firmata_SubscribeServer) error {
+	pins := make([]int, 0, len(req.Pins))
+	var states []bridge.PinState
+	for _, pin := range req.Pins {
+		state, err := s.board.Pin(int(pin))
+		if err != nil {
+			return statusOf(err)
+		}
+		pins = append(pins, state.Pin)
+		states = append(states, state)
+	}
+	if len(pins) == 0 {
+		states = s.board.Pins()
+	}
+
+	// subscribe before sending current values so no change is missed
+	events, cancel := s.board.Subscribe(pins...)
+	defer cancel()
+	now := time.Now()
+	for _, state := range states {
+		err := stream.Send(eventMessage(bridge.PinEvent{Pin: state.Pin, Mode: state.Mode, Value: state.Value, Reading: state.Reading, Time: now}))
+		if err != nil {
+			return err
+		}
+	}
+
+	for {
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				return status.Error(codes.Unavailable, "Board connection closed")
+			}
+			if err := stream.Send(eventMessage(ev)); err != nil {
+				return err
+			}
+		case <-stream.Context().Done():
+			return nil
+		}
+	}
+}
+
+func (s *Server) I2CTransfer(ctx context.Context, req *firmatapb.I2CTransferRequest) (*firmatapb.I2CTransferResponse, error) {
+	register := firmata.I2CRegisterNotSpecified
+	if req.Register != nil {
+		register = int(*req.Register)
+	}
+	switch {
+	case req.Address > 0x3FF:
+		return nil, status.Errorf(codes.InvalidArgument, "I2C address %#x out of range", req.Address)
+	case len(req.Write) == 0 && req.Read == 0:
+		return nil, status.Error(codes.InvalidArgument, "Nothing to write or read")
+	case req.Read > 0x3FFF:
+		return nil, status.Errorf(codes.InvalidArgument, "Read count %v out of range 0 to 16383", req.Read)
+	case register > 0x3FFF:
+		return nil, status.Errorf(codes.InvalidArgument, "Register %v out of range 0 to 16383", register)
+	}
+
+	c := s.board.Client()
+	if err := c.I2CConfig(0); err != nil {
+		return nil, statusOf(err)
+	}
+	if len(req.Write) > 0 {
+		if err := c.I2CWrite(int(req.Address), req.Write); err != nil {
+			return nil, statusOf(err)
+		}
+	}
+	resp := &firmatapb.I2CTransferResponse{}
+	if req.Read > 0 {
+		ctx, cancel := withDefaultTimeout(ctx)
+		defer cancel()
+		data, err := c.I2CRead(ctx, int(req.Address), register, int(req.Read))
+		if err != nil {
+			return nil, statusOf(err)
+		}
+		resp.Data = data
+	}
+	return resp, nil
+}
+
+func (s *Server) SPITransfer(ctx context.Context, req *firmatapb.SPITransferRequest) (*firmatapb.SPITransferResponse, error) {
+	if req.CsPin > 0x7F || req.Mode > 3 {
+		return nil, status.Errorf(codes.InvalidArgument, "Invalid chip-select pin %v or SPI mode %v", req.CsPin, req.Mode)
+	}
+	dev, err := s.spiDevice(byte(req.CsPin), spiModes[req.Mode])
+	if err != nil {
+		return nil, statusOf(err)
+	}
+	ctx, cancel := withDefaultTimeout(ctx)
+	defer cancel()
+	resp := &firmatapb.SPITransferResponse{Data: make([]byte, len(req.Data))}
+	if err = dev.Tx(ctx, req.Data, resp.Data); err != nil {
+		return nil, statusOf(err)
+	}
+	return resp, nil
+}
+
+// Get the device on csPin, configuring it on first use or when mode changes
+func (s *Server) spiDevice(csPin byte, mode byte) (dev *firmata.SPIDevice, err error) {
+	s.spiLock.Lock()
+	defer s.spiLock.Unlock()
+
+	if dev = s.spiDevices[csPin]; dev != nil && dev.Mode() == mode {
+		return
+	}
+	if dev, err = s.board.Client().NewSPIDevice(csPin, mode); err != nil {
+		return
+	}
+	s.spiDevices[csPin] = dev
+	return
+}
+
+func (s *Server) SerialConfig(ctx context.Context, req *firmatapb.SerialConfigRequest) (*firmatapb.SerialConfigResponse, error) {
+	if req.Port < 0 || req.Port > firmatapb.SerialPort_SERIAL_PORT_HW3 || req.TxPin > 0x7F || req.RxPin > 0x7F {
+		return nil, status.Errorf(codes.InvalidArgument, "Invalid serial port %v or pins %v, %v", req.Port, req.TxPin, req.RxPin)
+	}
+	err := s.board.Client().SerialConfig(firmata.SerialPort(req.Port), int(req.Baud), byte(req.TxPin), byte(req.RxPin))
+	if err != nil {
+		return nil, statusOf(err)
+	}
+	return &firmatapb.SerialConfigResponse{}, nil
+}
+
+func (s *Server) SerialWrite(ctx context.Context, req *firmatapb.SerialWriteRequest) (*firmatapb.SerialWriteResponse, error) {
+	if req.Port < 0 || req.Port > firmatapb.SerialPort_SERIAL_PORT_HW3 {
+		return nil, status.Errorf(codes.InvalidArgument, "Invalid serial port %v", req.Port)
+	}
+	if err := s.board.Client().SerialWrite(firmata.SerialPort(req.Port), req.Data); err != nil {
+		return nil, statusOf(err)
+	}
+	return &firmatapb.SerialWriteResponse{}, nil
+}
+
+func (s *Server) SerialRead(req *firmatapb.SerialReadRequest, stream firmatapb.Firmata_SerialReadServer) error {
+	s.serialOnce.Do(func() { go s.readSerial() })
+
+	data := make(chan *firmatapb.SerialData, 64)
+	s.serialLock.Lock()
+	if s.serialClosed {
+		s.serialLock.Unlock()
+		return status.Error(codes.Unavailable, "Board connection closed")
+	}
+	s.serialStreams[data] = struct{}{}
+	s.serialLock.Unlock()
+	defer func() {
+		s.serialLock.Lock()
+		delete(s.serialStreams, data)
+		s.serialLock.Unlock()
+	}()
+
+	for {
+		select {
+		case d, ok := <-data:
+			if !ok {
+				return status.Error(codes.Unavailable, "Board connection closed")
+			}
+			if err := stream.Send(d); err != nil {
+				return err
+			}
+		case <-stream.Context().Done():
+			return nil
+		}
+	}
+}
+
+// Copy serial data from the client to every open SerialRead stream.
+// Data is dropped for streams that fall behind.
+func (s *Server) readSerial() {
+	for ev := range s.board.Client().GetSerialEvents() {
+		msg := &firmatapb.SerialData{Data: ev.Data, Port: firmatapb.SerialPort(ev.Port)}
+		s.serialLock.Lock()
+		for data := range s.serialStreams {
+			select {
+			case data <- msg:
+			default:
+				s.board.Log.Warn("Serial stream buffer overflow. Slow client?", "port", ev.Port)
+			}
+		}
+		s.serialLock.Unlock()
+	}
+
+	s.serialLock.Lock()
+	defer s.serialLock.Unlock()
+	s.serialClosed = true
+	for data := range s.serialStreams {
+		delete(s.serialStreams, data)
+		close(data)
+	}
+}
+
+func pinMessage(state bridge.PinState) *firmatapb.Pin {
+	pin := &firmatapb.Pin{
+		Pin:           uint32(state.Pin),
+		Mode:          firmatapb.PinMode(state.Mode),
+		Value:         int32(state.Value),
+		AnalogChannel: int32(state.AnalogChannel),
+		Reading:       state.Reading,
+	}
+	for mode := firmata.Input; mode <= firmata.Ignore; mode++ {
+		if res, ok := state.Modes[mode]; ok {
+			pin.Capabilities = append(pin.Capabilities, &firmatapb.Capability{
+				Mode:       firmatapb.PinMode(mode),
+				Resolution: uint32(res),
+			})
+		}
+	}
+	return pin
+}
+
+func eventMessage(ev bridge.PinEvent) *firmatapb.PinEvent {
+	return &firmatapb.PinEvent{
+		Pin:     uint32(ev.Pin),
+		Mode:    firmatapb.PinMode(ev.Mode),
+		Value:   int32(ev.Value),
+		Reading: ev.Reading,
+		Edge:    firmatapb.Edge(ev.Edge),
+		Time:    timestamppb.New(ev.Time),
+	}
+}
+
+func withDefaultTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, DefaultTimeout)
+}
+
+// Map an error from the board to a gRPC status
+func statusOf(err error) error {
+	switch {
+	case errors.Is(err, bridge.ErrNoSuchPin):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, bridge.ErrInvalid):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, firmata.ErrUnsupported):
+		return status.Error(codes.Unimplemented, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	}
+	return status.Error(codes.Unknown, err.Error())
+}
@@ -0,0 +1,246 @@
+// Copyright 2014 Krishna Raman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpcapi_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kraman/go-firmata"
+	"github.com/kraman/go-firmata/bridge"
+	"github.com/kraman/go-firmata/grpcapi"
+	"github.com/kraman/go-firmata/grpcapi/firmatapb"
+	"github.com/kraman/go-firmata/virtual"
+	"google.golang.org/grpc"
+)
+
+func newTestServer(t *testing.T) (*grpcapi.Server, *bridge.Board, *virtual.Board) {
+	t.Helper()
+	vb := virtual.New(virtual.ArduinoUno())
+	c, err := firmata.NewClientWithTransport(vb.Transport())
+	if err != nil {
+		vb.Close()
+		t.Fatal(err)
+	}
+	c.SetAnalogSamplingInterval(5)
+	t.Cleanup(func() {
+		c.Close()
+		vb.Close()
+	})
+	b := bridge.New(c)
+	return grpcapi.NewServer(b), b, vb
+}
+
+// Server side of a SerialRead call, delivering sent messages on a channel
+type serialStream struct {
+	grpc.ServerStream
+	ctx  context.Context
+	sent chan *firmatapb.SerialData
+}
+
+func newSerialStream(t *testing.T) *serialStream {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	return &serialStream{ctx: ctx, sent: make(chan *firmatapb.SerialData, 16)}
+}
+
+func (s *serialStream) Context() context.Context { return s.ctx }
+
+func (s *serialStream) Send(m *firmatapb.SerialData) error {
+	s.sent <- m
+	return nil
+}
+
+func TestSerialReadFansOut(t *testing.T) {
+	s, _, vb := newTestServer(t)
+	streams := []*serialStream{newSerialStream(t), newSerialStream(t)}
+	done := make(chan error, len(streams))
+	for _, stream := range streams {
+		go func(stream *serialStream) {
+			done <- s.SerialRead(&firmatapb.SerialReadRequest{}, stream)
+		}(stream)
+	}
+
+	// the streams register asynchronously, so repeat the data until both
+	// have seen it
+	for i, stream := range streams {
+		var msg *firmatapb.SerialData
+		deadline := time.Now().Add(time.Second)
+		for msg == nil {
+			if time.Now().After(deadline) {
+				t.Fatalf("stream %v received nothing", i)
+			}
+			vb.SerialInput(firmata.HardSerial1, []byte("data"))
+			select {
+			case msg = <-stream.sent:
+			case <-time.After(10 * time.Millisecond):
+			}
+		}
+		if msg.Port != firmatapb.SerialPort_SERIAL_PORT_HW1 || string(msg.Data) != "data" {
+			t.Errorf("stream %v received %v", i, msg)
+		}
+	}
+
+	vb.Close()
+	for range streams {
+		select {
+		case err := <-done:
+			if err == nil {
+				t.Error("stream ended without an error when the board closed")
+			}
+		case <-time.After(time.Second):
+			t.Fatal("stream did not end when the board closed")
+		}
+	}
+}
+
+// Server side of a Subscribe call, delivering sent events on a channel
+type eventStream struct {
+	grpc.ServerStream
+	ctx  context.Context
+	sent chan *firmatapb.PinEvent
+}
+
+func (s *eventStream) Context() context.Context { return s.ctx }
+
+func (s *eventStream) Send(m *firmatapb.PinEvent) error {
+	s.sent <- m
+	return nil
+}
+
+func TestReadPinReading(t *testing.T) {
+	s, b, vb := newTestServer(t)
+	ctx := context.Background()
+
+	if err := b.SetFilters(14, bridge.Linear(0, 1000, 0, 10)); err != nil {
+		t.Fatal(err)
+	}
+	vb.SetAnalogInput(14, 500)
+	var resp *firmatapb.PinValue
+	deadline := time.Now().Add(time.Second)
+	for resp.GetReading() != 5 {
+		if time.Now().After(deadline) {
+			t.Fatalf("ReadPin returned %v", resp)
+		}
+		time.Sleep(time.Millisecond)
+		var err error
+		if resp, err = s.ReadPin(ctx, &firmatapb.ReadPinRequest{Pin: 14}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if resp.Value != 500 {
+		t.Errorf("raw value %v", resp.Value)
+	}
+	pin, err := s.GetPin(ctx, &firmatapb.GetPinRequest{Pin: 14})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pin.GetReading() != 5 {
+		t.Errorf("pin %v", pin)
+	}
+
+	// pins without filters have no reading
+	if resp, err = s.ReadPin(ctx, &firmatapb.ReadPinRequest{Pin: 13}); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Reading != nil {
+		t.Errorf("reading %v for an output", resp.GetReading())
+	}
+}
+
+func TestSubscribeEdges(t *testing.T) {
+	s, b, vb := newTestServer(t)
+	// the first report after the mode change has no edge
+	reports, stop := b.Subscribe(2)
+	defer stop()
+	if err := b.SetMode(2, firmata.Input); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-reports:
+	case <-time.After(time.Second):
+		t.Fatal("no report after the mode change")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream := &eventStream{ctx: ctx, sent: make(chan *firmatapb.PinEvent, 16)}
+	go s.Subscribe(&firmatapb.SubscribeRequest{Pins: []uint32{2}}, stream)
+
+	next := func() *firmatapb.PinEvent {
+		t.Helper()
+		select {
+		case ev := <-stream.sent:
+			return ev
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for an event")
+			return nil
+		}
+	}
+	if ev := next(); ev.Value != 0 || ev.Edge != firmatapb.Edge_EDGE_NONE {
+		t.Errorf("current value %v", ev)
+	}
+	vb.SetDigitalInput(2, true)
+	if ev := next(); ev.Value != 1 || ev.Edge != firmatapb.Edge_EDGE_RISING {
+		t.Errorf("got %v, want rising edge", ev)
+	}
+	vb.SetDigitalInput(2, false)
+	if ev := next(); ev.Value != 0 || ev.Edge != firmatapb.Edge_EDGE_FALLING {
+		t.Errorf("got %v, want falling edge", ev)
+	}
+}
+
+func TestSPITransferMode(t *testing.T) {
+	s, b, vb := newTestServer(t)
+	ctx := context.Background()
+
+	req := &firmatapb.SPITransferRequest{CsPin: 10, Mode: 2, Data: []byte{1, 2}}
+	resp, err := s.SPITransfer(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(resp.Data) != "\x01\x02" {
+		t.Errorf("read %v", resp.Data)
+	}
+	if mode, ok := vb.SPIMode(10); !ok || mode != firmata.SPI_MODE2 {
+		t.Errorf("mode 2 configured as 0x%02x", mode)
+	}
+
+	// the device is configured once, so a mode set behind the server's back
+	// stays in place
+	if err = b.Client().SPIConfig(10, firmata.SPI_MODE0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = s.SPITransfer(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+	if mode, _ := vb.SPIMode(10); mode != firmata.SPI_MODE0 {
+		t.Errorf("device reconfigured to 0x%02x for the same mode", mode)
+	}
+
+	req.Mode = 3
+	if _, err = s.SPITransfer(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+	if mode, _ := vb.SPIMode(10); mode != firmata.SPI_MODE3 {
+		t.Errorf("mode 3 configured as 0x%02x", mode)
+	}
+
+	req.Mode = 4
+	if _, err = s.SPITransfer(ctx, req); err == nil {
+		t.Error("transfer in mode 4 succeeded")
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright 2014 Krishna Raman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Transport recording what the client sends and never answering
+type recordingConn struct {
+	lock sync.Mutex
+	sent bytes.Buffer
+}
+
+func (c *recordingConn) Read(p []byte) (int, error) {
+	select {}
+}
+
+func (c *recordingConn) Write(p []byte) (int, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.sent.Write(p)
+}
+
+func (c *recordingConn) Close() error {
+	return nil
+}
+
+// Wait until the client has sent at least n bytes and return them
+func (c *recordingConn) waitSent(t *testing.T, n int) []byte {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		if sent := c.Sent(); len(sent) >= n {
+			return sent
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("client sent %x, want %v bytes", c.Sent(), n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func (c *recordingConn) Sent() []byte {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return append([]byte(nil), c.sent.Bytes()...)
+}
+
+// Client speaking protocol 2.6 that skipped the handshake, for testing
+// requests and reply parsing without a board
+func newOfflineClient() (*FirmataClient, *recordingConn) {
+	rc := &recordingConn{}
+	var conn io.ReadWriteCloser = rc
+	return &FirmataClient{conn: &conn, Log: discardLogger(), metrics: noopMetrics{},
+		protocolVersion: []byte{2, 6}, spiBus: make(chan struct{}, 1)}, rc
+}
@@ -0,0 +1,329 @@
+// Copyright 2014 Krishna Raman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// REST API for a board. Pins, their modes and values and the I2C bus are
+// exposed as JSON resources; the API is described by the OpenAPI document
+// served at /openapi.json. Pin changes are streamed over a WebSocket at /ws.
+//
+// Usage:
+//
+//	b := bridge.New(arduino)
+//	http.ListenAndServe(":8080", httpapi.NewHandler(b))
+package httpapi
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/kraman/go-firmata"
+	"github.com/kraman/go-firmata/bridge"
+)
+
+// Time to wait for the reply to an I2C read
+const DefaultI2CTimeout = time.Second * 2
+
+//go:embed openapi.json
+var openAPI []byte
+
+type handler struct {
+	board      *bridge.Board
+	i2cTimeout time.Duration
+	upgrader   websocket.Upgrader
+
+	i2cLock  sync.Mutex
+	i2cReady bool
+}
+
+type Option func(*handler)
+
+// Set the time to wait for the reply to an I2C read
+func WithI2CTimeout(d time.Duration) Option {
+	return func(h *handler) {
+		h.i2cTimeout = d
+	}
+}
+
+// Create a handler serving the REST API for b
+func NewHandler(b *bridge.Board, opts ...Option) http.Handler {
+	h := &handler{board: b, i2cTimeout: DefaultI2CTimeout}
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /openapi.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(openAPI)
+	})
+	mux.HandleFunc("GET /info", h.getInfo)
+	mux.HandleFunc("GET /pins", h.getPins)
+	mux.HandleFunc("GET /pins/{pin}", h.getPin)
+	mux.HandleFunc("GET /pins/{pin}/mode", h.getMode)
+	mux.HandleFunc("PUT /pins/{pin}/mode", h.putMode)
+	mux.HandleFunc("GET /pins/{pin}/value", h.getValue)
+	mux.HandleFunc("PUT /pins/{pin}/value", h.putValue)
+	mux.HandleFunc("POST /i2c/{addr}", h.postI2C)
+	mux.HandleFunc("GET /ws", h.serveWS)
+	return mux
+}
+
+type modeBody struct {
+	Mode firmata.PinMode `json:"mode"`
+}
+
+type valueBody struct {
+	Value int `json:"value"`
+}
+
+type readingBody struct {
+	Value int `json:"value"`
+	// Filtered reading of analog inputs with filters
+	Reading *float64 `json:"reading,omitempty"`
+}
+
+type i2cRequest struct {
+	// Bytes to write before reading. ints so they are not expected as base64.
+	Write []int `json:"write"`
+	// Register to read from, or the current one if nil
+	Register *int `json:"register"`
+	// Number of bytes to read
+	Read int `json:"read"`
+}
+
+type i2cResponse struct {
+	// ints so the bytes are not encoded as base64
+	Data []int `json:"data"`
+}
+
+type infoBody struct {
+	FirmwareName    string `json:"firmwareName"`
+	FirmwareVersion string `json:"firmwareVersion"`
+	ProtocolVersion string `json:"protocolVersion"`
+	Pins            int    `json:"pins"`
+	AnalogChannels  int    `json:"analogChannels"`
+}
+
+type errorBody struct {
+	Error string `json:"error"`
+}
+
+func (h *handler) getInfo(w http.ResponseWriter, r *http.Request) {
+	info := h.board.Client().BoardInfo()
+	writeJSON(w, http.StatusOK, infoBody{
+		FirmwareName:    info.FirmwareName,
+		FirmwareVersion: fmt.Sprintf("%v.%v", info.FirmwareMajor, info.FirmwareMinor),
+		ProtocolVersion: fmt.Sprintf("%v.%v", info.ProtocolMajor, info.ProtocolMinor),
+		Pins:            info.Pins,
+		AnalogChannels:  info.AnalogChannels,
+	})
+}
+
+func (h *handler) getPins(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, h.board.Pins())
+}
+
+func (h *handler) getPin(w http.ResponseWriter, r *http.Request) {
+	state, ok := h.pin(w, r)
+	if ok {
+		writeJSON(w, http.StatusOK, state)
+	}
+}
+
+func (h *handler) getMode(w http.ResponseWriter, r *http.Request) {
+	state, ok := h.pin(w, r)
+	if ok {
+		writeJSON(w, http.StatusOK, modeBody{state.Mode})
+	}
+}
+
+func (h *handler) putMode(w http.ResponseWriter, r *http.Request) {
+	state, ok := h.pin(w, r)
+	if !ok {
+		return
+	}
+	var body modeBody
+	if !readJSON(w, r, &body) {
+		return
+	}
+	if err := h.board.SetMode(state.Pin, body.Mode); err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, body)
+}
+
+func (h *handler) getValue(w http.ResponseWriter, r *http.Request) {
+	state, ok := h.pin(w, r)
+	if ok {
+		writeJSON(w, http.StatusOK, readingBody{state.Value, state.Reading})
+	}
+}
+
+func (h *handler) putValue(w http.ResponseWriter, r *http.Request) {
+	state, ok := h.pin(w, r)
+	if !ok {
+		return
+	}
+	var body valueBody
+	if !readJSON(w, r, &body) {
+		return
+	}
+	if err := h.board.Write(state.Pin, body.Value); err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, body)
+}
+
+// Write to and/or read from an I2C device
+func (h *handler) postI2C(w http.ResponseWriter, r *http.Request) {
+	addr, err := strconv.ParseUint(r.PathValue("addr"), 0, 10)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorBody{fmt.Sprintf("Invalid I2C address %q", r.PathValue("addr"))})
+		return
+	}
+	var req i2cRequest
+	if !readJSON(w, r, &req) {
+		return
+	}
+	data := make([]byte, len(req.Write))
+	for i, b := range req.Write {
+		if b < 0 || b > 0xFF {
+			writeJSON(w, http.StatusBadRequest, errorBody{fmt.Sprintf("Byte %v out of range 0 to 255", b)})
+			return
+		}
+		data[i] = byte(b)
+	}
+	register := firmata.I2CRegisterNotSpecified
+	if req.Register != nil {
+		register = *req.Register
+	}
+	switch {
+	case len(data) == 0 && req.Read == 0:
+		writeJSON(w, http.StatusBadRequest, errorBody{"Nothing to write or read"})
+		return
+	case req.Read < 0 || req.Read > 0x3FFF:
+		writeJSON(w, http.StatusBadRequest, errorBody{fmt.Sprintf("Read count %v out of range 0 to 16383", req.Read)})
+		return
+	case register != firmata.I2CRegisterNotSpecified && (register < 0 || register > 0x3FFF):
+		writeJSON(w, http.StatusBadRequest, errorBody{fmt.Sprintf("Register %v out of range 0 to 16383", register)})
+		return
+	}
+
+	c := h.board.Client()
+	if err = h.configureI2C(); err != nil {
+		writeError(w, err)
+		return
+	}
+	if len(data) > 0 {
+		if err = c.I2CWrite(int(addr), data); err != nil {
+			writeError(w, err)
+			return
+		}
+	}
+	resp := i2cResponse{Data: []int{}}
+	if req.Read > 0 {
+		ctx, cancel := context.WithTimeout(r.Context(), h.i2cTimeout)
+		defer cancel()
+		reply, err := c.I2CRead(ctx, int(addr), register, req.Read)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		for _, b := range reply {
+			resp.Data = append(resp.Data, int(b))
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// Configure the I2C bus the first time it is used
+func (h *handler) configureI2C() error {
+	h.i2cLock.Lock()
+	defer h.i2cLock.Unlock()
+
+	if h.i2cReady {
+		return nil
+	}
+	supported := false
+	for _, p := range h.board.Pins() {
+		if _, ok := p.Modes[firmata.I2C]; ok {
+			supported = true
+			break
+		}
+	}
+	if !supported {
+		return fmt.Errorf("No pin supports I2C: %w", firmata.ErrUnsupported)
+	}
+	if err := h.board.Client().I2CConfig(0); err != nil {
+		return err
+	}
+	h.i2cReady = true
+	return nil
+}
+
+// Look up the pin named in the request path
+func (h *handler) pin(w http.ResponseWriter, r *http.Request) (state bridge.PinState, ok bool) {
+	pin, err := strconv.Atoi(r.PathValue("pin"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, errorBody{fmt.Sprintf("Invalid pin number %q", r.PathValue("pin"))})
+		return
+	}
+	if state, err = h.board.Pin(pin); err != nil {
+		writeError(w, err)
+		return
+	}
+	ok = true
+	return
+}
+
+func readJSON(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorBody{fmt.Sprintf("Invalid request body: %v", err)})
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// Map an error from the board to a status code
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusBadGateway
+	switch {
+	case errors.Is(err, bridge.ErrNoSuchPin):
+		status = http.StatusNotFound
+	case errors.Is(err, bridge.ErrInvalid):
+		status = http.StatusBadRequest
+	case errors.Is(err, firmata.ErrUnsupported):
+		status = http.StatusNotImplemented
+	case errors.Is(err, context.DeadlineExceeded):
+		status = http.StatusGatewayTimeout
+	}
+	writeJSON(w, status, errorBody{err.Error()})
+}
@@ -0,0 +1,322 @@
+// Copyright 2014 Krishna Raman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpapi_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kraman/go-firmata"
+	"github.com/kraman/go-firmata/bridge"
+	"github.com/kraman/go-firmata/httpapi"
+	"github.com/kraman/go-firmata/virtual"
+)
+
+func newTestServer(t *testing.T) (*httptest.Server, *bridge.Board, *virtual.Board) {
+	t.Helper()
+	return newProfileServer(t, virtual.ArduinoUno(), nil)
+}
+
+// Serve a board simulating profile. The client talks to the board through
+// wrap(transport) if wrap is not nil.
+func newProfileServer(t *testing.T, profile virtual.Profile, wrap func(io.ReadWriteCloser) io.ReadWriteCloser) (*httptest.Server, *bridge.Board, *virtual.Board) {
+	t.Helper()
+	vb := virtual.New(profile)
+	conn := vb.Transport()
+	if wrap != nil {
+		conn = wrap(conn)
+	}
+	c, err := firmata.NewClientWithTransport(conn)
+	if err != nil {
+		vb.Close()
+		t.Fatal(err)
+	}
+	c.SetAnalogSamplingInterval(5)
+	b := bridge.New(c)
+	srv := httptest.NewServer(httpapi.NewHandler(b, httpapi.WithI2CTimeout(time.Second)))
+	t.Cleanup(func() {
+		srv.Close()
+		c.Close()
+		vb.Close()
+	})
+	return srv, b, vb
+}
+
+// Send a request with an optional JSON body and decode the JSON response
+// into out if it is not nil
+func do(t *testing.T, method string, url string, body string, out interface{}) int {
+	t.Helper()
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "application/json" {
+		t.Errorf("%v %v returned content type %q", method, url, ct)
+	}
+	if out != nil {
+		if err = json.NewDecoder(resp.Body).Decode(out); err != nil {
+			t.Fatalf("%v %v: %v", method, url, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+// Simulated I2C device returning its register numbers as data
+type echoDevice struct {
+	lock    sync.Mutex
+	written []byte
+}
+
+func (d *echoDevice) Write(data []byte) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.written = append(d.written, data...)
+}
+
+func (d *echoDevice) Read(register int, count int) []byte {
+	out := make([]byte, count)
+	for i := range out {
+		out[i] = byte(register + i)
+	}
+	return out
+}
+
+func TestGetInfo(t *testing.T) {
+	srv, _, _ := newTestServer(t)
+
+	var info map[string]interface{}
+	if code := do(t, "GET", srv.URL+"/info", "", &info); code != http.StatusOK {
+		t.Fatalf("status %v", code)
+	}
+	if info["firmwareName"] != "StandardFirmata.ino" || info["protocolVersion"] != "2.5" || info["pins"] != 20.0 {
+		t.Errorf("info %v", info)
+	}
+
+	var spec map[string]interface{}
+	if code := do(t, "GET", srv.URL+"/openapi.json", "", &spec); code != http.StatusOK || spec["openapi"] == nil {
+		t.Errorf("openapi.json status %v: %v", code, spec)
+	}
+}
+
+func TestPins(t *testing.T) {
+	srv, _, _ := newTestServer(t)
+
+	var pins []bridge.PinState
+	if code := do(t, "GET", srv.URL+"/pins", "", &pins); code != http.StatusOK || len(pins) != 20 {
+		t.Fatalf("status %v, %v pins", code, len(pins))
+	}
+
+	var pin bridge.PinState
+	if code := do(t, "GET", srv.URL+"/pins/14", "", &pin); code != http.StatusOK {
+		t.Fatalf("status %v", code)
+	}
+	if pin.Pin != 14 || pin.Mode != firmata.Analog || pin.AnalogChannel != 0 {
+		t.Errorf("pin 14 %+v", pin)
+	}
+
+	if code := do(t, "GET", srv.URL+"/pins/20", "", nil); code != http.StatusNotFound {
+		t.Errorf("pin 20 status %v", code)
+	}
+	if code := do(t, "GET", srv.URL+"/pins/x", "", nil); code != http.StatusBadRequest {
+		t.Errorf("pin x status %v", code)
+	}
+}
+
+func TestModeAndValue(t *testing.T) {
+	srv, _, vb := newTestServer(t)
+
+	var mode map[string]interface{}
+	if code := do(t, "PUT", srv.URL+"/pins/9/mode", `{"mode":"PWM"}`, &mode); code != http.StatusOK {
+		t.Fatalf("status %v: %v", code, mode)
+	}
+	if code := do(t, "GET", srv.URL+"/pins/9/mode", "", &mode); code != http.StatusOK || mode["mode"] != "PWM" {
+		t.Errorf("status %v, mode %v", code, mode)
+	}
+
+	if code := do(t, "PUT", srv.URL+"/pins/9/value", `{"value":77}`, nil); code != http.StatusOK {
+		t.Fatalf("status %v", code)
+	}
+	deadline := time.Now().Add(time.Second)
+	for vb.AnalogOutput(9) != 77 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if v := vb.AnalogOutput(9); v != 77 {
+		t.Errorf("pin 9 duty %v", v)
+	}
+	var value map[string]interface{}
+	if code := do(t, "GET", srv.URL+"/pins/9/value", "", &value); code != http.StatusOK || value["value"] != 77.0 {
+		t.Errorf("status %v, value %v", code, value)
+	}
+
+	tests := []struct {
+		method, path, body string
+		code               int
+	}{
+		{"PUT", "/pins/9/value", `{"value":300}`, http.StatusBadRequest},
+		{"PUT", "/pins/9/value", `{"value":"high"}`, http.StatusBadRequest},
+		{"PUT", "/pins/9/value", `{"value":1,"extra":true}`, http.StatusBadRequest},
+		{"PUT", "/pins/4/mode", `{"mode":"PWM"}`, http.StatusBadRequest},
+		{"PUT", "/pins/4/mode", `{"mode":"FLYING"}`, http.StatusBadRequest},
+		{"PUT", "/pins/14/value", `{"value":1}`, http.StatusBadRequest},
+		{"PUT", "/pins/30/value", `{"value":1}`, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		var body map[string]interface{}
+		if code := do(t, tt.method, srv.URL+tt.path, tt.body, &body); code != tt.code {
+			t.Errorf("%v %v %v: status %v, want %v", tt.method, tt.path, tt.body, code, tt.code)
+		} else if body["error"] == nil {
+			t.Errorf("%v %v %v: no error message", tt.method, tt.path, tt.body)
+		}
+	}
+}
+
+func TestI2C(t *testing.T) {
+	srv, _, vb := newTestServer(t)
+	dev := &echoDevice{}
+	vb.AttachI2CDevice(0x20, dev)
+
+	var resp struct {
+		Data []int `json:"data"`
+	}
+	code := do(t, "POST", srv.URL+"/i2c/0x20", `{"write":[1,2,255],"register":16,"read":3}`, &resp)
+	if code != http.StatusOK {
+		t.Fatalf("status %v", code)
+	}
+	if len(resp.Data) != 3 || resp.Data[0] != 16 || resp.Data[2] != 18 {
+		t.Errorf("read %v", resp.Data)
+	}
+	dev.lock.Lock()
+	written := string(dev.written)
+	dev.lock.Unlock()
+	if written != "\x01\x02\xff" {
+		t.Errorf("device received %q", written)
+	}
+
+	if code = do(t, "POST", srv.URL+"/i2c/0x21", `{"read":1}`, nil); code != http.StatusBadGateway {
+		t.Errorf("read from missing device: status %v", code)
+	}
+	if code = do(t, "POST", srv.URL+"/i2c/0x20", `{}`, nil); code != http.StatusBadRequest {
+		t.Errorf("empty request: status %v", code)
+	}
+	if code = do(t, "POST", srv.URL+"/i2c/0x20", `{"write":[256]}`, nil); code != http.StatusBadRequest {
+		t.Errorf("byte out of range: status %v", code)
+	}
+	if code = do(t, "POST", srv.URL+"/i2c/zz", `{"read":1}`, nil); code != http.StatusBadRequest {
+		t.Errorf("invalid address: status %v", code)
+	}
+}
+
+// Transport counting the I2C config messages written by the client
+type i2cConfigCounter struct {
+	io.ReadWriteCloser
+	lock sync.Mutex
+	n    int
+}
+
+func (c *i2cConfigCounter) Write(p []byte) (int, error) {
+	c.lock.Lock()
+	c.n += bytes.Count(p, []byte{byte(firmata.StartSysEx), byte(firmata.I2CConfig)})
+	c.lock.Unlock()
+	return c.ReadWriteCloser.Write(p)
+}
+
+func (c *i2cConfigCounter) count() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.n
+}
+
+func TestI2CConfiguredOnce(t *testing.T) {
+	counter := &i2cConfigCounter{}
+	srv, _, vb := newProfileServer(t, virtual.ArduinoUno(), func(conn io.ReadWriteCloser) io.ReadWriteCloser {
+		counter.ReadWriteCloser = conn
+		return counter
+	})
+	vb.AttachI2CDevice(0x20, &echoDevice{})
+
+	for i := 0; i < 3; i++ {
+		if code := do(t, "POST", srv.URL+"/i2c/0x20", `{"read":1}`, nil); code != http.StatusOK {
+			t.Fatalf("status %v", code)
+		}
+	}
+	if n := counter.count(); n != 1 {
+		t.Errorf("I2C configured %v times", n)
+	}
+}
+
+func TestI2CUnsupported(t *testing.T) {
+	profile := virtual.ArduinoUno()
+	for _, p := range profile.Pins {
+		delete(p.Modes, firmata.I2C)
+	}
+	counter := &i2cConfigCounter{}
+	srv, _, _ := newProfileServer(t, profile, func(conn io.ReadWriteCloser) io.ReadWriteCloser {
+		counter.ReadWriteCloser = conn
+		return counter
+	})
+
+	if code := do(t, "POST", srv.URL+"/i2c/0x20", `{"write":[1]}`, nil); code != http.StatusNotImplemented {
+		t.Errorf("status %v", code)
+	}
+	if n := counter.count(); n != 0 {
+		t.Errorf("I2C configured %v times on a board without I2C", n)
+	}
+}
+
+func TestValueReading(t *testing.T) {
+	srv, b, vb := newTestServer(t)
+
+	var value map[string]interface{}
+	vb.SetAnalogInput(14, 500)
+	deadline := time.Now().Add(time.Second)
+	for {
+		value = nil
+		if code := do(t, "GET", srv.URL+"/pins/14/value", "", &value); code != http.StatusOK {
+			t.Fatalf("status %v", code)
+		}
+		if value["value"] == 500.0 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if _, ok := value["reading"]; ok || value["value"] != 500.0 {
+		t.Fatalf("unfiltered value %v", value)
+	}
+
+	if err := b.SetFilters(14, bridge.Linear(0, 1000, 0, 10)); err != nil {
+		t.Fatal(err)
+	}
+	deadline = time.Now().Add(time.Second)
+	for value["reading"] != 5.0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+		value = nil
+		do(t, "GET", srv.URL+"/pins/14/value", "", &value)
+	}
+	if value["reading"] != 5.0 || value["value"] != 500.0 {
+		t.Errorf("filtered value %v", value)
+	}
+}
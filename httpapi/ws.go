@@ -0,0 +1,222 @@
+// Copyright 2014 Krishna Raman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/kraman/go-firmata"
+	"github.com/kraman/go-firmata/bridge"
+)
+
+const (
+	// Time allowed to write a frame to the peer
+	wsWriteTimeout = time.Second * 10
+	// Interval between pings. The connection is dropped if no pong arrives
+	// before the next ping is due.
+	wsPingInterval = time.Second * 30
+)
+
+// Frame sent by a WebSocket client. Type is one of subscribe, unsubscribe,
+// mode and write. ID is optional and echoed in the reply.
+type wsRequest struct {
+	Type  string           `json:"type"`
+	ID    string           `json:"id,omitempty"`
+	Pins  []int            `json:"pins,omitempty"`
+	Pin   *int             `json:"pin,omitempty"`
+	Mode  *firmata.PinMode `json:"mode,omitempty"`
+	Value *int             `json:"value,omitempty"`
+}
+
+// Frame sent to a WebSocket client. Type is value for pin changes and ok or
+// error in reply to a request.
+type wsFrame struct {
+	Type    string           `json:"type"`
+	ID      string           `json:"id,omitempty"`
+	Error   string           `json:"error,omitempty"`
+	Pin     *int             `json:"pin,omitempty"`
+	Mode    *firmata.PinMode `json:"mode,omitempty"`
+	Value   *int             `json:"value,omitempty"`
+	Reading *float64         `json:"reading,omitempty"`
+	Edge    bridge.Edge      `json:"edge,omitempty"`
+	Time    *time.Time       `json:"time,omitempty"`
+}
+
+// Set the function checking the Origin header of WebSocket requests. By
+// default only same-origin requests are accepted.
+func WithOriginCheck(check func(r *http.Request) bool) Option {
+	return func(h *handler) {
+		h.upgrader.CheckOrigin = check
+	}
+}
+
+type wsConn struct {
+	board *bridge.Board
+	conn  *websocket.Conn
+
+	writeLock sync.Mutex
+
+	// pins the peer subscribed to
+	lock sync.Mutex
+	pins map[int]bool
+}
+
+// Stream pin values to a WebSocket client and accept commands from it
+func (h *handler) serveWS(w http.ResponseWriter, r *http.Request) {
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade already replied with an error
+		return
+	}
+	c := &wsConn{board: h.board, conn: conn, pins: make(map[int]bool)}
+	events, cancel := h.board.Subscribe()
+	defer cancel()
+	done := make(chan struct{})
+	defer close(done)
+	go c.writeEvents(events, done)
+
+	conn.SetReadDeadline(time.Now().Add(wsPingInterval * 2))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(wsPingInterval * 2))
+	})
+	for {
+		_, msg, err := conn.ReadMessage()
+		if err != nil {
+			conn.Close()
+			return
+		}
+		var req wsRequest
+		if err = json.Unmarshal(msg, &req); err != nil {
+			c.send(wsFrame{Type: "error", Error: fmt.Sprintf("Invalid request: %v", err)})
+			continue
+		}
+		c.handle(req)
+	}
+}
+
+func (c *wsConn) handle(req wsRequest) {
+	var err error
+	switch req.Type {
+	case "subscribe":
+		err = c.subscribe(req.Pins, true)
+	case "unsubscribe":
+		err = c.subscribe(req.Pins, false)
+	case "mode":
+		if req.Pin == nil {
+			err = fmt.Errorf("Missing pin")
+			break
+		}
+		if req.Mode == nil {
+			err = fmt.Errorf("Missing mode")
+			break
+		}
+		err = c.board.SetMode(*req.Pin, *req.Mode)
+	case "write":
+		if req.Pin == nil {
+			err = fmt.Errorf("Missing pin")
+			break
+		}
+		if req.Value == nil {
+			err = fmt.Errorf("Missing value")
+			break
+		}
+		err = c.board.Write(*req.Pin, *req.Value)
+	default:
+		err = fmt.Errorf("Unknown request type %q", req.Type)
+	}
+	if err != nil {
+		c.send(wsFrame{Type: "error", ID: req.ID, Error: err.Error()})
+		return
+	}
+	c.send(wsFrame{Type: "ok", ID: req.ID})
+}
+
+// Add or remove pins from the subscription. The current value of newly
+// subscribed pins is sent right away.
+func (c *wsConn) subscribe(pins []int, enable bool) error {
+	states := make([]bridge.PinState, 0, len(pins))
+	for _, pin := range pins {
+		state, err := c.board.Pin(pin)
+		if err != nil {
+			return err
+		}
+		states = append(states, state)
+	}
+
+	c.lock.Lock()
+	for _, state := range states {
+		if enable {
+			c.pins[state.Pin] = true
+		} else {
+			delete(c.pins, state.Pin)
+		}
+	}
+	c.lock.Unlock()
+
+	if enable {
+		now := time.Now()
+		for _, state := range states {
+			c.send(valueFrame(bridge.PinEvent{Pin: state.Pin, Mode: state.Mode, Value: state.Value, Reading: state.Reading, Time: now}))
+		}
+	}
+	return nil
+}
+
+// Forward events of subscribed pins and keep the connection alive
+func (c *wsConn) writeEvents(events <-chan bridge.PinEvent, done <-chan struct{}) {
+	ping := time.NewTicker(wsPingInterval)
+	defer ping.Stop()
+	for {
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				// board client closed
+				c.conn.Close()
+				return
+			}
+			c.lock.Lock()
+			subscribed := c.pins[ev.Pin]
+			c.lock.Unlock()
+			if subscribed {
+				c.send(valueFrame(ev))
+			}
+		case <-ping.C:
+			c.writeLock.Lock()
+			c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteTimeout))
+			c.writeLock.Unlock()
+		case <-done:
+			return
+		}
+	}
+}
+
+func (c *wsConn) send(f wsFrame) {
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+	c.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	if err := c.conn.WriteJSON(f); err != nil {
+		// the read loop notices the broken connection
+		c.conn.Close()
+	}
+}
+
+func valueFrame(ev bridge.PinEvent) wsFrame {
+	return wsFrame{Type: "value", Pin: &ev.Pin, Mode: &ev.Mode, Value: &ev.Value, Reading: ev.Reading, Edge: ev.Edge, Time: &ev.Time}
+}
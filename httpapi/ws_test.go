@@ -0,0 +1,182 @@
+// Copyright 2014 Krishna Raman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpapi_test
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/kraman/go-firmata"
+	"github.com/kraman/go-firmata/bridge"
+)
+
+type frame struct {
+	Type    string   `json:"type"`
+	ID      string   `json:"id"`
+	Error   string   `json:"error"`
+	Pin     *int     `json:"pin"`
+	Mode    string   `json:"mode"`
+	Value   *int     `json:"value"`
+	Reading *float64 `json:"reading"`
+	Edge    string   `json:"edge"`
+}
+
+func dialWS(t *testing.T, url string) *websocket.Conn {
+	t.Helper()
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(url, "http")+"/ws", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// Read frames until one matches or fail the test after a second
+func readFrame(t *testing.T, conn *websocket.Conn, match func(f frame) bool) frame {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	for {
+		var f frame
+		if err := conn.ReadJSON(&f); err != nil {
+			t.Fatalf("reading frame: %v", err)
+		}
+		if match(f) {
+			return f
+		}
+	}
+}
+
+func reply(id string) func(f frame) bool {
+	return func(f frame) bool { return f.ID == id }
+}
+
+func TestWSRequests(t *testing.T) {
+	srv, _, vb := newTestServer(t)
+	conn := dialWS(t, srv.URL)
+
+	conn.WriteJSON(map[string]interface{}{"type": "mode", "id": "1", "pin": 13, "mode": "OUTPUT"})
+	if f := readFrame(t, conn, reply("1")); f.Type != "ok" {
+		t.Fatalf("mode reply %+v", f)
+	}
+	conn.WriteJSON(map[string]interface{}{"type": "write", "id": "2", "pin": 13, "value": 1})
+	if f := readFrame(t, conn, reply("2")); f.Type != "ok" {
+		t.Fatalf("write reply %+v", f)
+	}
+	deadline := time.Now().Add(time.Second)
+	for !vb.DigitalOutput(13) && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if !vb.DigitalOutput(13) {
+		t.Error("pin 13 not high")
+	}
+
+	errors := []map[string]interface{}{
+		{"type": "write", "id": "3", "pin": 13},
+		{"type": "write", "id": "4", "pin": 13, "value": 5},
+		{"type": "mode", "id": "5", "pin": 4},
+		{"type": "subscribe", "id": "6", "pins": []int{40}},
+		{"type": "dance", "id": "7"},
+	}
+	for _, req := range errors {
+		conn.WriteJSON(req)
+		if f := readFrame(t, conn, reply(req["id"].(string))); f.Type != "error" || f.Error == "" {
+			t.Errorf("request %v got %+v", req, f)
+		}
+	}
+	missing := []map[string]interface{}{
+		{"type": "write", "id": "8", "value": 1},
+		{"type": "mode", "id": "9", "mode": "OUTPUT"},
+	}
+	for _, req := range missing {
+		conn.WriteJSON(req)
+		if f := readFrame(t, conn, reply(req["id"].(string))); f.Type != "error" || f.Error != "Missing pin" {
+			t.Errorf("request %v got %+v", req, f)
+		}
+	}
+	conn.WriteMessage(websocket.TextMessage, []byte("{"))
+	if f := readFrame(t, conn, func(f frame) bool { return f.Type == "error" }); !strings.Contains(f.Error, "Invalid request") {
+		t.Errorf("malformed request got %+v", f)
+	}
+}
+
+func TestWSSubscribe(t *testing.T) {
+	srv, b, vb := newTestServer(t)
+	conn := dialWS(t, srv.URL)
+
+	vb.SetAnalogInput(15, 100)
+	conn.WriteJSON(map[string]interface{}{"type": "subscribe", "id": "s", "pins": []int{15}})
+	readFrame(t, conn, reply("s"))
+
+	vb.SetAnalogInput(15, 900)
+	f := readFrame(t, conn, func(f frame) bool { return f.Type == "value" && f.Value != nil && *f.Value == 900 })
+	if *f.Pin != 15 || f.Mode != "ANALOG" {
+		t.Errorf("value frame %+v", f)
+	}
+
+	// unsubscribed pins are not forwarded
+	conn.WriteJSON(map[string]interface{}{"type": "unsubscribe", "id": "u", "pins": []int{15}})
+	readFrame(t, conn, reply("u"))
+	b.SetMode(4, firmata.Input)
+	conn.WriteJSON(map[string]interface{}{"type": "subscribe", "id": "s4", "pins": []int{4}})
+	vb.SetAnalogInput(15, 10)
+	vb.SetDigitalInput(4, true)
+	f = readFrame(t, conn, func(f frame) bool {
+		return f.Type == "value" && (*f.Pin == 15 || (*f.Pin == 4 && *f.Value == 1))
+	})
+	if *f.Pin != 4 {
+		t.Errorf("got frame for unsubscribed pin %+v", f)
+	}
+}
+
+func TestWSClosesWithBoard(t *testing.T) {
+	srv, b, _ := newTestServer(t)
+	conn := dialWS(t, srv.URL)
+
+	b.Client().Close()
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			if ne, ok := err.(net.Error); ok && ne.Timeout() {
+				t.Fatal("connection not closed after the client closed")
+			}
+			return
+		}
+	}
+}
+
+func TestWSSubscribeSendsReading(t *testing.T) {
+	srv, b, vb := newTestServer(t)
+	if err := b.SetFilters(14, bridge.Linear(0, 1000, 0, 10)); err != nil {
+		t.Fatal(err)
+	}
+	vb.SetAnalogInput(14, 500)
+	deadline := time.Now().Add(time.Second)
+	for r, _ := b.Reading(14); r != 5; r, _ = b.Reading(14) {
+		if time.Now().After(deadline) {
+			t.Fatalf("reading %v, want 5", r)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	conn := dialWS(t, srv.URL)
+	conn.WriteJSON(map[string]interface{}{"type": "subscribe", "id": "s", "pins": []int{14}})
+	f := readFrame(t, conn, func(f frame) bool { return f.Type == "value" })
+	if f.Reading == nil || *f.Reading != 5 || *f.Value != 500 {
+		t.Errorf("initial frame %+v", f)
+	}
+}
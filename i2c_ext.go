@@ -0,0 +1,192 @@
+// Copyright 2014 Krishna Raman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Data read from an I2C device by a continuous read, delivered on the
+// channel returned by GetI2CEvents()
+type I2CEvent struct {
+	Address  int
+	Register int
+	Data     []byte
+}
+
+func (e I2CEvent) String() string {
+	return fmt.Sprintf("I2C %#x register %#x = %x", e.Address, e.Register, e.Data)
+}
+
+type i2cRead struct {
+	addr int
+	data chan []byte
+	fail chan error
+}
+
+// Enable I2C on the board. delay is the pause between writing a register
+// and reading it back, needed by some devices.
+func (c *FirmataClient) I2CConfig(delay time.Duration) error {
+	us := int(delay / time.Microsecond)
+	if us < 0 || us > 0x3FFF {
+		return fmt.Errorf("Invalid I2C read delay %v", delay)
+	}
+	return c.sendSysEx(I2CConfig, int14to7Bit(us)...)
+}
+
+// Write data to the device at addr
+func (c *FirmataClient) I2CWrite(addr int, data []byte) error {
+	return c.i2cRequest(addr, I2CWrite, Encode7Bit(data)...)
+}
+
+// Read count bytes from the device at addr, starting at register unless it is
+// I2CRegisterNotSpecified. Fails if the board reports an I2C error for the
+// read or ctx is done first.
+func (c *FirmataClient) I2CRead(ctx context.Context, addr int, register int, count int) (data []byte, err error) {
+	defer c.observeQuery("i2c_read", time.Now(), &err)
+
+	req := &i2cRead{addr: addr, data: make(chan []byte, 1), fail: make(chan error, 1)}
+	c.i2cLock.Lock()
+	c.i2cReads = append(c.i2cReads, req)
+	c.i2cLock.Unlock()
+	defer c.releaseI2CRead(req)
+
+	if err = c.i2cRequest(addr, I2CRead, i2cReadArgs(register, count)...); err != nil {
+		return
+	}
+
+	select {
+	case data = <-req.data:
+	case err = <-req.fail:
+	case <-ctx.Done():
+		err = fmt.Errorf("I2C read from %#x: %w", addr, ctx.Err())
+	}
+	return
+}
+
+// Read count bytes from the device at addr every sampling interval. Results
+// are delivered as events on GetI2CEvents().
+func (c *FirmataClient) I2CReadContinuously(addr int, register int, count int) error {
+	return c.i2cRequest(addr, I2CReadContinuously, i2cReadArgs(register, count)...)
+}
+
+// Stop continuous reads from the device at addr
+func (c *FirmataClient) I2CStopReading(addr int) error {
+	return c.i2cRequest(addr, I2CStopReading)
+}
+
+// Get channel for continuous I2C read results
+func (c *FirmataClient) GetI2CEvents() <-chan I2CEvent {
+	return c.i2cChan
+}
+
+func i2cReadArgs(register int, count int) (args []byte) {
+	if register != I2CRegisterNotSpecified {
+		args = append(args, int14to7Bit(register)...)
+	}
+	return append(args, int14to7Bit(count)...)
+}
+
+func (c *FirmataClient) i2cRequest(addr int, mode I2CMode, args ...byte) error {
+	if addr < 0 || addr > 0x3FF {
+		return fmt.Errorf("Invalid I2C address %#x", addr)
+	}
+	if addr > 0x7F {
+		mode |= I2C10BitAddressMode
+	}
+	data := []byte{byte(addr & 0x7F), byte(mode) | byte((addr>>7)&0x07)}
+	return c.sendSysEx(I2CRequest, append(data, args...)...)
+}
+
+func (c *FirmataClient) releaseI2CRead(req *i2cRead) {
+	c.i2cLock.Lock()
+	defer c.i2cLock.Unlock()
+	for i, r := range c.i2cReads {
+		if r == req {
+			c.i2cReads = append(c.i2cReads[:i:i], c.i2cReads[i+1:]...)
+			break
+		}
+	}
+}
+
+func (c *FirmataClient) parseI2CReply(data []byte) {
+	if len(data) < 4 {
+		c.Log.Warn("Discarding short I2C reply", "length", len(data))
+		c.metrics.ParseError(I2CReply)
+		return
+	}
+	addr := int(data[0]&0x7F) | int(data[1]&0x7F)<<7
+	register := int(data[2]&0x7F) | int(data[3]&0x7F)<<7
+	values := Decode7Bit(data[4:])
+
+	// the oldest pending one-shot read of the device gets the reply,
+	// anything else is the result of a continuous read
+	c.i2cLock.Lock()
+	var req *i2cRead
+	for i, r := range c.i2cReads {
+		if r.addr == addr {
+			req = r
+			c.i2cReads = append(c.i2cReads[:i:i], c.i2cReads[i+1:]...)
+			break
+		}
+	}
+	c.i2cLock.Unlock()
+
+	if req != nil {
+		req.data <- values
+		return
+	}
+	select {
+	case c.i2cChan <- I2CEvent{Address: addr, Register: register, Data: values}:
+	default:
+		c.Log.Warn("I2C event buffer overflow. No listener?", "address", addr)
+		c.metrics.DroppedEvent("i2c")
+	}
+}
+
+// The firmware reports I2C failures as string messages prefixed with "I2C".
+// Read failures fail the oldest pending read, as the board handles reads in
+// order. Other failures, such as write errors, are left to be logged.
+func (c *FirmataClient) i2cError(msg string) bool {
+	if !isI2CReadError(msg) {
+		return false
+	}
+	c.i2cLock.Lock()
+	var req *i2cRead
+	if len(c.i2cReads) > 0 {
+		req = c.i2cReads[0]
+		c.i2cReads = c.i2cReads[1:]
+	}
+	c.i2cLock.Unlock()
+
+	if req == nil {
+		return false
+	}
+	req.fail <- fmt.Errorf("I2C read from %#x failed: %v", req.addr, msg)
+	return true
+}
+
+// Matches read failures such as "I2C: Too few bytes received" and
+// "I2C Read Error: ..."
+func isI2CReadError(msg string) bool {
+	if !strings.HasPrefix(msg, "I2C") {
+		return false
+	}
+	detail := strings.ToLower(strings.TrimLeft(msg[3:], ": "))
+	return strings.HasPrefix(detail, "read") || strings.Contains(detail, "bytes received")
+}
@@ -0,0 +1,64 @@
+// Copyright 2014 Krishna Raman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"testing"
+)
+
+func TestIsI2CReadError(t *testing.T) {
+	tests := map[string]bool{
+		"I2C Read Error: Too many bytes received": true,
+		"I2C: Too few bytes received":             true,
+		"I2C: Read failed: No device":             true,
+		"I2C: Write failed: already busy":         false,
+		"I2C: Too many queries":                   false,
+		"10-bit addressing not supported":         false,
+		"Read error":                              false,
+	}
+	for msg, want := range tests {
+		if got := isI2CReadError(msg); got != want {
+			t.Errorf("isI2CReadError(%q) = %v, want %v", msg, got, want)
+		}
+	}
+}
+
+func TestI2CErrorFailsPendingRead(t *testing.T) {
+	c, _ := newOfflineClient()
+	req := &i2cRead{addr: 0x48, data: make(chan []byte, 1), fail: make(chan error, 1)}
+	c.i2cReads = append(c.i2cReads, req)
+
+	if c.i2cError("I2C: Write failed: no ack") {
+		t.Error("write failure consumed by the pending read")
+	}
+	if len(c.i2cReads) != 1 {
+		t.Fatal("write failure removed the pending read")
+	}
+
+	if !c.i2cError("I2C Read Error: Too few bytes received") {
+		t.Fatal("read failure not handled")
+	}
+	select {
+	case err := <-req.fail:
+		if err == nil {
+			t.Error("nil read failure")
+		}
+	default:
+		t.Error("pending read not failed")
+	}
+	if len(c.i2cReads) != 0 {
+		t.Error("failed read still pending")
+	}
+}
@@ -0,0 +1,230 @@
+// Copyright 2014 Krishna Raman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Returned (wrapped) by methods the connected board's protocol version does not support
+var ErrUnsupported = errors.New("not supported by board")
+
+type Feature byte
+
+// Identification of the connected board
+type BoardInfo struct {
+	ProtocolMajor byte
+	ProtocolMinor byte
+	FirmwareName  string
+	FirmwareMajor int
+	FirmwareMinor int
+	// Number of pins and analog channels reported during the handshake
+	Pins           int
+	AnalogChannels int
+}
+
+func (i BoardInfo) String() string {
+	return fmt.Sprintf("%v %v.%v (protocol %v.%v, %v pins, %v analog channels)",
+		i.FirmwareName, i.FirmwareMajor, i.FirmwareMinor,
+		i.ProtocolMajor, i.ProtocolMinor, i.Pins, i.AnalogChannels)
+}
+
+// Minimum protocol version needed for each feature. Firmware such as
+// contrib/ExtendedFirmata reports the version of the Firmata library it was
+// built with, so a board can implement a feature while reporting an older
+// protocol; use WithFeatures to skip the check for those.
+var featureVersions = map[Feature][2]byte{
+	FeatureDigitalPinValue: {2, 5},
+	FeatureInputPullup:     {2, 5},
+	FeatureScheduler:       {2, 4},
+	FeatureSerial:          {2, 5},
+	FeatureAccelStepper:    {2, 6},
+}
+
+func (f Feature) String() string {
+	switch f {
+	case FeatureDigitalPinValue:
+		return "SetDigitalPinValue"
+	case FeatureInputPullup:
+		return "INPUT_PULLUP"
+	case FeatureScheduler:
+		return "Scheduler"
+	case FeatureSerial:
+		return "Serial"
+	case FeatureAccelStepper:
+		return "AccelStepper"
+	}
+	return fmt.Sprintf("Feature(%d)", byte(f))
+}
+
+// Get the protocol and firmware versions captured from the board
+func (c *FirmataClient) BoardInfo() (info BoardInfo) {
+	c.infoLock.Lock()
+	defer c.infoLock.Unlock()
+
+	if len(c.protocolVersion) == 2 {
+		info.ProtocolMajor = c.protocolVersion[0]
+		info.ProtocolMinor = c.protocolVersion[1]
+	}
+	if len(c.firmwareVersion) == 2 {
+		info.FirmwareMajor = c.firmwareVersion[0]
+		info.FirmwareMinor = c.firmwareVersion[1]
+	}
+	info.FirmwareName = c.firmwareName
+	info.Pins = len(c.pinModes)
+	info.AnalogChannels = len(c.analogChannelPinsMap)
+	return
+}
+
+// Ask the board for its protocol version
+func (c *FirmataClient) QueryVersion(ctx context.Context) (major byte, minor byte, err error) {
+	defer c.observeQuery("version", time.Now(), &err)
+	reply := make(chan struct{}, 1)
+
+	c.infoLock.Lock()
+	c.versionQueries = append(c.versionQueries, reply)
+	c.infoLock.Unlock()
+
+	defer func() {
+		c.infoLock.Lock()
+		defer c.infoLock.Unlock()
+		for i, w := range c.versionQueries {
+			if w == reply {
+				c.versionQueries = append(c.versionQueries[:i:i], c.versionQueries[i+1:]...)
+				break
+			}
+		}
+	}()
+
+	if err = c.sendCommand([]byte{byte(ReportVersion)}); err != nil {
+		return
+	}
+
+	select {
+	case <-reply:
+		info := c.BoardInfo()
+		major, minor = info.ProtocolMajor, info.ProtocolMinor
+	case <-ctx.Done():
+		err = fmt.Errorf("Version query: %w", ctx.Err())
+	}
+	return
+}
+
+// Ask the board for its firmware name and version
+func (c *FirmataClient) QueryFirmware(ctx context.Context) (name string, major int, minor int, err error) {
+	defer c.observeQuery("firmware", time.Now(), &err)
+	reply := make(chan struct{}, 1)
+
+	c.infoLock.Lock()
+	c.firmwareQueries = append(c.firmwareQueries, reply)
+	c.infoLock.Unlock()
+
+	defer func() {
+		c.infoLock.Lock()
+		defer c.infoLock.Unlock()
+		for i, w := range c.firmwareQueries {
+			if w == reply {
+				c.firmwareQueries = append(c.firmwareQueries[:i:i], c.firmwareQueries[i+1:]...)
+				break
+			}
+		}
+	}()
+
+	if err = c.sendSysEx(ReportFirmware); err != nil {
+		return
+	}
+
+	select {
+	case <-reply:
+		info := c.BoardInfo()
+		name, major, minor = info.FirmwareName, info.FirmwareMajor, info.FirmwareMinor
+	case <-ctx.Done():
+		err = fmt.Errorf("Firmware query: %w", ctx.Err())
+	}
+	return
+}
+
+// Treat features as supported whatever protocol version the board reports
+func WithFeatures(features ...Feature) ClientOption {
+	return func(c *FirmataClient) {
+		c.infoLock.Lock()
+		defer c.infoLock.Unlock()
+
+		if c.forcedFeatures == nil {
+			c.forcedFeatures = make(map[Feature]bool)
+		}
+		for _, f := range features {
+			c.forcedFeatures[f] = true
+		}
+	}
+}
+
+// Check if the protocol version spoken by the board supports feature
+func (c *FirmataClient) Supports(feature Feature) bool {
+	c.infoLock.Lock()
+	forced := c.forcedFeatures[feature]
+	c.infoLock.Unlock()
+
+	v, ok := featureVersions[feature]
+	return forced || !ok || c.protocolAtLeast(v[0], v[1])
+}
+
+func (c *FirmataClient) requireFeature(feature Feature) error {
+	if c.Supports(feature) {
+		return nil
+	}
+	info := c.BoardInfo()
+	v := featureVersions[feature]
+	return fmt.Errorf("%v requires protocol %v.%v, board speaks %v.%v: %w",
+		feature, v[0], v[1], info.ProtocolMajor, info.ProtocolMinor, ErrUnsupported)
+}
+
+func (c *FirmataClient) protocolAtLeast(major byte, minor byte) bool {
+	c.infoLock.Lock()
+	defer c.infoLock.Unlock()
+
+	if len(c.protocolVersion) < 2 {
+		return false
+	}
+	return c.protocolVersion[0] > major || (c.protocolVersion[0] == major && c.protocolVersion[1] >= minor)
+}
+
+func (c *FirmataClient) setProtocolVersion(major byte, minor byte) {
+	c.infoLock.Lock()
+	c.protocolVersion = []byte{major, minor}
+	queries := c.versionQueries
+	c.versionQueries = nil
+	c.infoLock.Unlock()
+
+	for _, q := range queries {
+		q <- struct{}{}
+	}
+}
+
+func (c *FirmataClient) setFirmware(name string, major int, minor int) {
+	c.infoLock.Lock()
+	c.firmwareName = name
+	c.firmwareVersion = []int{major, minor}
+	queries := c.firmwareQueries
+	c.firmwareQueries = nil
+	c.infoLock.Unlock()
+
+	for _, q := range queries {
+		q <- struct{}{}
+	}
+}
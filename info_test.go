@@ -0,0 +1,119 @@
+// Copyright 2014 Krishna Raman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestFeatureGating(t *testing.T) {
+	c, rc := newOfflineClient()
+	c.protocolVersion = []byte{2, 3}
+
+	calls := map[string]func() error{
+		"SerialConfig":      func() error { return c.SerialConfig(SerialPort(0), 9600, 0, 0) },
+		"SerialWrite":       func() error { return c.SerialWrite(SerialPort(0), []byte("x")) },
+		"ResetScheduler":    c.ResetScheduler,
+		"AccelStepperZero":  func() error { return c.AccelStepperZero(0) },
+		"AccelStepperStop":  func() error { return c.AccelStepperStop(0) },
+		"QueryTasks":        func() error { _, err := c.QueryTasks(context.Background()); return err },
+		"AccelStepperQuery": func() error { _, err := c.AccelStepperPosition(context.Background(), 0); return err },
+	}
+	for name, call := range calls {
+		if err := call(); !errors.Is(err, ErrUnsupported) {
+			t.Errorf("%v on protocol 2.3 returned %v", name, err)
+		}
+	}
+	if len(rc.Sent()) != 0 {
+		t.Errorf("unsupported calls sent %#v", rc.Sent())
+	}
+	if len(c.taskListQueries) != 0 || len(c.stepperPositions[0]) != 0 {
+		t.Error("unsupported queries left waiters behind")
+	}
+
+	c.protocolVersion = []byte{2, 5}
+	if err := c.ResetScheduler(); err != nil {
+		t.Error(err)
+	}
+	if err := c.AccelStepperZero(0); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("AccelStepper on protocol 2.5 returned %v", err)
+	}
+}
+
+func TestWithFeatures(t *testing.T) {
+	c, rc := newOfflineClient()
+	c.protocolVersion = []byte{2, 3}
+	WithFeatures(FeatureSerial)(c)
+
+	if !c.Supports(FeatureSerial) {
+		t.Error("forced Serial not supported on protocol 2.3")
+	}
+	if c.Supports(FeatureAccelStepper) {
+		t.Error("AccelStepper supported on protocol 2.3 without forcing it")
+	}
+	if err := c.SerialConfig(SerialPort(0), 9600, 0, 0); err != nil {
+		t.Error(err)
+	}
+	if err := c.SerialWrite(SerialPort(0), []byte("x")); err != nil {
+		t.Error(err)
+	}
+	if len(rc.Sent()) == 0 {
+		t.Error("forced Serial sent nothing")
+	}
+}
+
+func TestInfoQueryCancel(t *testing.T) {
+	c, _ := newOfflineClient()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, _, err := c.QueryVersion(ctx); err == nil {
+		t.Error("version query on cancelled context succeeded")
+	}
+	if _, _, _, err := c.QueryFirmware(ctx); err == nil {
+		t.Error("firmware query on cancelled context succeeded")
+	}
+	if len(c.versionQueries) != 0 || len(c.firmwareQueries) != 0 {
+		t.Errorf("%v version and %v firmware waiters left after cancel", len(c.versionQueries), len(c.firmwareQueries))
+	}
+}
+
+func TestInfoQueryReply(t *testing.T) {
+	c, _ := newOfflineClient()
+	c.ready = true
+
+	done := make(chan string)
+	go func() {
+		name, _, _, _ := c.QueryFirmware(context.Background())
+		done <- name
+	}()
+	for {
+		c.infoLock.Lock()
+		n := len(c.firmwareQueries)
+		c.infoLock.Unlock()
+		if n > 0 {
+			break
+		}
+	}
+	c.parseSysEx(append([]byte{byte(ReportFirmware), 2, 6}, Encode7Bit([]byte("Test.ino"))...))
+	if name := <-done; name != "Test.ino" {
+		t.Errorf("firmware name %q", name)
+	}
+	if info := c.BoardInfo(); info.FirmwareMajor != 2 || info.FirmwareMinor != 6 {
+		t.Errorf("firmware version %v.%v", info.FirmwareMajor, info.FirmwareMinor)
+	}
+}
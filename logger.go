@@ -0,0 +1,66 @@
+// Copyright 2014 Krishna Raman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// Option applied to a FirmataClient by NewClient
+type ClientOption func(*FirmataClient)
+
+// Log through the given slog handler. Protocol traffic is logged at debug
+// level, dropped or unexpected messages at warn level.
+func WithLogHandler(h slog.Handler) ClientOption {
+	return func(c *FirmataClient) {
+		c.Log = slog.New(h).With("component", "go-firmata")
+	}
+}
+
+// Log through the given logger
+func WithLogger(l *slog.Logger) ClientOption {
+	return func(c *FirmataClient) {
+		c.Log = l
+	}
+}
+
+// Whether protocol traffic is logged. Checked before formatting message
+// bytes so the hot paths do not pay for it.
+func (c *FirmataClient) tracing() bool {
+	return c.Verbose && c.Log.Enabled(context.Background(), slog.LevelDebug)
+}
+
+func hexBytes(data []byte) string {
+	var b strings.Builder
+	for _, d := range data {
+		fmt.Fprintf(&b, " %#2x", d)
+	}
+	return b.String()
+}
+
+// Default logger. Discards everything.
+func discardLogger() *slog.Logger {
+	return slog.New(discardHandler{})
+}
+
+type discardHandler struct{}
+
+func (discardHandler) Enabled(context.Context, slog.Level) bool  { return false }
+func (discardHandler) Handle(context.Context, slog.Record) error { return nil }
+func (h discardHandler) WithAttrs([]slog.Attr) slog.Handler      { return h }
+func (h discardHandler) WithGroup(string) slog.Handler           { return h }
@@ -0,0 +1,58 @@
+// Copyright 2014 Krishna Raman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestHexBytes(t *testing.T) {
+	if got := hexBytes([]byte{0x91, 0x02, 0x7F}); got != " 0x91 0x2 0x7f" {
+		t.Errorf("hexBytes = %q", got)
+	}
+	if got := hexBytes(nil); got != "" {
+		t.Errorf("hexBytes(nil) = %q", got)
+	}
+}
+
+func TestTracing(t *testing.T) {
+	var out bytes.Buffer
+	c, _ := newOfflineClient()
+	c.Log = slog.New(slog.NewTextHandler(&out, &slog.HandlerOptions{Level: slog.LevelInfo}))
+
+	c.Verbose = true
+	if c.tracing() {
+		t.Error("tracing with the logger at info level")
+	}
+	c.sendCommand([]byte{byte(ReportVersion)})
+	if out.Len() != 0 {
+		t.Errorf("command logged at info level: %q", out.String())
+	}
+
+	c.Log = slog.New(slog.NewTextHandler(&out, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	c.Verbose = false
+	if c.tracing() {
+		t.Error("tracing without Verbose")
+	}
+
+	c.Verbose = true
+	c.sendCommand([]byte{byte(ReportVersion)})
+	if !strings.Contains(out.String(), "0xf9") {
+		t.Errorf("command bytes not logged: %q", out.String())
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright 2014 Krishna Raman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"io"
+	"time"
+)
+
+// Receives measurements about the Firmata link. Methods are called from the
+// reply reader and from callers' goroutines and must be safe for concurrent
+// use and must not block.
+type MetricsCollector interface {
+	// Raw bytes read from and written to the board
+	BytesIn(n int)
+	BytesOut(n int)
+	// Messages by command. Digital and analog messages are reported without
+	// their port or channel.
+	MessageIn(cmd FirmataCommand)
+	MessageOut(cmd FirmataCommand)
+	SysExIn(cmd SysExCommand)
+	SysExOut(cmd SysExCommand)
+	// Malformed replies from the board
+	ParseError(cmd SysExCommand)
+	// Bytes dropped because they were not part of a known message
+	DiscardedBytes(n int)
+	// Events or replies dropped because no one was listening
+	DroppedEvent(kind string)
+	// Time from sending a query until its reply arrived or the caller gave up
+	QueryLatency(query string, d time.Duration, err error)
+	// Board resets sent while waiting for the handshake to complete
+	HandshakeReset()
+}
+
+// Report link measurements to m
+func WithMetrics(m MetricsCollector) ClientOption {
+	return func(c *FirmataClient) {
+		c.metrics = m
+	}
+}
+
+type noopMetrics struct{}
+
+func (noopMetrics) BytesIn(int)                               {}
+func (noopMetrics) BytesOut(int)                              {}
+func (noopMetrics) MessageIn(FirmataCommand)                  {}
+func (noopMetrics) MessageOut(FirmataCommand)                 {}
+func (noopMetrics) SysExIn(SysExCommand)                      {}
+func (noopMetrics) SysExOut(SysExCommand)                     {}
+func (noopMetrics) ParseError(SysExCommand)                   {}
+func (noopMetrics) DiscardedBytes(int)                        {}
+func (noopMetrics) DroppedEvent(string)                       {}
+func (noopMetrics) QueryLatency(string, time.Duration, error) {}
+func (noopMetrics) HandshakeReset()                           {}
+
+// Strip the port or channel from digital and analog message commands
+func messageType(cmd FirmataCommand) FirmataCommand {
+	if cmd < 0xF0 {
+		return cmd & 0xF0
+	}
+	return cmd
+}
+
+// Usage: defer c.observeQuery("spi", time.Now(), &err)
+func (c *FirmataClient) observeQuery(query string, start time.Time, err *error) {
+	c.metrics.QueryLatency(query, time.Since(start), *err)
+}
+
+type countingReader struct {
+	r       io.Reader
+	metrics MetricsCollector
+}
+
+func (r countingReader) Read(p []byte) (n int, err error) {
+	n, err = r.r.Read(p)
+	if n > 0 {
+		r.metrics.BytesIn(n)
+	}
+	return
+}
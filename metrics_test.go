@@ -0,0 +1,74 @@
+// Copyright 2014 Krishna Raman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"testing"
+)
+
+type countingMetrics struct {
+	noopMetrics
+	lock     sync.Mutex
+	messages map[FirmataCommand]int
+	sysex    map[SysExCommand]int
+}
+
+func (m *countingMetrics) MessageIn(cmd FirmataCommand) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.messages[cmd]++
+}
+
+func (m *countingMetrics) SysExIn(cmd SysExCommand) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.sysex[cmd]++
+}
+
+type scriptedConn struct {
+	io.Reader
+	io.Writer
+}
+
+func (scriptedConn) Close() error { return nil }
+
+func TestMetricsCountSysExOnce(t *testing.T) {
+	var in bytes.Buffer
+	in.Write([]byte{byte(ReportVersion), 2, 6})
+	in.Write([]byte{byte(StartSysEx), byte(StringData)})
+	in.Write(Encode7Bit([]byte("hi")))
+	in.Write([]byte{byte(EndSysEx)})
+
+	m := &countingMetrics{messages: make(map[FirmataCommand]int), sysex: make(map[SysExCommand]int)}
+	var conn io.ReadWriteCloser = scriptedConn{&in, io.Discard}
+	c := &FirmataClient{conn: &conn, Log: discardLogger(), metrics: m,
+		valueChan: make(chan FirmataValue), serialChan: make(chan SerialEvent), readerDone: make(chan struct{})}
+
+	// the reader stops at the end of the script
+	c.replyReader()
+
+	if n := m.sysex[StringData]; n != 1 {
+		t.Errorf("string data counted %v times", n)
+	}
+	if n := m.messages[StartSysEx]; n != 0 {
+		t.Errorf("SysEx also counted %v times as a command", n)
+	}
+	if n := m.messages[ReportVersion]; n != 1 {
+		t.Errorf("version report counted %v times", n)
+	}
+}
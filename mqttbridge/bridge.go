@@ -0,0 +1,313 @@
+// Copyright 2014 Krishna Raman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// MQTT bridge for a board. Pin values and modes are published as retained
+// messages and outputs are driven by publishing to the matching set topics:
+//
+//	firmata/<board>/status              online or offline
+//	firmata/<board>/pin/<n>/state       value of pin n, filtered if it has filters
+//	firmata/<board>/pin/<n>/edge        rising or falling, not retained
+//	firmata/<board>/pin/<n>/set         write a value to pin n
+//	firmata/<board>/pin/<n>/mode        mode of pin n
+//	firmata/<board>/pin/<n>/mode/set    set the mode of pin n
+//
+// The status topic is the connection's last will, so it turns offline when
+// the bridge drops off the broker.
+//
+// Usage:
+//
+//	opts := mqtt.NewClientOptions().AddBroker("tcp://localhost:1883")
+//	m := mqttbridge.New(bridge.New(arduino), "uno", opts)
+//	err := m.Start()
+package mqttbridge
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/kraman/go-firmata"
+	"github.com/kraman/go-firmata/bridge"
+)
+
+// Time to wait for the broker to acknowledge the offline status on Close
+const closeTimeout = time.Second * 2
+
+// Publishes the pins of a board to an MQTT broker
+type Bridge struct {
+	board  *bridge.Board
+	client mqtt.Client
+
+	prefix   string
+	qos      byte
+	interval time.Duration
+
+	lock    sync.Mutex
+	limits  map[int]*limit
+	cancel  func()
+	stopped bool
+}
+
+// Rate limit state of a pin
+type limit struct {
+	last    time.Time
+	pending bool
+	payload string
+	timer   *time.Timer
+}
+
+type Option func(*Bridge)
+
+// Set the first topic level. Defaults to firmata.
+func WithTopicPrefix(prefix string) Option {
+	return func(m *Bridge) {
+		m.prefix = prefix
+	}
+}
+
+// Set the QoS used for publishing and subscribing. Defaults to 0.
+func WithQoS(qos byte) Option {
+	return func(m *Bridge) {
+		m.qos = qos
+	}
+}
+
+// Publish the state of a pin at most once per interval. Changes within the
+// interval are coalesced and the latest value is published when it ends.
+func WithRateLimit(interval time.Duration) Option {
+	return func(m *Bridge) {
+		m.interval = interval
+	}
+}
+
+// Create a bridge publishing the pins of board under the topic
+// <prefix>/<name>. opts configures the broker connection; the bridge sets
+// its last will and connect handler.
+func New(board *bridge.Board, name string, opts *mqtt.ClientOptions, options ...Option) *Bridge {
+	m := &Bridge{
+		board:  board,
+		prefix: "firmata",
+		limits: make(map[int]*limit),
+	}
+	for _, opt := range options {
+		opt(m)
+	}
+	m.prefix = m.prefix + "/" + name
+
+	opts.SetWill(m.topic("status"), "offline", m.qos, true)
+	opts.SetOnConnectHandler(m.onConnect)
+	m.client = mqtt.NewClient(opts)
+	return m
+}
+
+// Connect to the broker and start publishing
+func (m *Bridge) Start() error {
+	events, cancel := m.board.Subscribe()
+	m.lock.Lock()
+	m.cancel = cancel
+	m.lock.Unlock()
+
+	token := m.client.Connect()
+	if token.Wait(); token.Error() != nil {
+		cancel()
+		return fmt.Errorf("Unable to connect to MQTT broker: %w", token.Error())
+	}
+	go m.publishEvents(events)
+	return nil
+}
+
+// Publish the offline status and disconnect from the broker
+func (m *Bridge) Close() {
+	m.lock.Lock()
+	m.stopped = true
+	if m.cancel != nil {
+		m.cancel()
+	}
+	for _, l := range m.limits {
+		if l.timer != nil {
+			l.timer.Stop()
+		}
+	}
+	m.lock.Unlock()
+
+	if m.client.IsConnected() {
+		m.client.Publish(m.topic("status"), m.qos, true, "offline").WaitTimeout(closeTimeout)
+	}
+	m.client.Disconnect(uint(closeTimeout / time.Millisecond))
+}
+
+// Announce the board and subscribe to set topics. Called on every
+// (re)connect, so retained state is refreshed after the broker restarts.
+func (m *Bridge) onConnect(c mqtt.Client) {
+	m.publish("status", "online")
+	for _, pin := range m.board.Pins() {
+		m.publish(pinTopic(pin.Pin, "mode"), pin.Mode.String())
+		m.publish(pinTopic(pin.Pin, "state"), statePayload(pin.Value, pin.Reading))
+	}
+	m.check(c.Subscribe(m.topic("pin/+/set"), m.qos, m.onSet), "subscribe")
+	m.check(c.Subscribe(m.topic("pin/+/mode/set"), m.qos, m.onSetMode), "subscribe")
+}
+
+// Write the payload of a pin/<n>/set message to the pin. Payloads are
+// numbers; ON and OFF are accepted as 1 and 0.
+func (m *Bridge) onSet(c mqtt.Client, msg mqtt.Message) {
+	pin, ok := m.pinOf(msg.Topic())
+	if !ok {
+		return
+	}
+	payload := strings.TrimSpace(string(msg.Payload()))
+	var value int
+	var err error
+	switch strings.ToUpper(payload) {
+	case "ON":
+		value = 1
+	case "OFF":
+		value = 0
+	default:
+		value, err = strconv.Atoi(payload)
+	}
+	if err == nil {
+		err = m.board.Write(pin, value)
+	}
+	if err != nil {
+		m.board.Log.Warn("MQTT set failed", "topic", msg.Topic(), "payload", payload, "err", err)
+	}
+}
+
+func (m *Bridge) onSetMode(c mqtt.Client, msg mqtt.Message) {
+	pin, ok := m.pinOf(msg.Topic())
+	if !ok {
+		return
+	}
+	mode, err := firmata.ParsePinMode(strings.TrimSpace(string(msg.Payload())))
+	if err == nil {
+		err = m.board.SetMode(pin, mode)
+	}
+	if err != nil {
+		m.board.Log.Warn("MQTT mode set failed", "topic", msg.Topic(), "payload", string(msg.Payload()), "err", err)
+		return
+	}
+	m.publish(pinTopic(pin, "mode"), mode.String())
+	// through the rate limit so a pending publish does not restore the
+	// value from before the mode change
+	m.limit(pin, "0")
+}
+
+// Publish pin changes until the board's client closes, then go offline
+func (m *Bridge) publishEvents(events <-chan bridge.PinEvent) {
+	for ev := range events {
+		m.limit(ev.Pin, statePayload(ev.Value, ev.Reading))
+		if ev.Edge != 0 {
+			m.publishEdge(ev.Pin, ev.Edge)
+		}
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if !m.stopped {
+		m.publish("status", "offline")
+	}
+}
+
+// Publish an edge as it happens. Edges are not retained, as they are only
+// meaningful when they occur.
+func (m *Bridge) publishEdge(pin int, edge bridge.Edge) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if !m.stopped {
+		m.check(m.client.Publish(m.topic(pinTopic(pin, "edge")), m.qos, false, edge.String()), "publish")
+	}
+}
+
+// Publish a state payload now, or once the pin's rate limit interval ends
+func (m *Bridge) limit(pin int, payload string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.stopped {
+		return
+	}
+	l := m.limits[pin]
+	if l == nil {
+		l = &limit{}
+		m.limits[pin] = l
+	}
+	if wait := m.interval - time.Since(l.last); wait > 0 {
+		l.payload = payload
+		if !l.pending {
+			l.pending = true
+			l.timer = time.AfterFunc(wait, func() { m.flush(pin) })
+		}
+		return
+	}
+	l.last = time.Now()
+	m.publish(pinTopic(pin, "state"), payload)
+}
+
+func (m *Bridge) flush(pin int) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	l := m.limits[pin]
+	if m.stopped || !l.pending {
+		return
+	}
+	l.pending = false
+	l.last = time.Now()
+	m.publish(pinTopic(pin, "state"), l.payload)
+}
+
+// Filtered reading of pins with filters, otherwise the value
+func statePayload(value int, reading *float64) string {
+	if reading != nil {
+		return strconv.FormatFloat(*reading, 'g', -1, 64)
+	}
+	return strconv.Itoa(value)
+}
+
+// Publish a retained message under the board's topic
+func (m *Bridge) publish(topic string, payload string) {
+	m.check(m.client.Publish(m.topic(topic), m.qos, true, payload), "publish")
+}
+
+// Log the failure of an MQTT operation without blocking the caller
+func (m *Bridge) check(token mqtt.Token, op string) {
+	go func() {
+		if token.Wait(); token.Error() != nil {
+			m.board.Log.Warn("MQTT "+op+" failed", "err", token.Error())
+		}
+	}()
+}
+
+func (m *Bridge) topic(topic string) string {
+	return m.prefix + "/" + topic
+}
+
+// Get the pin number from a <prefix>/pin/<n>/... topic
+func (m *Bridge) pinOf(topic string) (pin int, ok bool) {
+	rest := strings.TrimPrefix(topic, m.topic("pin/"))
+	n, _, _ := strings.Cut(rest, "/")
+	pin, err := strconv.Atoi(n)
+	if err != nil {
+		m.board.Log.Warn("Invalid pin in MQTT topic", "topic", topic)
+		return
+	}
+	ok = true
+	return
+}
+
+func pinTopic(pin int, topic string) string {
+	return fmt.Sprintf("pin/%v/%v", pin, topic)
+}
@@ -0,0 +1,205 @@
+// Copyright 2014 Krishna Raman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mqttbridge_test
+
+import (
+	"testing"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/kraman/go-firmata"
+	"github.com/kraman/go-firmata/bridge"
+	"github.com/kraman/go-firmata/mqttbridge"
+	"github.com/kraman/go-firmata/virtual"
+)
+
+func startBridge(t *testing.T, options ...mqttbridge.Option) (*testBroker, *mqttbridge.Bridge, *bridge.Board, *virtual.Board) {
+	t.Helper()
+	broker := startBroker(t)
+	vb := virtual.New(virtual.ArduinoUno())
+	c, err := firmata.NewClientWithTransport(vb.Transport())
+	if err != nil {
+		vb.Close()
+		t.Fatal(err)
+	}
+	c.SetAnalogSamplingInterval(5)
+	b := bridge.New(c)
+
+	opts := mqtt.NewClientOptions().AddBroker(broker.URL()).SetClientID("test")
+	m := mqttbridge.New(b, "uno", opts, options...)
+	if err = m.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		m.Close()
+		c.Close()
+		vb.Close()
+	})
+	return broker, m, b, vb
+}
+
+// Wait until a retained message has the payload or fail the test after a second
+func waitRetained(t *testing.T, broker *testBroker, topic string, payload string) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for broker.Retained(topic) != payload {
+		if time.Now().After(deadline) {
+			t.Fatalf("%v is %q, want %q", topic, broker.Retained(topic), payload)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestAnnounce(t *testing.T) {
+	broker, _, _, vb := startBridge(t)
+
+	waitRetained(t, broker, "firmata/uno/status", "online")
+	waitRetained(t, broker, "firmata/uno/pin/13/mode", "OUTPUT")
+	waitRetained(t, broker, "firmata/uno/pin/14/mode", "ANALOG")
+
+	vb.SetAnalogInput(14, 321)
+	waitRetained(t, broker, "firmata/uno/pin/14/state", "321")
+}
+
+func TestSet(t *testing.T) {
+	broker, _, _, vb := startBridge(t)
+	waitRetained(t, broker, "firmata/uno/status", "online")
+
+	broker.Publish("firmata/uno/pin/13/set", "ON", false)
+	waitRetained(t, broker, "firmata/uno/pin/13/state", "1")
+	if !vb.DigitalOutput(13) {
+		t.Error("pin 13 not high")
+	}
+
+	broker.Publish("firmata/uno/pin/9/mode/set", "PWM", false)
+	waitRetained(t, broker, "firmata/uno/pin/9/mode", "PWM")
+	broker.Publish("firmata/uno/pin/9/set", "128", false)
+	waitRetained(t, broker, "firmata/uno/pin/9/state", "128")
+	if v := vb.AnalogOutput(9); v != 128 {
+		t.Errorf("pin 9 duty %v", v)
+	}
+
+	// invalid requests change nothing
+	broker.Publish("firmata/uno/pin/9/set", "lots", false)
+	broker.Publish("firmata/uno/pin/4/mode/set", "PWM", false)
+	broker.Publish("firmata/uno/pin/x/set", "1", false)
+	time.Sleep(20 * time.Millisecond)
+	if v := broker.Retained("firmata/uno/pin/9/state"); v != "128" {
+		t.Errorf("pin 9 state %q after invalid set", v)
+	}
+	if v := broker.Retained("firmata/uno/pin/4/mode"); v != "OUTPUT" {
+		t.Errorf("pin 4 mode %q after invalid mode set", v)
+	}
+}
+
+func TestRateLimitModeChange(t *testing.T) {
+	broker, _, _, vb := startBridge(t, mqttbridge.WithRateLimit(300*time.Millisecond))
+	waitRetained(t, broker, "firmata/uno/status", "online")
+
+	// the announcement bypasses the rate limit, the second value does not
+	vb.SetAnalogInput(15, 100)
+	waitRetained(t, broker, "firmata/uno/pin/15/state", "100")
+	vb.SetAnalogInput(15, 150)
+	waitRetained(t, broker, "firmata/uno/pin/15/state", "150")
+
+	// held back by the rate limit
+	vb.SetAnalogInput(15, 200)
+	time.Sleep(50 * time.Millisecond)
+	if v := broker.Retained("firmata/uno/pin/15/state"); v != "150" {
+		t.Fatalf("pin 15 state %q within the rate limit", v)
+	}
+
+	// the mode change supersedes the pending value
+	broker.Publish("firmata/uno/pin/15/mode/set", "OUTPUT", false)
+	waitRetained(t, broker, "firmata/uno/pin/15/mode", "OUTPUT")
+	time.Sleep(400 * time.Millisecond)
+	if v := broker.Retained("firmata/uno/pin/15/state"); v != "0" {
+		t.Errorf("pin 15 state %q after mode change", v)
+	}
+}
+
+func TestOfflineWhenClientCloses(t *testing.T) {
+	broker, _, b, _ := startBridge(t)
+	waitRetained(t, broker, "firmata/uno/status", "online")
+
+	b.Client().Close()
+	waitRetained(t, broker, "firmata/uno/status", "offline")
+}
+
+func TestOfflineOnClose(t *testing.T) {
+	broker, m, _, _ := startBridge(t)
+	waitRetained(t, broker, "firmata/uno/status", "online")
+
+	m.Close()
+	waitRetained(t, broker, "firmata/uno/status", "offline")
+}
+
+func TestFilteredState(t *testing.T) {
+	broker, _, b, vb := startBridge(t)
+	waitRetained(t, broker, "firmata/uno/status", "online")
+
+	if err := b.SetFilters(14, bridge.Linear(0, 1000, 0, 10)); err != nil {
+		t.Fatal(err)
+	}
+	vb.SetAnalogInput(14, 250)
+	waitRetained(t, broker, "firmata/uno/pin/14/state", "2.5")
+}
+
+func TestEdges(t *testing.T) {
+	broker, _, b, vb := startBridge(t)
+	waitRetained(t, broker, "firmata/uno/status", "online")
+
+	edges := make(chan string, 10)
+	sub := mqtt.NewClient(mqtt.NewClientOptions().AddBroker(broker.URL()).SetClientID("listener"))
+	if token := sub.Connect(); token.Wait() && token.Error() != nil {
+		t.Fatal(token.Error())
+	}
+	defer sub.Disconnect(0)
+	token := sub.Subscribe("firmata/uno/pin/2/edge", 1, func(c mqtt.Client, msg mqtt.Message) {
+		edges <- string(msg.Payload())
+	})
+	if token.Wait() && token.Error() != nil {
+		t.Fatal(token.Error())
+	}
+
+	// the first report after the mode change has no edge
+	events, cancel := b.Subscribe(2)
+	defer cancel()
+	if err := b.SetMode(2, firmata.Input); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-events:
+	case <-time.After(time.Second):
+		t.Fatal("no report after the mode change")
+	}
+	vb.SetDigitalInput(2, true)
+	waitRetained(t, broker, "firmata/uno/pin/2/state", "1")
+	vb.SetDigitalInput(2, false)
+
+	for _, want := range []string{"rising", "falling"} {
+		select {
+		case got := <-edges:
+			if got != want {
+				t.Errorf("edge %q, want %q", got, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("no %v edge", want)
+		}
+	}
+	if r := broker.Retained("firmata/uno/pin/2/edge"); r != "" {
+		t.Errorf("edge retained as %q", r)
+	}
+}
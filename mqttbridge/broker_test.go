@@ -0,0 +1,253 @@
+// Copyright 2014 Krishna Raman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mqttbridge_test
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Minimal MQTT 3.1.1 broker for tests. Supports QoS 0 and 1 publishing,
+// retained messages, wildcard subscriptions and last wills.
+type testBroker struct {
+	listener net.Listener
+
+	lock     sync.Mutex
+	clients  map[*brokerClient]bool
+	retained map[string]string
+}
+
+type brokerClient struct {
+	conn      net.Conn
+	writeLock sync.Mutex
+	filters   []string
+	will      *brokerMessage
+}
+
+type brokerMessage struct {
+	topic   string
+	payload string
+	retain  bool
+}
+
+func startBroker(t *testing.T) *testBroker {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := &testBroker{listener: l, clients: make(map[*brokerClient]bool), retained: make(map[string]string)}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go b.serve(conn)
+		}
+	}()
+	t.Cleanup(func() { l.Close() })
+	return b
+}
+
+func (b *testBroker) URL() string {
+	return "tcp://" + b.listener.Addr().String()
+}
+
+// Get a retained message
+func (b *testBroker) Retained(topic string) string {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.retained[topic]
+}
+
+// Deliver a message to all matching subscribers
+func (b *testBroker) Publish(topic string, payload string, retain bool) {
+	b.lock.Lock()
+	if retain {
+		if payload == "" {
+			delete(b.retained, topic)
+		} else {
+			b.retained[topic] = payload
+		}
+	}
+	var targets []*brokerClient
+	for c := range b.clients {
+		for _, f := range c.filters {
+			if topicMatches(f, topic) {
+				targets = append(targets, c)
+				break
+			}
+		}
+	}
+	b.lock.Unlock()
+
+	for _, c := range targets {
+		c.publish(topic, payload, false)
+	}
+}
+
+func (b *testBroker) serve(conn net.Conn) {
+	c := &brokerClient{conn: conn}
+	r := bufio.NewReader(conn)
+	disconnected := false
+	defer func() {
+		conn.Close()
+		b.lock.Lock()
+		delete(b.clients, c)
+		b.lock.Unlock()
+		if !disconnected && c.will != nil {
+			b.Publish(c.will.topic, c.will.payload, c.will.retain)
+		}
+	}()
+
+	for {
+		header, err := r.ReadByte()
+		if err != nil {
+			return
+		}
+		length, err := readLength(r)
+		if err != nil {
+			return
+		}
+		body := make([]byte, length)
+		if _, err = io.ReadFull(r, body); err != nil {
+			return
+		}
+
+		switch header >> 4 {
+		case 1: // CONNECT
+			_, rest := readString(body)
+			flags := rest[1]
+			_, rest = readString(rest[4:])
+			if flags&0x04 != 0 {
+				c.will = &brokerMessage{retain: flags&0x20 != 0}
+				c.will.topic, rest = readString(rest)
+				c.will.payload, _ = readString(rest)
+			}
+			b.lock.Lock()
+			b.clients[c] = true
+			b.lock.Unlock()
+			c.send(0x20, []byte{0, 0})
+		case 3: // PUBLISH
+			topic, rest := readString(body)
+			if (header>>1)&0x03 > 0 {
+				c.send(0x40, rest[:2])
+				rest = rest[2:]
+			}
+			b.Publish(topic, string(rest), header&0x01 != 0)
+		case 8: // SUBSCRIBE
+			id, rest := body[:2], body[2:]
+			var filters []string
+			var granted []byte
+			for len(rest) > 0 {
+				var f string
+				f, rest = readString(rest)
+				rest = rest[1:]
+				filters = append(filters, f)
+				granted = append(granted, 0)
+			}
+			b.lock.Lock()
+			c.filters = append(c.filters, filters...)
+			var retained []brokerMessage
+			for topic, payload := range b.retained {
+				for _, f := range filters {
+					if topicMatches(f, topic) {
+						retained = append(retained, brokerMessage{topic, payload, true})
+						break
+					}
+				}
+			}
+			b.lock.Unlock()
+			c.send(0x90, append(append([]byte{}, id...), granted...))
+			for _, msg := range retained {
+				c.publish(msg.topic, msg.payload, true)
+			}
+		case 12: // PINGREQ
+			c.send(0xD0, nil)
+		case 14: // DISCONNECT
+			disconnected = true
+			return
+		}
+	}
+}
+
+func (c *brokerClient) publish(topic string, payload string, retain bool) {
+	header := byte(0x30)
+	if retain {
+		header |= 0x01
+	}
+	body := binary.BigEndian.AppendUint16(nil, uint16(len(topic)))
+	body = append(body, topic...)
+	c.send(header, append(body, payload...))
+}
+
+func (c *brokerClient) send(header byte, body []byte) {
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+	packet := append([]byte{header}, writeLength(len(body))...)
+	c.conn.Write(append(packet, body...))
+}
+
+func topicMatches(filter string, topic string) bool {
+	f := strings.Split(filter, "/")
+	t := strings.Split(topic, "/")
+	for i, level := range f {
+		if level == "#" {
+			return true
+		}
+		if i >= len(t) || (level != "+" && level != t[i]) {
+			return false
+		}
+	}
+	return len(f) == len(t)
+}
+
+func readLength(r *bufio.Reader) (n int, err error) {
+	for shift := 0; ; shift += 7 {
+		var b byte
+		if b, err = r.ReadByte(); err != nil {
+			return
+		}
+		n |= int(b&0x7F) << shift
+		if b&0x80 == 0 {
+			return
+		}
+	}
+}
+
+func writeLength(n int) (out []byte) {
+	for {
+		b := byte(n & 0x7F)
+		n >>= 7
+		if n > 0 {
+			b |= 0x80
+		}
+		out = append(out, b)
+		if n == 0 {
+			return
+		}
+	}
+}
+
+func readString(b []byte) (string, []byte) {
+	n := int(binary.BigEndian.Uint16(b))
+	return string(b[2 : 2+n]), b[2+n:]
+}
@@ -0,0 +1,283 @@
+// Copyright 2014 Krishna Raman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+type OneWireSubCommand byte
+
+// ROM address of a device on a OneWire bus
+type OneWireAddress [8]byte
+
+func (a OneWireAddress) String() string {
+	return fmt.Sprintf("%x", a[:])
+}
+
+// Family code of the device (0x28 for DS18B20)
+func (a OneWireAddress) Family() byte {
+	return a[0]
+}
+
+// A single OneWire bus transaction. The firmware executes the steps in order:
+// reset, select or skip, write, read and finally delay.
+type OneWireRequest struct {
+	Reset  bool
+	Skip   bool
+	Device *OneWireAddress
+	Write  []byte
+	// Number of bytes to read after writing
+	ReadCount int
+	// Time to wait after the write and read, such as for a temperature
+	// conversion, before the next request is processed
+	Delay time.Duration
+}
+
+type oneWireSearchKey struct {
+	pin byte
+	cmd OneWireSubCommand
+}
+
+// Configure pin as a OneWire bus. Set parasitePower if devices on the bus are
+// powered from the data line.
+func (c *FirmataClient) OneWireConfig(pin byte, parasitePower bool) (err error) {
+	power := byte(0x00)
+	if parasitePower {
+		power = 0x01
+	}
+	err = c.sendSysEx(OneWireData, byte(OneWireConfigRequest), pin&0x7F, power)
+	return
+}
+
+// Search the bus on pin and return the addresses of all devices found
+func (c *FirmataClient) OneWireSearch(ctx context.Context, pin byte) ([]OneWireAddress, error) {
+	return c.oneWireSearch(ctx, pin, OneWireSearchRequest, OneWireSearchReply)
+}
+
+// Search the bus on pin and return the addresses of devices in alarm state
+func (c *FirmataClient) OneWireSearchAlarms(ctx context.Context, pin byte) ([]OneWireAddress, error) {
+	return c.oneWireSearch(ctx, pin, OneWireSearchAlarmsRequest, OneWireSearchAlarmsReply)
+}
+
+// Send a reset pulse on the bus
+func (c *FirmataClient) OneWireReset(pin byte) error {
+	_, err := c.OneWireTransfer(context.Background(), pin, OneWireRequest{Reset: true})
+	return err
+}
+
+// Let the bus idle for the given time before processing further requests
+func (c *FirmataClient) OneWireDelay(pin byte, delay time.Duration) error {
+	_, err := c.OneWireTransfer(context.Background(), pin, OneWireRequest{Delay: delay})
+	return err
+}
+
+// Write data to device. All devices on the bus are addressed if device is nil.
+func (c *FirmataClient) OneWireWrite(pin byte, device *OneWireAddress, data []byte) error {
+	_, err := c.OneWireTransfer(context.Background(), pin,
+		OneWireRequest{Skip: device == nil, Device: device, Write: data})
+	return err
+}
+
+// Read count bytes from device
+func (c *FirmataClient) OneWireRead(ctx context.Context, pin byte, device OneWireAddress, count int) ([]byte, error) {
+	return c.OneWireTransfer(ctx, pin, OneWireRequest{Device: &device, ReadCount: count})
+}
+
+// Write data to device and read count bytes back in the same transaction
+func (c *FirmataClient) OneWireWriteRead(ctx context.Context, pin byte, device OneWireAddress, data []byte, count int) ([]byte, error) {
+	return c.OneWireTransfer(ctx, pin, OneWireRequest{Device: &device, Write: data, ReadCount: count})
+}
+
+// Execute a OneWire transaction on pin. When req.ReadCount is non-zero the
+// reply is matched to the request by a correlation id and the call waits
+// until it arrives or ctx is done.
+func (c *FirmataClient) OneWireTransfer(ctx context.Context, pin byte, req OneWireRequest) (data []byte, err error) {
+	if req.ReadCount < 0 || req.ReadCount > 0xFFFF {
+		err = fmt.Errorf("Invalid OneWire read count %v", req.ReadCount)
+		return
+	}
+
+	var subCmd OneWireSubCommand
+	if req.Reset {
+		subCmd |= OneWireResetRequest
+	}
+	if req.Skip {
+		subCmd |= OneWireSkipRequest
+	}
+	if req.Device != nil {
+		subCmd |= OneWireSelectRequest
+	}
+	if req.ReadCount > 0 {
+		subCmd |= OneWireReadRequest
+	}
+	if req.Delay > 0 {
+		subCmd |= OneWireDelayRequest
+	}
+	if len(req.Write) > 0 {
+		subCmd |= OneWireWriteRequest
+	}
+	if subCmd == 0 {
+		err = fmt.Errorf("Empty OneWire request")
+		return
+	}
+
+	var id uint16
+	var reply chan []byte
+	if req.ReadCount > 0 {
+		id, reply = c.newOneWireRead()
+		defer c.releaseOneWireRead(id)
+	}
+
+	payload := []byte{byte(subCmd), pin & 0x7F}
+	if subCmd&oneWireWithDataRequest != 0 {
+		// the firmware expects address, read count, correlation id and
+		// delay at fixed offsets followed by the data to write
+		args := make([]byte, 16, 16+len(req.Write))
+		if req.Device != nil {
+			copy(args[0:8], req.Device[:])
+		}
+		ms := uint32(req.Delay / time.Millisecond)
+		args[8], args[9] = byte(req.ReadCount), byte(req.ReadCount>>8)
+		args[10], args[11] = byte(id), byte(id>>8)
+		args[12], args[13], args[14], args[15] = byte(ms), byte(ms>>8), byte(ms>>16), byte(ms>>24)
+		args = append(args, req.Write...)
+		payload = append(payload, Pack7Bit(args)...)
+	}
+
+	if err = c.sendSysEx(OneWireData, payload...); err != nil || reply == nil {
+		return
+	}
+	defer c.observeQuery("onewire_read", time.Now(), &err)
+
+	select {
+	case data = <-reply:
+	case <-ctx.Done():
+		err = fmt.Errorf("OneWire read on pin %v: %w", pin, ctx.Err())
+	}
+	return
+}
+
+func (c *FirmataClient) oneWireSearch(ctx context.Context, pin byte, req OneWireSubCommand, rep OneWireSubCommand) (devices []OneWireAddress, err error) {
+	defer c.observeQuery("onewire_search", time.Now(), &err)
+
+	key := oneWireSearchKey{pin: pin, cmd: rep}
+	reply := make(chan []OneWireAddress, 1)
+
+	c.oneWireLock.Lock()
+	if c.oneWireSearches == nil {
+		c.oneWireSearches = make(map[oneWireSearchKey][]chan []OneWireAddress)
+	}
+	c.oneWireSearches[key] = append(c.oneWireSearches[key], reply)
+	c.oneWireLock.Unlock()
+
+	defer func() {
+		c.oneWireLock.Lock()
+		defer c.oneWireLock.Unlock()
+		waiters := c.oneWireSearches[key]
+		for i, w := range waiters {
+			if w == reply {
+				c.oneWireSearches[key] = append(waiters[:i:i], waiters[i+1:]...)
+				break
+			}
+		}
+	}()
+
+	if err = c.sendSysEx(OneWireData, byte(req), pin&0x7F); err != nil {
+		return
+	}
+
+	select {
+	case devices = <-reply:
+	case <-ctx.Done():
+		err = fmt.Errorf("OneWire search on pin %v: %w", pin, ctx.Err())
+	}
+	return
+}
+
+func (c *FirmataClient) newOneWireRead() (id uint16, reply chan []byte) {
+	c.oneWireLock.Lock()
+	defer c.oneWireLock.Unlock()
+
+	if c.oneWireReads == nil {
+		c.oneWireReads = make(map[uint16]chan []byte)
+	}
+	c.oneWireID++
+	id = c.oneWireID
+	reply = make(chan []byte, 1)
+	c.oneWireReads[id] = reply
+	return
+}
+
+func (c *FirmataClient) releaseOneWireRead(id uint16) {
+	c.oneWireLock.Lock()
+	defer c.oneWireLock.Unlock()
+	delete(c.oneWireReads, id)
+}
+
+func (c *FirmataClient) parseOneWireResponse(data []byte) {
+	if len(data) < 2 {
+		c.Log.Warn("Discarding short OneWire reply", "length", len(data))
+		c.metrics.ParseError(OneWireData)
+		return
+	}
+	subCmd := OneWireSubCommand(data[0])
+	pin := data[1]
+	decoded := Unpack7Bit(data[2:])
+
+	switch subCmd {
+	case OneWireSearchReply, OneWireSearchAlarmsReply:
+		devices := make([]OneWireAddress, 0)
+		for i := 0; i+8 <= len(decoded); i = i + 8 {
+			var addr OneWireAddress
+			copy(addr[:], decoded[i:i+8])
+			devices = append(devices, addr)
+		}
+
+		key := oneWireSearchKey{pin: pin, cmd: subCmd}
+		c.oneWireLock.Lock()
+		waiters := c.oneWireSearches[key]
+		delete(c.oneWireSearches, key)
+		c.oneWireLock.Unlock()
+
+		for _, w := range waiters {
+			w <- devices
+		}
+	case OneWireReadReply:
+		if len(decoded) < 2 {
+			c.Log.Warn("Discarding OneWire read reply without correlation id", "pin", pin)
+			c.metrics.ParseError(OneWireData)
+			return
+		}
+		id := uint16(decoded[0]) | uint16(decoded[1])<<8
+
+		c.oneWireLock.Lock()
+		reply := c.oneWireReads[id]
+		delete(c.oneWireReads, id)
+		c.oneWireLock.Unlock()
+
+		if reply == nil {
+			c.Log.Warn("Discarding OneWire read reply. No pending request", "id", id, "pin", pin)
+			c.metrics.DroppedEvent("onewire_reply")
+			return
+		}
+		reply <- decoded[2:]
+	default:
+		c.Log.Warn("Discarding unexpected OneWire reply", "subcommand", byte(subCmd), "pin", pin)
+		c.metrics.DiscardedBytes(len(data) + 3)
+	}
+}
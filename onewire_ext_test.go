@@ -0,0 +1,201 @@
+// Copyright 2014 Krishna Raman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+var (
+	testROM1 = OneWireAddress{0x28, 0xFF, 0x4C, 0x60, 0x91, 0x16, 0x04, 0xB3}
+	testROM2 = OneWireAddress{0x28, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07}
+)
+
+// OneWire request as the client sends it
+func oneWireMessage(subCmd OneWireSubCommand, pin byte, args []byte) []byte {
+	msg := []byte{byte(StartSysEx), byte(OneWireData), byte(subCmd), pin}
+	if args != nil {
+		msg = append(msg, Pack7Bit(args)...)
+	}
+	return append(msg, byte(EndSysEx))
+}
+
+// OneWire reply payload as sent by the firmware
+func oneWireReply(subCmd OneWireSubCommand, pin byte, data []byte) []byte {
+	return append([]byte{byte(subCmd), pin}, Pack7Bit(data)...)
+}
+
+func TestOneWireSearchReplies(t *testing.T) {
+	c, rc := newOfflineClient()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	type result struct {
+		devices []OneWireAddress
+		err     error
+	}
+	search := make(chan result, 1)
+	alarms := make(chan result, 1)
+	go func() {
+		devices, err := c.OneWireSearch(ctx, 4)
+		search <- result{devices, err}
+	}()
+	rc.waitSent(t, 5)
+	go func() {
+		devices, err := c.OneWireSearchAlarms(ctx, 4)
+		alarms <- result{devices, err}
+	}()
+	sent := rc.waitSent(t, 10)
+	want := []byte{byte(StartSysEx), byte(OneWireData), byte(OneWireSearchRequest), 4, byte(EndSysEx),
+		byte(StartSysEx), byte(OneWireData), byte(OneWireSearchAlarmsRequest), 4, byte(EndSysEx)}
+	if !bytes.Equal(sent, want) {
+		t.Fatalf("sent %x, want %x", sent, want)
+	}
+
+	// replies for another pin or the other search are not taken
+	c.parseOneWireResponse(oneWireReply(OneWireSearchReply, 5, testROM1[:]))
+	c.parseOneWireResponse(oneWireReply(OneWireSearchAlarmsReply, 4, testROM2[:]))
+	c.parseOneWireResponse(oneWireReply(OneWireSearchReply, 4, append(testROM1[:], testROM2[:]...)))
+
+	res := <-search
+	if res.err != nil || len(res.devices) != 2 || res.devices[0] != testROM1 || res.devices[1] != testROM2 {
+		t.Errorf("search returned %v, %v", res.devices, res.err)
+	}
+	if res.devices[0].Family() != 0x28 {
+		t.Errorf("family %#x", res.devices[0].Family())
+	}
+	res = <-alarms
+	if res.err != nil || len(res.devices) != 1 || res.devices[0] != testROM2 {
+		t.Errorf("alarm search returned %v, %v", res.devices, res.err)
+	}
+}
+
+func TestOneWireRequestEncoding(t *testing.T) {
+	c, rc := newOfflineClient()
+
+	if err := c.OneWireReset(4); err != nil {
+		t.Fatal(err)
+	}
+	want := oneWireMessage(OneWireResetRequest, 4, nil)
+
+	if err := c.OneWireWrite(4, nil, []byte{0x44}); err != nil {
+		t.Fatal(err)
+	}
+	args := make([]byte, 16)
+	want = append(want, oneWireMessage(OneWireSkipRequest|OneWireWriteRequest, 4, append(args, 0x44))...)
+
+	rom := testROM1
+	if err := c.OneWireWrite(4, &rom, []byte{0xBE}); err != nil {
+		t.Fatal(err)
+	}
+	args = make([]byte, 16)
+	copy(args, rom[:])
+	want = append(want, oneWireMessage(OneWireSelectRequest|OneWireWriteRequest, 4, append(args, 0xBE))...)
+
+	if err := c.OneWireDelay(4, 750*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	args = make([]byte, 16)
+	args[12], args[13] = 0xEE, 0x02
+	want = append(want, oneWireMessage(OneWireDelayRequest, 4, args)...)
+
+	if sent := rc.Sent(); !bytes.Equal(sent, want) {
+		t.Fatalf("sent\n%x\nwant\n%x", sent, want)
+	}
+
+	// reads carry the device, read count and correlation id
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	c.OneWireWriteRead(ctx, 4, rom, []byte{0xBE}, 9)
+	args = make([]byte, 16)
+	copy(args, rom[:])
+	args[8], args[10] = 9, 1
+	read := oneWireMessage(OneWireSelectRequest|OneWireReadRequest|OneWireWriteRequest, 4, append(args, 0xBE))
+	if sent := rc.Sent()[len(want):]; !bytes.Equal(sent, read) {
+		t.Errorf("read sent %x, want %x", sent, read)
+	}
+
+	if _, err := c.OneWireTransfer(context.Background(), 4, OneWireRequest{}); err == nil {
+		t.Error("empty request accepted")
+	}
+	if _, err := c.OneWireRead(context.Background(), 4, rom, 0x10000); err == nil {
+		t.Error("read count above 65535 accepted")
+	}
+}
+
+func TestOneWireReadCorrelation(t *testing.T) {
+	c, _ := newOfflineClient()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	type result struct {
+		data []byte
+		err  error
+	}
+	first := make(chan result, 1)
+	second := make(chan result, 1)
+	go func() {
+		data, err := c.OneWireRead(ctx, 4, testROM1, 2)
+		first <- result{data, err}
+	}()
+	for len(c.pendingOneWireReads()) < 1 {
+		time.Sleep(time.Millisecond)
+	}
+	go func() {
+		data, err := c.OneWireRead(ctx, 4, testROM2, 2)
+		second <- result{data, err}
+	}()
+	for len(c.pendingOneWireReads()) < 2 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// replies arrive out of order and are matched by id
+	c.parseOneWireResponse(oneWireReply(OneWireReadReply, 4, []byte{2, 0, 0xBB, 0xBB}))
+	c.parseOneWireResponse(oneWireReply(OneWireReadReply, 4, []byte{1, 0, 0xAA, 0xAA}))
+	if res := <-first; res.err != nil || !bytes.Equal(res.data, []byte{0xAA, 0xAA}) {
+		t.Errorf("first read returned %x, %v", res.data, res.err)
+	}
+	if res := <-second; res.err != nil || !bytes.Equal(res.data, []byte{0xBB, 0xBB}) {
+		t.Errorf("second read returned %x, %v", res.data, res.err)
+	}
+}
+
+func TestOneWireReadCancelled(t *testing.T) {
+	c, _ := newOfflineClient()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := c.OneWireRead(ctx, 4, testROM1, 2); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("read returned %v", err)
+	}
+	if pending := c.pendingOneWireReads(); len(pending) != 0 {
+		t.Fatalf("reads %v still pending", pending)
+	}
+	// the late reply is discarded
+	c.parseOneWireResponse(oneWireReply(OneWireReadReply, 4, []byte{1, 0, 0xAA, 0xAA}))
+}
+
+func (c *FirmataClient) pendingOneWireReads() (ids []uint16) {
+	c.oneWireLock.Lock()
+	defer c.oneWireLock.Unlock()
+	for id := range c.oneWireReads {
+		ids = append(ids, id)
+	}
+	return
+}
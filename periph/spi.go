@@ -0,0 +1,92 @@
+// Copyright 2014 Krishna Raman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Adapters exposing go-firmata devices through periph.io interfaces
+package periph
+
+import (
+	"context"
+	"fmt"
+	"github.com/kraman/go-firmata"
+	"periph.io/x/conn/v3"
+	"periph.io/x/conn/v3/spi"
+	"time"
+)
+
+// periph.io spi.Conn backed by a Firmata SPI device
+type SPIConn struct {
+	dev     *firmata.SPIDevice
+	timeout time.Duration
+}
+
+// Wraps a SPIDevice as a periph.io spi.Conn. Each transfer waits at most
+// timeout for the board to reply.
+func NewSPIConn(dev *firmata.SPIDevice, timeout time.Duration) *SPIConn {
+	return &SPIConn{dev: dev, timeout: timeout}
+}
+
+func (s *SPIConn) String() string {
+	return s.dev.String()
+}
+
+// SPI transfers through Firmata are always full duplex
+func (s *SPIConn) Duplex() conn.Duplex {
+	return conn.Full
+}
+
+func (s *SPIConn) Tx(w, r []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+	return s.dev.Tx(ctx, w, r)
+}
+
+// The firmware toggles chip-select around every transfer, so consecutive
+// packets with KeepCS set are merged into a single transfer.
+func (s *SPIConn) TxPackets(p []spi.Packet) error {
+	start := 0
+	for i := range p {
+		if p[i].BitsPerWord != 0 && p[i].BitsPerWord != 8 {
+			return fmt.Errorf("Unsupported bits per word %v", p[i].BitsPerWord)
+		}
+		if p[i].R != nil && len(p[i].R) != len(p[i].W) {
+			return fmt.Errorf("Packet %v read length %v does not match write length %v", i, len(p[i].R), len(p[i].W))
+		}
+		if p[i].KeepCS && i != len(p)-1 {
+			continue
+		}
+		if err := s.txGroup(p[start : i+1]); err != nil {
+			return err
+		}
+		start = i + 1
+	}
+	return nil
+}
+
+func (s *SPIConn) txGroup(p []spi.Packet) error {
+	var w []byte
+	for _, pkt := range p {
+		w = append(w, pkt.W...)
+	}
+	r := make([]byte, len(w))
+	if err := s.Tx(w, r); err != nil {
+		return err
+	}
+	for _, pkt := range p {
+		copy(pkt.R, r[:len(pkt.W)])
+		r = r[len(pkt.W):]
+	}
+	return nil
+}
+
+var _ spi.Conn = &SPIConn{}
@@ -0,0 +1,108 @@
+// Copyright 2014 Krishna Raman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package periph
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kraman/go-firmata"
+	"github.com/kraman/go-firmata/virtual"
+	"periph.io/x/conn/v3/spi"
+)
+
+// SPI connection to a virtual board recording the transfers it sees
+func newTestConn(t *testing.T) (*SPIConn, func() [][]byte) {
+	t.Helper()
+	board := virtual.New(virtual.ArduinoUno())
+	client, err := firmata.NewClientWithTransport(board.Transport())
+	if err != nil {
+		board.Close()
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		client.Close()
+		board.Close()
+	})
+
+	var lock sync.Mutex
+	var transfers [][]byte
+	board.SetSPIHandler(func(csPin byte, data []byte) []byte {
+		lock.Lock()
+		defer lock.Unlock()
+		transfers = append(transfers, append([]byte(nil), data...))
+		out := make([]byte, len(data))
+		for i, b := range data {
+			out[i] = ^b
+		}
+		return out
+	})
+
+	dev, err := client.NewSPIDevice(10, firmata.SPI_MODE0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewSPIConn(dev, time.Second), func() [][]byte {
+		lock.Lock()
+		defer lock.Unlock()
+		return transfers
+	}
+}
+
+func TestTxPackets(t *testing.T) {
+	s, transfers := newTestConn(t)
+
+	r1, r2, r3 := make([]byte, 1), make([]byte, 2), make([]byte, 1)
+	err := s.TxPackets([]spi.Packet{
+		{W: []byte{0x01}, R: r1, KeepCS: true},
+		{W: []byte{0x02, 0x03}, R: r2},
+		{W: []byte{0x04}, R: r3},
+		{W: []byte{0x05}, KeepCS: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// packets with KeepCS are merged with the following one; a trailing
+	// KeepCS packet is sent on its own
+	want := [][]byte{{0x01, 0x02, 0x03}, {0x04}, {0x05}}
+	got := transfers()
+	if len(got) != len(want) {
+		t.Fatalf("transfers %x, want %x", got, want)
+	}
+	for i := range want {
+		if !bytes.Equal(got[i], want[i]) {
+			t.Errorf("transfer %v is %x, want %x", i, got[i], want[i])
+		}
+	}
+	if r1[0] != 0xFE || !bytes.Equal(r2, []byte{0xFD, 0xFC}) || r3[0] != 0xFB {
+		t.Errorf("read %x %x %x", r1, r2, r3)
+	}
+}
+
+func TestTxPacketsRejects(t *testing.T) {
+	s, transfers := newTestConn(t)
+	if err := s.TxPackets([]spi.Packet{{W: []byte{1}, BitsPerWord: 16}}); err == nil {
+		t.Error("16 bits per word accepted")
+	}
+	if err := s.TxPackets([]spi.Packet{{W: []byte{1, 2}, R: make([]byte, 1)}}); err == nil {
+		t.Error("short read buffer accepted")
+	}
+	if got := transfers(); len(got) != 0 {
+		t.Errorf("rejected packets sent %x", got)
+	}
+}
@@ -0,0 +1,149 @@
+// Copyright 2014 Krishna Raman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Prometheus exporter for go-firmata link metrics.
+//
+// Usage:
+//
+//	col := prommetrics.New("bench-1")
+//	prometheus.MustRegister(col)
+//	arduino, err := firmata.NewClient("COM1", 57600, firmata.WithMetrics(col))
+package prommetrics
+
+import (
+	"github.com/kraman/go-firmata"
+	"github.com/prometheus/client_golang/prometheus"
+	"time"
+)
+
+const namespace = "firmata"
+
+// Prometheus collector implementing firmata.MetricsCollector
+type Collector struct {
+	bytes       *prometheus.CounterVec
+	messages    *prometheus.CounterVec
+	parseErrors *prometheus.CounterVec
+	discarded   prometheus.Counter
+	dropped     *prometheus.CounterVec
+	queries     *prometheus.HistogramVec
+	resets      prometheus.Counter
+}
+
+// Create a collector. All metrics carry a board label set to board so that
+// several clients can share a registry.
+func New(board string) *Collector {
+	labels := prometheus.Labels{"board": board}
+	return &Collector{
+		bytes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Name: "bytes_total", ConstLabels: labels,
+			Help: "Bytes transferred over the Firmata link.",
+		}, []string{"direction"}),
+		messages: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Name: "messages_total", ConstLabels: labels,
+			Help: "Firmata messages by direction, type and command.",
+		}, []string{"direction", "type", "command"}),
+		parseErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Name: "parse_errors_total", ConstLabels: labels,
+			Help: "Malformed replies received from the board.",
+		}, []string{"command"}),
+		discarded: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace, Name: "discarded_bytes_total", ConstLabels: labels,
+			Help: "Received bytes that were not part of a known message.",
+		}),
+		dropped: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Name: "dropped_events_total", ConstLabels: labels,
+			Help: "Events and replies dropped because no one was listening.",
+		}, []string{"kind"}),
+		queries: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace, Name: "query_duration_seconds", ConstLabels: labels,
+			Help:    "Time from sending a query to receiving its reply.",
+			Buckets: prometheus.ExponentialBuckets(0.001, 2, 14),
+		}, []string{"query", "result"}),
+		resets: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace, Name: "handshake_resets_total", ConstLabels: labels,
+			Help: "Board resets while waiting for the handshake.",
+		}),
+	}
+}
+
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	c.bytes.Describe(ch)
+	c.messages.Describe(ch)
+	c.parseErrors.Describe(ch)
+	c.discarded.Describe(ch)
+	c.dropped.Describe(ch)
+	c.queries.Describe(ch)
+	c.resets.Describe(ch)
+}
+
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.bytes.Collect(ch)
+	c.messages.Collect(ch)
+	c.parseErrors.Collect(ch)
+	c.discarded.Collect(ch)
+	c.dropped.Collect(ch)
+	c.queries.Collect(ch)
+	c.resets.Collect(ch)
+}
+
+func (c *Collector) BytesIn(n int) {
+	c.bytes.WithLabelValues("in").Add(float64(n))
+}
+
+func (c *Collector) BytesOut(n int) {
+	c.bytes.WithLabelValues("out").Add(float64(n))
+}
+
+func (c *Collector) MessageIn(cmd firmata.FirmataCommand) {
+	c.messages.WithLabelValues("in", "command", cmd.String()).Inc()
+}
+
+func (c *Collector) MessageOut(cmd firmata.FirmataCommand) {
+	c.messages.WithLabelValues("out", "command", cmd.String()).Inc()
+}
+
+func (c *Collector) SysExIn(cmd firmata.SysExCommand) {
+	c.messages.WithLabelValues("in", "sysex", cmd.String()).Inc()
+}
+
+func (c *Collector) SysExOut(cmd firmata.SysExCommand) {
+	c.messages.WithLabelValues("out", "sysex", cmd.String()).Inc()
+}
+
+func (c *Collector) ParseError(cmd firmata.SysExCommand) {
+	c.parseErrors.WithLabelValues(cmd.String()).Inc()
+}
+
+func (c *Collector) DiscardedBytes(n int) {
+	c.discarded.Add(float64(n))
+}
+
+func (c *Collector) DroppedEvent(kind string) {
+	c.dropped.WithLabelValues(kind).Inc()
+}
+
+func (c *Collector) QueryLatency(query string, d time.Duration, err error) {
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+	c.queries.WithLabelValues(query, result).Observe(d.Seconds())
+}
+
+func (c *Collector) HandshakeReset() {
+	c.resets.Inc()
+}
+
+var _ firmata.MetricsCollector = &Collector{}
+var _ prometheus.Collector = &Collector{}
@@ -0,0 +1,186 @@
+// Copyright 2014 Krishna Raman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prommetrics
+
+import (
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/kraman/go-firmata"
+	"github.com/kraman/go-firmata/virtual"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// Transport counting the bytes passed through it, with a way to slip extra
+// replies in between the ones of the board
+type tapConn struct {
+	io.ReadWriteCloser
+	r *io.PipeReader
+	w *io.PipeWriter
+
+	lock sync.Mutex
+	in   int
+	out  int
+}
+
+func newTapConn(conn io.ReadWriteCloser) *tapConn {
+	t := &tapConn{ReadWriteCloser: conn}
+	t.r, t.w = io.Pipe()
+	go func() {
+		_, err := io.Copy(t.w, conn)
+		t.w.CloseWithError(err)
+	}()
+	return t
+}
+
+func (t *tapConn) Read(p []byte) (n int, err error) {
+	n, err = t.r.Read(p)
+	t.lock.Lock()
+	t.in += n
+	t.lock.Unlock()
+	return
+}
+
+func (t *tapConn) Write(p []byte) (n int, err error) {
+	n, err = t.ReadWriteCloser.Write(p)
+	t.lock.Lock()
+	t.out += n
+	t.lock.Unlock()
+	return
+}
+
+func (t *tapConn) inject(reply []byte) {
+	t.w.Write(reply)
+}
+
+func (t *tapConn) counts() (in int, out int) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.in, t.out
+}
+
+func newTestClient(t *testing.T) (*firmata.FirmataClient, *Collector, *tapConn) {
+	t.Helper()
+	col := New("test")
+	board := virtual.New(virtual.ArduinoUno())
+	conn := newTapConn(board.Transport())
+	c, err := firmata.NewClientWithTransport(conn, firmata.WithMetrics(col))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		c.Close()
+		board.Close()
+	})
+	return c, col, conn
+}
+
+func histogramCount(t *testing.T, col *Collector, query string, result string) uint64 {
+	t.Helper()
+	var m dto.Metric
+	if err := col.queries.WithLabelValues(query, result).(interface{ Write(*dto.Metric) error }).Write(&m); err != nil {
+		t.Fatal(err)
+	}
+	return m.GetHistogram().GetSampleCount()
+}
+
+func TestCollectorBytes(t *testing.T) {
+	c, col, conn := newTestClient(t)
+	if _, _, err := c.QueryVersion(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	in, out := conn.counts()
+	if got := testutil.ToFloat64(col.bytes.WithLabelValues("in")); got != float64(in) {
+		t.Errorf("bytes in = %v, transport read %v", got, in)
+	}
+	if got := testutil.ToFloat64(col.bytes.WithLabelValues("out")); got != float64(out) {
+		t.Errorf("bytes out = %v, transport wrote %v", got, out)
+	}
+	if in == 0 || out == 0 {
+		t.Errorf("no traffic counted: %v in, %v out", in, out)
+	}
+}
+
+func TestCollectorMessages(t *testing.T) {
+	c, col, _ := newTestClient(t)
+	versions := testutil.ToFloat64(col.messages.WithLabelValues("in", "command", firmata.ReportVersion.String()))
+	firmware := testutil.ToFloat64(col.messages.WithLabelValues("out", "sysex", firmata.ReportFirmware.String()))
+
+	if _, _, err := c.QueryVersion(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, _, err := c.QueryFirmware(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := testutil.ToFloat64(col.messages.WithLabelValues("in", "command", firmata.ReportVersion.String())); got != versions+1 {
+		t.Errorf("%v version reports counted, want %v", got, versions+1)
+	}
+	if got := testutil.ToFloat64(col.messages.WithLabelValues("out", "sysex", firmata.ReportFirmware.String())); got != firmware+1 {
+		t.Errorf("%v firmware queries counted, want %v", got, firmware+1)
+	}
+}
+
+func TestCollectorErrors(t *testing.T) {
+	c, col, conn := newTestClient(t)
+
+	// a short I2C reply and a stray data byte, followed by a reply to
+	// wait for so that both have been read
+	conn.inject([]byte{byte(firmata.StartSysEx), byte(firmata.I2CReply), 0x01, byte(firmata.EndSysEx)})
+	conn.inject([]byte{0x0F})
+	if _, _, err := c.QueryVersion(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `
+# HELP firmata_parse_errors_total Malformed replies received from the board.
+# TYPE firmata_parse_errors_total counter
+firmata_parse_errors_total{board="test",command="I2CReply (0x77)"} 1
+# HELP firmata_discarded_bytes_total Received bytes that were not part of a known message.
+# TYPE firmata_discarded_bytes_total counter
+firmata_discarded_bytes_total{board="test"} 1
+`
+	if err := testutil.CollectAndCompare(col, strings.NewReader(expected),
+		"firmata_parse_errors_total", "firmata_discarded_bytes_total"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCollectorLatency(t *testing.T) {
+	c, col, _ := newTestClient(t)
+	if _, _, err := c.QueryVersion(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, _, err := c.QueryVersion(ctx); err == nil {
+		t.Fatal("version query on cancelled context succeeded")
+	}
+
+	if n := histogramCount(t, col, "version", "ok"); n != 1 {
+		t.Errorf("%v successful version queries observed", n)
+	}
+	if n := histogramCount(t, col, "version", "error"); n != 1 {
+		t.Errorf("%v failed version queries observed", n)
+	}
+	if n := testutil.CollectAndCount(col, "firmata_query_duration_seconds"); n != 2 {
+		t.Errorf("%v query series collected, want 2", n)
+	}
+}
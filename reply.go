@@ -60,30 +60,32 @@ func (v FirmataValue) String() string {
 		p, v, _ := v.GetAnalogValue()
 		return fmt.Sprintf("Analog value %v = %v", p, v)
 	} else {
-		p, v, _ := v.GetAnalogValue()
-		return fmt.Sprintf("Digital port %v = %b", p, v)
+		return fmt.Sprintf("Digital port %v = %08b", byte(v.valueType & ^DigitalMessage), v.value)
 	}
 }
 
 func (c *FirmataClient) replyReader() {
-	r := bufio.NewReader(*c.conn)
-	c.valueChan = make(chan FirmataValue)
+	defer close(c.readerDone)
+	defer close(c.valueChan)
+	defer close(c.serialChan)
+	r := bufio.NewReader(countingReader{*c.conn, c.metrics})
 	var init bool
 	for {
 		b, err := (r.ReadByte())
 		if err != nil {
-			c.Log.Print(err)
+			c.Log.Error("Reading from board failed", "error", err)
 			return
 		}
 		cmd := FirmataCommand(b)
 		if c.Verbose {
-			c.Log.Printf("Incoming cmd %v", cmd)
+			c.Log.Debug("Incoming command", "command", cmd)
 		}
 		if !init {
 			if cmd != ReportVersion {
 				if c.Verbose {
-					c.Log.Printf("Discarding unexpected command byte %0d (not initialized)\n", b)
+					c.Log.Debug("Discarding unexpected command byte (not initialized)", "byte", b)
 				}
+				c.metrics.DiscardedBytes(1)
 				continue
 			} else {
 				init = true
@@ -92,29 +94,47 @@ func (c *FirmataClient) replyReader() {
 
 		switch {
 		case cmd == ReportVersion:
-			c.protocolVersion = make([]byte, 2)
-			c.protocolVersion[0], err = r.ReadByte()
-			c.protocolVersion[1], err = r.ReadByte()
-			c.Log.Printf("Protocol version: %d.%d", c.protocolVersion[0], c.protocolVersion[1])
+			c.metrics.MessageIn(cmd)
+			var major, minor byte
+			major, err = r.ReadByte()
+			if err == nil {
+				minor, err = r.ReadByte()
+			}
+			if err == nil {
+				c.setProtocolVersion(major, minor)
+				c.Log.Info("Protocol version", "major", major, "minor", minor)
+			}
 		case cmd == StartSysEx:
 			var sysExData []byte
+			// counted by type in parseSysEx
 			sysExData, err = r.ReadSlice(byte(EndSysEx))
-			if err == nil {
+			if err == nil && len(sysExData) < 2 {
+				c.Log.Warn("Discarding empty SysEx message")
+				c.metrics.DiscardedBytes(2)
+			} else if err == nil {
 				c.parseSysEx(sysExData[0 : len(sysExData)-1])
 			}
 		case (cmd&DigitalMessage) > 0 || byte(cmd&AnalogMessage) > 0:
 			b1, _ := r.ReadByte()
 			b2, _ := r.ReadByte()
+			c.metrics.MessageIn(messageType(cmd))
+			if (cmd & 0xF0) == DigitalMessage {
+				c.digitalLock.Lock()
+				c.digitalPortValues[cmd&0x0F] = from7Bit(b1, b2)
+				c.digitalLock.Unlock()
+			}
 			select {
-			case c.valueChan <- FirmataValue{cmd, int(from7Bit(b1, b2)), c.analogChannelPinsMap}:
+			// analog values are 14 bits wide, digital port values 8
+			case c.valueChan <- FirmataValue{cmd, int(b1&0x7F) | int(b2&0x7F)<<7, c.analogChannelPinsMap}:
 			}
 		default:
 			if c.Verbose {
-				c.Log.Printf("Discarding unexpected command byte %0d\n", b)
+				c.Log.Debug("Discarding unexpected command byte", "byte", b)
 			}
+			c.metrics.DiscardedBytes(1)
 		}
 		if err != nil {
-			c.Log.Print(err)
+			c.Log.Error("Reading from board failed", "error", err)
 			return
 		}
 	}
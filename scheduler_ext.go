@@ -0,0 +1,291 @@
+// Copyright 2014 Krishna Raman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"time"
+)
+
+type SchedulerSubCommand byte
+
+// Max bytes of task data sent in a single add-to-task message
+const taskChunkSize = 48
+
+// A sequence of Firmata messages run by the board's scheduler.
+// Messages are recorded by calling ordinary FirmataClient methods on the
+// client returned by Client(). Only write-style calls can be recorded; calls
+// that wait for a reply from the board will not complete.
+type Task struct {
+	ID     byte
+	buf    taskRecorder
+	client *FirmataClient
+}
+
+// State of a task reported by the board
+type TaskInfo struct {
+	ID byte
+	// Time in ms since board start when the task next runs
+	Time     uint32
+	Length   int
+	Position int
+	Data     []byte
+}
+
+type taskRecorder struct {
+	bytes.Buffer
+}
+
+func (r *taskRecorder) Close() error {
+	return nil
+}
+
+// Start recording a new task. Task ids range from 0 to 127.
+func (c *FirmataClient) NewTask(id byte) *Task {
+	t := &Task{ID: id & 0x7F}
+	var conn io.ReadWriteCloser = &t.buf
+
+	c.digitalLock.Lock()
+	pinState := c.digitalPinState
+	c.digitalLock.Unlock()
+
+	t.client = &FirmataClient{
+		conn:                 &conn,
+		Log:                  c.Log,
+		metrics:              noopMetrics{},
+		Verbose:              c.Verbose,
+		protocolVersion:      c.protocolVersion,
+		digitalPinState:      pinState,
+		analogPinsChannelMap: c.analogPinsChannelMap,
+		analogChannelPinsMap: c.analogChannelPinsMap,
+		pinModes:             c.pinModes,
+	}
+	return t
+}
+
+// Client whose commands are appended to the task instead of being sent
+func (t *Task) Client() *FirmataClient {
+	return t.client
+}
+
+// Pause the task for d when it runs
+func (t *Task) Delay(d time.Duration) error {
+	data := append([]byte{byte(SchedulerDelayTask)}, Pack7Bit(uint32Bytes(uint32(d/time.Millisecond)))...)
+	return t.client.sendSysEx(SchedulerData, data...)
+}
+
+// Recorded task data
+func (t *Task) Bytes() []byte {
+	return t.buf.Bytes()
+}
+
+// Create the task on the board and upload its recorded messages
+func (c *FirmataClient) UploadTask(t *Task) (err error) {
+	data := t.Bytes()
+	if len(data) > 0x3FFF {
+		err = fmt.Errorf("Task %v too long (%v bytes)", t.ID, len(data))
+		return
+	}
+	length := int14to7Bit(len(data))
+	if err = c.sendScheduler(byte(SchedulerCreateTask), t.ID, length[0], length[1]); err != nil {
+		return
+	}
+	for len(data) > 0 {
+		n := len(data)
+		if n > taskChunkSize {
+			n = taskChunkSize
+		}
+		chunk := append([]byte{byte(SchedulerAddToTask), t.ID}, Pack7Bit(data[:n])...)
+		if err = c.sendScheduler(chunk...); err != nil {
+			return
+		}
+		data = data[n:]
+	}
+	return
+}
+
+// Run a task once after delay. A task can reschedule itself with Task.Delay.
+func (c *FirmataClient) ScheduleTask(id byte, delay time.Duration) error {
+	data := append([]byte{byte(SchedulerScheduleTask), id & 0x7F}, Pack7Bit(uint32Bytes(uint32(delay/time.Millisecond)))...)
+	return c.sendScheduler(data...)
+}
+
+// Delete a task from the board
+func (c *FirmataClient) DeleteTask(id byte) error {
+	return c.sendScheduler(byte(SchedulerDeleteTask), id&0x7F)
+}
+
+// Delete all tasks from the board
+func (c *FirmataClient) ResetScheduler() error {
+	return c.sendScheduler(byte(SchedulerReset))
+}
+
+// Get the ids of all tasks on the board
+func (c *FirmataClient) QueryTasks(ctx context.Context) (ids []byte, err error) {
+	defer c.observeQuery("tasks", time.Now(), &err)
+	reply := make(chan []byte, 1)
+
+	c.schedulerLock.Lock()
+	c.taskListQueries = append(c.taskListQueries, reply)
+	c.schedulerLock.Unlock()
+
+	defer func() {
+		c.schedulerLock.Lock()
+		defer c.schedulerLock.Unlock()
+		for i, w := range c.taskListQueries {
+			if w == reply {
+				c.taskListQueries = append(c.taskListQueries[:i:i], c.taskListQueries[i+1:]...)
+				break
+			}
+		}
+	}()
+
+	if err = c.sendScheduler(byte(SchedulerQueryAllTasks)); err != nil {
+		return
+	}
+
+	select {
+	case ids = <-reply:
+	case <-ctx.Done():
+		err = fmt.Errorf("Task list query: %w", ctx.Err())
+	}
+	return
+}
+
+// Get the state of a task on the board. Returns a nil TaskInfo if the task
+// does not exist.
+func (c *FirmataClient) QueryTask(ctx context.Context, id byte) (info *TaskInfo, err error) {
+	defer c.observeQuery("task", time.Now(), &err)
+	id = id & 0x7F
+	reply := make(chan *TaskInfo, 1)
+
+	c.schedulerLock.Lock()
+	if c.taskQueries == nil {
+		c.taskQueries = make(map[byte][]chan *TaskInfo)
+	}
+	c.taskQueries[id] = append(c.taskQueries[id], reply)
+	c.schedulerLock.Unlock()
+
+	defer func() {
+		c.schedulerLock.Lock()
+		defer c.schedulerLock.Unlock()
+		waiters := c.taskQueries[id]
+		for i, w := range waiters {
+			if w == reply {
+				c.taskQueries[id] = append(waiters[:i:i], waiters[i+1:]...)
+				break
+			}
+		}
+		if len(c.taskQueries[id]) == 0 {
+			delete(c.taskQueries, id)
+		}
+	}()
+
+	if err = c.sendScheduler(byte(SchedulerQueryTask), id); err != nil {
+		return
+	}
+
+	select {
+	case info = <-reply:
+	case <-ctx.Done():
+		err = fmt.Errorf("Task %v query: %w", id, ctx.Err())
+	}
+	return
+}
+
+func (c *FirmataClient) sendScheduler(data ...byte) error {
+	if err := c.requireFeature(FeatureScheduler); err != nil {
+		return err
+	}
+	return c.sendSysEx(SchedulerData, data...)
+}
+
+func (c *FirmataClient) parseSchedulerResponse(data []byte) {
+	if len(data) < 1 {
+		c.Log.Warn("Discarding empty scheduler reply")
+		c.metrics.ParseError(SchedulerData)
+		return
+	}
+	cmd := SchedulerSubCommand(data[0])
+	data = data[1:]
+
+	switch cmd {
+	case SchedulerQueryAllTasksReply:
+		ids := append([]byte{}, data...)
+
+		c.schedulerLock.Lock()
+		queries := c.taskListQueries
+		c.taskListQueries = nil
+		c.schedulerLock.Unlock()
+
+		for _, q := range queries {
+			q <- ids
+		}
+	case SchedulerQueryTaskReply, SchedulerErrorTaskReply:
+		if len(data) < 1 {
+			c.Log.Warn("Discarding scheduler reply without task id")
+			c.metrics.ParseError(SchedulerData)
+			return
+		}
+		info := parseTaskInfo(data[0], data[1:])
+
+		if cmd == SchedulerErrorTaskReply {
+			if info != nil {
+				c.Log.Error("Task failed", "task", data[0], "position", info.Position)
+			} else {
+				c.Log.Error("Task failed", "task", data[0])
+			}
+			return
+		}
+
+		c.schedulerLock.Lock()
+		queries := c.taskQueries[data[0]]
+		delete(c.taskQueries, data[0])
+		c.schedulerLock.Unlock()
+
+		for _, q := range queries {
+			q <- info
+		}
+	default:
+		c.Log.Warn("Discarding unexpected scheduler reply", "subcommand", byte(cmd))
+		c.metrics.DiscardedBytes(len(data) + 4)
+	}
+}
+
+// A query for a task that does not exist is answered with the task id only
+func parseTaskInfo(id byte, data7bit []byte) *TaskInfo {
+	data := Unpack7Bit(data7bit)
+	if len(data) < 8 {
+		return nil
+	}
+	info := &TaskInfo{
+		ID:       id,
+		Time:     uint32(data[0]) | uint32(data[1])<<8 | uint32(data[2])<<16 | uint32(data[3])<<24,
+		Length:   int(data[4]) | int(data[5])<<8,
+		Position: int(data[6]) | int(data[7])<<8,
+		Data:     data[8:],
+	}
+	if len(info.Data) > info.Length {
+		info.Data = info.Data[:info.Length]
+	}
+	return info
+}
+
+func uint32Bytes(i uint32) []byte {
+	return []byte{byte(i), byte(i >> 8), byte(i >> 16), byte(i >> 24)}
+}
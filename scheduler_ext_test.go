@@ -0,0 +1,76 @@
+// Copyright 2014 Krishna Raman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func TestSchedulerQueryCancel(t *testing.T) {
+	c, _ := newOfflineClient()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := c.QueryTasks(ctx); err == nil {
+		t.Error("task list query on cancelled context succeeded")
+	}
+	if _, err := c.QueryTask(ctx, 5); err == nil {
+		t.Error("task query on cancelled context succeeded")
+	}
+	if n := len(c.taskListQueries); n != 0 {
+		t.Errorf("%v task list waiters left after cancel", n)
+	}
+	if _, ok := c.taskQueries[5]; ok {
+		t.Error("task waiters left after cancel")
+	}
+}
+
+func TestSchedulerTaskReply(t *testing.T) {
+	c, _ := newOfflineClient()
+
+	done := make(chan *TaskInfo)
+	go func() {
+		info, _ := c.QueryTask(context.Background(), 2)
+		done <- info
+	}()
+	for {
+		c.schedulerLock.Lock()
+		n := len(c.taskQueries[2])
+		c.schedulerLock.Unlock()
+		if n > 0 {
+			break
+		}
+	}
+
+	payload := append(uint32Bytes(1000), 3, 0, 1, 0, 0xF4, 0x90, 0x01)
+	reply := append([]byte{byte(SchedulerQueryTaskReply), 2}, Pack7Bit(payload)...)
+	c.parseSchedulerResponse(reply)
+
+	info := <-done
+	if info == nil {
+		t.Fatal("no task info")
+	}
+	if info.ID != 2 || info.Time != 1000 || info.Length != 3 || info.Position != 1 {
+		t.Errorf("unexpected task info %+v", info)
+	}
+	if !bytes.Equal(info.Data, []byte{0xF4, 0x90, 0x01}) {
+		t.Errorf("task data %v", info.Data)
+	}
+	if _, ok := c.taskQueries[2]; ok {
+		t.Error("task waiters left after reply")
+	}
+}
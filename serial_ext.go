@@ -14,37 +14,82 @@
 
 package firmata
 
+import (
+	"fmt"
+)
+
 type SerialSubCommand byte
 
+// Data received on a serial port, delivered on the channel returned by
+// GetSerialEvents()
+type SerialEvent struct {
+	Port SerialPort
+	Data []byte
+}
+
+func (e SerialEvent) String() string {
+	return fmt.Sprintf("Serial port %v received %q", e.Port, e.Data)
+}
+
 // Configure a builtin or soft serial port. This command must be called before sending serial data.
 // Set txPin and rxPin to 0x00 for builtin serial ports.
 func (c *FirmataClient) SerialConfig(port SerialPort, baud int, txPin byte, rxPin byte) (err error) {
 	baudBytes := intto7Bit(baud)
 	bufferSize := intto7Bit(1024)
 	termChar := to7Bit('\n')
-	c.serialChan = make(chan string, 10)
 
-	err = c.sendSysEx(Serial, byte(SerialConfig)|byte(port),
+	err = c.sendSerial(byte(SerialConfig)|byte(port),
 		baudBytes[0], baudBytes[1], baudBytes[2],
 		bufferSize[0], bufferSize[1], bufferSize[2],
 		termChar[0], termChar[1])
 	return
 }
 
-// Get channel for incoming serial data
-func (c *FirmataClient) GetSerialData() <-chan string {
+// Send data out of a serial port configured with SerialConfig
+func (c *FirmataClient) SerialWrite(port SerialPort, data []byte) error {
+	return c.sendSerial(append([]byte{byte(SerialComm) | byte(port)}, Encode7Bit(data)...)...)
+}
+
+func (c *FirmataClient) sendSerial(data ...byte) error {
+	if err := c.requireFeature(FeatureSerial); err != nil {
+		return err
+	}
+	return c.sendSysEx(Serial, data...)
+}
+
+// Get channel for data received on any serial port. The channel is closed
+// when the connection to the board ends.
+func (c *FirmataClient) GetSerialEvents() <-chan SerialEvent {
 	return c.serialChan
 }
 
-func (c *FirmataClient) parseSerialResponse(data7bit []byte) {
+// Get channel for incoming serial data without the port it arrived on. This
+// reads from GetSerialEvents(), so only one of the two may be used.
+func (c *FirmataClient) GetSerialData() <-chan string {
+	c.serialOnce.Do(func() {
+		c.serialData = make(chan string, 10)
+		go func() {
+			defer close(c.serialData)
+			for ev := range c.serialChan {
+				c.serialData <- string(ev.Data)
+			}
+		}()
+	})
+	return c.serialData
+}
 
-	data := make([]byte, 0)
-	for i := 1; i < len(data7bit); i = i + 2 {
-		data = append(data, byte(from7Bit(data7bit[i], data7bit[i+1])))
+func (c *FirmataClient) parseSerialResponse(data7bit []byte) {
+	if len(data7bit) == 0 {
+		return
+	}
+	ev := SerialEvent{Port: SerialPort(data7bit[0] & 0x0F), Data: make([]byte, 0)}
+	for i := 1; i+1 < len(data7bit); i = i + 2 {
+		ev.Data = append(ev.Data, byte(from7Bit(data7bit[i], data7bit[i+1])))
 	}
 	select {
-	case c.serialChan <- string(data):
+	case c.serialChan <- ev:
 	default:
-		c.Log.Print("Serial data buffer overflow. No listener?")
+		c.Log.Warn("Serial data buffer overflow. No listener?", "port", ev.Port)
+		c.metrics.DroppedEvent("serial")
 	}
 }
@@ -0,0 +1,61 @@
+// Copyright 2014 Krishna Raman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"github.com/kraman/go-firmata"
+)
+
+// Capabilities of a single pin
+type Pin struct {
+	// Supported modes and their resolution in bits
+	Modes map[firmata.PinMode]byte
+	// Analog channel of the pin, or -1 if it has none
+	AnalogChannel int
+}
+
+// Hardware behind the server's pins. Pin numbers are indexes into the
+// slice returned by Pins. The server only calls SetPinMode with modes the
+// pin reports as supported.
+type Backend interface {
+	Pins() []Pin
+	SetPinMode(pin int, mode firmata.PinMode) error
+	DigitalWrite(pin int, val bool) error
+	DigitalRead(pin int) (bool, error)
+	// Write a PWM duty cycle, servo angle or other analog value
+	AnalogWrite(pin int, val int) error
+	AnalogRead(pin int) (int, error)
+}
+
+// Handler for one or more SysEx based features, such as I2C or SPI
+type Feature interface {
+	// SysEx commands routed to HandleSysEx
+	SysExCommands() []firmata.SysExCommand
+	// Handle a SysEx message. data holds the payload without the command
+	// byte. A returned error is reported to the client as a string message.
+	HandleSysEx(s *Server, cmd firmata.SysExCommand, data []byte) error
+}
+
+// Implemented by backends and features that keep state which must be
+// cleared when the client sends a system reset
+type Resetter interface {
+	Reset()
+}
+
+// Implemented by features that report data every sampling interval, such
+// as continuous I2C reads
+type Sampler interface {
+	Sample(s *Server)
+}
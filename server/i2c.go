@@ -0,0 +1,136 @@
+// Copyright 2014 Krishna Raman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/kraman/go-firmata"
+)
+
+// I2C bus behind an I2CFeature
+type I2CBus interface {
+	Write(addr int, data []byte) error
+	// Read count bytes from the device at addr, starting at register unless
+	// it is firmata.I2CRegisterNotSpecified
+	Read(addr int, register int, count int) ([]byte, error)
+}
+
+// Serves I2C requests from the client on an I2CBus, including continuous
+// reads reported every sampling interval
+type I2CFeature struct {
+	bus I2CBus
+
+	lock    sync.Mutex
+	queries []i2cQuery
+}
+
+type i2cQuery struct {
+	addr     int
+	register int
+	count    int
+}
+
+func NewI2CFeature(bus I2CBus) *I2CFeature {
+	return &I2CFeature{bus: bus}
+}
+
+func (f *I2CFeature) SysExCommands() []firmata.SysExCommand {
+	return []firmata.SysExCommand{firmata.I2CRequest, firmata.I2CConfig}
+}
+
+func (f *I2CFeature) HandleSysEx(s *Server, cmd firmata.SysExCommand, data []byte) error {
+	if cmd == firmata.I2CConfig {
+		return nil
+	}
+	if len(data) < 2 {
+		return fmt.Errorf("I2C: short request")
+	}
+	addr := int(data[0] & 0x7F)
+	mode := firmata.I2CMode(data[1])
+	if mode&firmata.I2C10BitAddressMode != 0 {
+		addr = addr | int(data[1]&0x07)<<7
+	}
+	args := data[2:]
+
+	q := i2cQuery{addr: addr, register: firmata.I2CRegisterNotSpecified}
+	if len(args) >= 4 {
+		q.register = int(args[0]&0x7F) | int(args[1]&0x7F)<<7
+		q.count = int(args[2]&0x7F) | int(args[3]&0x7F)<<7
+	} else if len(args) >= 2 {
+		q.count = int(args[0]&0x7F) | int(args[1]&0x7F)<<7
+	}
+
+	switch mode & firmata.I2CReadWriteModeMask {
+	case firmata.I2CWrite:
+		if err := f.bus.Write(addr, firmata.Decode7Bit(args)); err != nil {
+			return fmt.Errorf("I2C: Write failed: %v", err)
+		}
+	case firmata.I2CRead:
+		return f.read(s, q)
+	case firmata.I2CReadContinuously:
+		f.lock.Lock()
+		f.queries = append(f.queries, q)
+		f.lock.Unlock()
+	case firmata.I2CStopReading:
+		f.lock.Lock()
+		queries := f.queries[:0]
+		for _, cq := range f.queries {
+			if cq.addr != addr {
+				queries = append(queries, cq)
+			}
+		}
+		f.queries = queries
+		f.lock.Unlock()
+	}
+	return nil
+}
+
+// Report continuous reads
+func (f *I2CFeature) Sample(s *Server) {
+	f.lock.Lock()
+	queries := append([]i2cQuery{}, f.queries...)
+	f.lock.Unlock()
+
+	for _, q := range queries {
+		if err := f.read(s, q); err != nil {
+			s.Log.Warn("Continuous I2C read failed", "address", q.addr, "error", err)
+		}
+	}
+}
+
+// Stop continuous reads
+func (f *I2CFeature) Reset() {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.queries = nil
+}
+
+func (f *I2CFeature) read(s *Server, q i2cQuery) error {
+	data, err := f.bus.Read(q.addr, q.register, q.count)
+	if err != nil {
+		return fmt.Errorf("I2C: Read failed: %v", err)
+	}
+
+	reg := q.register
+	if reg < 0 {
+		reg = 0
+	}
+	msg := []byte{
+		byte(q.addr & 0x7F), byte((q.addr >> 7) & 0x7F),
+		byte(reg & 0x7F), byte((reg >> 7) & 0x7F)}
+	return s.SendSysEx(firmata.I2CReply, append(msg, firmata.Encode7Bit(data)...)...)
+}
@@ -0,0 +1,265 @@
+// Copyright 2014 Krishna Raman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"bufio"
+	"sort"
+	"time"
+
+	"github.com/kraman/go-firmata"
+)
+
+func (s *Server) readCommands() error {
+	r := bufio.NewReader(s.rw)
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return err
+		}
+		if b&0x80 == 0 {
+			s.Log.Debug("Discarding stray data byte", "byte", b)
+			continue
+		}
+
+		switch cmd := firmata.FirmataCommand(b); {
+		case cmd == firmata.SystemReset:
+			s.reset()
+			s.sendHello()
+		case cmd == firmata.ReportVersion:
+			s.sendVersion()
+		case cmd == firmata.StartSysEx:
+			data, err := r.ReadBytes(byte(firmata.EndSysEx))
+			if err != nil {
+				return err
+			}
+			if len(data) > 1 {
+				s.handleSysEx(firmata.SysExCommand(data[0]), data[1:len(data)-1])
+			}
+		case cmd&0xF0 == firmata.EnableAnalogInput, cmd&0xF0 == firmata.EnableDigitalInput:
+			// report toggles carry a single data byte
+			arg, err := r.ReadByte()
+			if err != nil {
+				return err
+			}
+			s.handleCommand(cmd, arg, 0)
+		case cmd == firmata.SetPinMode, cmd == firmata.SetDigitalPinValue,
+			cmd&0xF0 == firmata.DigitalMessage, cmd&0xF0 == firmata.AnalogMessage:
+			var args [2]byte
+			if args[0], err = r.ReadByte(); err != nil {
+				return err
+			}
+			if args[1], err = r.ReadByte(); err != nil {
+				return err
+			}
+			s.handleCommand(cmd, args[0], args[1])
+		default:
+			s.Log.Debug("Discarding unsupported command", "command", cmd)
+		}
+	}
+}
+
+func (s *Server) handleCommand(cmd firmata.FirmataCommand, arg0 byte, arg1 byte) {
+	s.lock.Lock()
+	var msg []byte
+	switch {
+	case cmd == firmata.SetPinMode:
+		pin, mode := int(arg0), firmata.PinMode(arg1)
+		if pin >= len(s.pins) {
+			break
+		}
+		if _, ok := s.pins[pin].Modes[mode]; !ok {
+			s.Log.Warn("Pin does not support mode", "pin", pin, "mode", mode)
+			break
+		}
+		if err := s.backend.SetPinMode(pin, mode); err != nil {
+			s.Log.Warn("Setting pin mode failed", "pin", pin, "mode", mode, "error", err)
+			break
+		}
+		s.modes[pin] = mode
+		s.outputs[pin] = 0
+		msg = s.portReport(byte(pin/8), false)
+	case cmd == firmata.SetDigitalPinValue:
+		s.digitalWrite(int(arg0), arg1&0x01 != 0)
+	case cmd&0xF0 == firmata.DigitalMessage:
+		port := int(cmd & 0x0F)
+		val := int(arg0&0x7F) | int(arg1&0x01)<<7
+		for i := 0; i < 8; i++ {
+			pin := port*8 + i
+			if pin < len(s.modes) && s.modes[pin] == firmata.Output {
+				s.digitalWrite(pin, (val>>uint(i))&0x01 != 0)
+			}
+		}
+	case cmd&0xF0 == firmata.AnalogMessage:
+		s.analogWrite(int(cmd&0x0F), int(arg0&0x7F)|int(arg1&0x7F)<<7)
+	case cmd&0xF0 == firmata.EnableAnalogInput:
+		s.reportAnalog[int(cmd&0x0F)] = arg0 != 0
+	case cmd&0xF0 == firmata.EnableDigitalInput:
+		port := byte(cmd & 0x0F)
+		s.reportPorts[port] = arg0 != 0
+		msg = s.portReport(port, true)
+	}
+	s.lock.Unlock()
+
+	s.Send(msg...)
+}
+
+// Caller must hold s.lock
+func (s *Server) digitalWrite(pin int, val bool) {
+	if pin >= len(s.modes) || s.modes[pin] != firmata.Output {
+		return
+	}
+	if err := s.backend.DigitalWrite(pin, val); err != nil {
+		s.Log.Warn("Digital write failed", "pin", pin, "error", err)
+		return
+	}
+	s.outputs[pin] = 0
+	if val {
+		s.outputs[pin] = 1
+	}
+}
+
+// Caller must hold s.lock. Like the firmware, only pins in PWM or servo
+// mode are written.
+func (s *Server) analogWrite(pin int, val int) {
+	if pin >= len(s.modes) || (s.modes[pin] != firmata.PWM && s.modes[pin] != firmata.Servo) {
+		return
+	}
+	if err := s.backend.AnalogWrite(pin, val); err != nil {
+		s.Log.Warn("Analog write failed", "pin", pin, "error", err)
+		return
+	}
+	s.outputs[pin] = val
+}
+
+func (s *Server) handleSysEx(cmd firmata.SysExCommand, data []byte) {
+	switch cmd {
+	case firmata.ReportFirmware:
+		s.sendFirmware()
+	case firmata.CapabilityQuery:
+		s.sendCapabilities()
+	case firmata.AnalogMappingQuery:
+		s.sendAnalogMapping()
+	case firmata.PinStateQuery:
+		if len(data) > 0 {
+			s.sendPinState(int(data[0]))
+		}
+	case firmata.ExtendedAnalog:
+		if len(data) < 2 {
+			return
+		}
+		val := 0
+		for i, v := range data[1:] {
+			val = val | int(v&0x7F)<<uint(7*i)
+		}
+		s.lock.Lock()
+		s.analogWrite(int(data[0]), val)
+		s.lock.Unlock()
+	case firmata.SamplingInterval:
+		if len(data) < 2 {
+			return
+		}
+		ms := int(data[0]&0x7F) | int(data[1]&0x7F)<<7
+		if ms == 0 {
+			return
+		}
+		s.lock.Lock()
+		s.setSamplingInterval(time.Duration(ms) * time.Millisecond)
+		s.lock.Unlock()
+	default:
+		f := s.handlers[cmd]
+		if f == nil {
+			s.Log.Debug("Discarding unsupported SysEx command", "command", cmd)
+			return
+		}
+		if err := f.HandleSysEx(s, cmd, data); err != nil {
+			s.Log.Warn("SysEx command failed", "command", cmd, "error", err)
+			s.SendString(err.Error())
+		}
+	}
+}
+
+func (s *Server) sendHello() {
+	s.sendVersion()
+	s.sendFirmware()
+}
+
+func (s *Server) sendVersion() {
+	s.Send(byte(firmata.ReportVersion), s.protocolMajor, s.protocolMinor)
+}
+
+func (s *Server) sendFirmware() {
+	msg := []byte{s.firmwareMajor, s.firmwareMinor}
+	s.SendSysEx(firmata.ReportFirmware, append(msg, firmata.Encode7Bit([]byte(s.firmwareName))...)...)
+}
+
+func (s *Server) sendCapabilities() {
+	var msg []byte
+	for _, p := range s.pins {
+		modes := make([]int, 0, len(p.Modes))
+		for m := range p.Modes {
+			modes = append(modes, int(m))
+		}
+		sort.Ints(modes)
+		for _, m := range modes {
+			msg = append(msg, byte(m), p.Modes[firmata.PinMode(m)])
+		}
+		msg = append(msg, 127)
+	}
+	s.SendSysEx(firmata.CapabilityResponse, msg...)
+}
+
+func (s *Server) sendAnalogMapping() {
+	var msg []byte
+	for _, p := range s.pins {
+		if p.AnalogChannel >= 0 {
+			msg = append(msg, byte(p.AnalogChannel))
+		} else {
+			msg = append(msg, 127)
+		}
+	}
+	s.SendSysEx(firmata.AnalogMappingResponse, msg...)
+}
+
+func (s *Server) sendPinState(pin int) {
+	s.lock.Lock()
+	if pin >= len(s.modes) {
+		s.lock.Unlock()
+		return
+	}
+	mode := s.modes[pin]
+	val := s.outputs[pin]
+	switch mode {
+	case firmata.Input, firmata.InputPullup:
+		high, _ := s.backend.DigitalRead(pin)
+		val = 0
+		if high {
+			val = 1
+		}
+	case firmata.Analog:
+		val, _ = s.backend.AnalogRead(pin)
+	}
+	s.lock.Unlock()
+
+	msg := []byte{byte(pin), byte(mode)}
+	for {
+		msg = append(msg, byte(val&0x7F))
+		val = val >> 7
+		if val == 0 {
+			break
+		}
+	}
+	s.SendSysEx(firmata.PinStateResponse, msg...)
+}
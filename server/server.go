@@ -0,0 +1,328 @@
+// Copyright 2014 Krishna Raman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Device side of the Firmata protocol. A Server exposes the pins of a
+// Backend, and any SysEx features added to it, to a Firmata client on the
+// other end of an io.ReadWriter.
+//
+// Usage:
+//
+//	srv := server.New(port, backend, server.WithFirmware("LinuxGPIO", 1, 0))
+//	srv.AddFeature(server.NewI2CFeature(bus))
+//	err := srv.Serve()
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/kraman/go-firmata"
+)
+
+// Default time between analog and digital input reports
+const DefaultSamplingInterval = time.Millisecond * 19
+
+// Option applied to a Server by New
+type Option func(*Server)
+
+// Firmware name and version announced to the client
+func WithFirmware(name string, major byte, minor byte) Option {
+	return func(s *Server) {
+		s.firmwareName = name
+		s.firmwareMajor = major
+		s.firmwareMinor = minor
+	}
+}
+
+// Protocol version announced to the client. Defaults to 2.5.
+func WithProtocolVersion(major byte, minor byte) Option {
+	return func(s *Server) {
+		s.protocolMajor = major
+		s.protocolMinor = minor
+	}
+}
+
+// Log through the given logger. Silent by default.
+func WithLogger(l *slog.Logger) Option {
+	return func(s *Server) {
+		s.Log = l
+	}
+}
+
+// Firmata device serving the pins of a Backend
+type Server struct {
+	Log *slog.Logger
+
+	rw       io.ReadWriter
+	backend  Backend
+	pins     []Pin
+	features []Feature
+	handlers map[firmata.SysExCommand]Feature
+
+	firmwareName  string
+	firmwareMajor byte
+	firmwareMinor byte
+	protocolMajor byte
+	protocolMinor byte
+
+	lock         sync.Mutex
+	modes        []firmata.PinMode
+	outputs      []int
+	reportPorts  map[byte]bool
+	lastPorts    map[byte]byte
+	reportAnalog map[int]bool
+
+	writeLock sync.Mutex
+	interval  chan time.Duration
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// Create a server for backend speaking over rw. Call Serve to start it.
+func New(rw io.ReadWriter, backend Backend, opts ...Option) *Server {
+	s := &Server{
+		Log:           slog.New(discardHandler{}),
+		rw:            rw,
+		backend:       backend,
+		pins:          backend.Pins(),
+		handlers:      make(map[firmata.SysExCommand]Feature),
+		firmwareName:  "go-firmata",
+		firmwareMajor: 2,
+		firmwareMinor: 5,
+		protocolMajor: 2,
+		protocolMinor: 5,
+		interval:      make(chan time.Duration, 1),
+		done:          make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Route the SysEx commands of f to it. Must be called before Serve.
+func (s *Server) AddFeature(f Feature) error {
+	for _, cmd := range f.SysExCommands() {
+		if _, ok := s.handlers[cmd]; ok {
+			return fmt.Errorf("SysEx command %v is already handled", cmd)
+		}
+	}
+	for _, cmd := range f.SysExCommands() {
+		s.handlers[cmd] = f
+	}
+	s.features = append(s.features, f)
+	return nil
+}
+
+// Announce the firmware and process commands from the client until reading
+// fails or the server is closed. Returns the read error.
+func (s *Server) Serve() error {
+	s.reset()
+	go s.sample()
+	defer s.stop()
+
+	s.sendHello()
+	return s.readCommands()
+}
+
+// Stop the server. Closes the link if it implements io.Closer.
+func (s *Server) Close() error {
+	s.stop()
+	if c, ok := s.rw.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+func (s *Server) stop() {
+	s.closeOnce.Do(func() { close(s.done) })
+}
+
+// Current mode of a pin
+func (s *Server) PinMode(pin int) firmata.PinMode {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if pin < 0 || pin >= len(s.modes) {
+		return firmata.Ignore
+	}
+	return s.modes[pin]
+}
+
+// Report the port of pin right away instead of waiting for the next
+// sampling interval. For backends that are notified of input changes.
+func (s *Server) DigitalInputChanged(pin int) {
+	if pin < 0 || pin >= len(s.pins) {
+		return
+	}
+	s.lock.Lock()
+	msg := s.portReport(byte(pin/8), false)
+	s.lock.Unlock()
+	s.Send(msg...)
+}
+
+// Send raw bytes to the client
+func (s *Server) Send(data ...byte) error {
+	if len(data) == 0 {
+		return nil
+	}
+	s.writeLock.Lock()
+	defer s.writeLock.Unlock()
+	_, err := s.rw.Write(data)
+	return err
+}
+
+// Send a SysEx message. Every payload byte must be 7-bit.
+func (s *Server) SendSysEx(cmd firmata.SysExCommand, payload ...byte) error {
+	msg := append([]byte{byte(firmata.StartSysEx), byte(cmd)}, payload...)
+	return s.Send(append(msg, byte(firmata.EndSysEx))...)
+}
+
+// Send a string message to the client
+func (s *Server) SendString(str string) error {
+	return s.SendSysEx(firmata.StringData, firmata.Encode7Bit([]byte(str))...)
+}
+
+// Restore power-on state: analog pins in analog mode, others as outputs
+func (s *Server) reset() {
+	s.lock.Lock()
+	n := len(s.pins)
+	s.modes = make([]firmata.PinMode, n)
+	s.outputs = make([]int, n)
+	for pin, p := range s.pins {
+		_, analog := p.Modes[firmata.Analog]
+		_, output := p.Modes[firmata.Output]
+		switch {
+		case analog && p.AnalogChannel >= 0:
+			s.modes[pin] = firmata.Analog
+		case output:
+			s.modes[pin] = firmata.Output
+		default:
+			s.modes[pin] = firmata.Ignore
+		}
+	}
+	modes := append([]firmata.PinMode{}, s.modes...)
+	s.reportPorts = make(map[byte]bool)
+	s.lastPorts = make(map[byte]byte)
+	s.reportAnalog = make(map[int]bool)
+	s.setSamplingInterval(DefaultSamplingInterval)
+	s.lock.Unlock()
+
+	if r, ok := s.backend.(Resetter); ok {
+		r.Reset()
+	}
+	for pin, mode := range modes {
+		if mode == firmata.Ignore {
+			continue
+		}
+		if err := s.backend.SetPinMode(pin, mode); err != nil {
+			s.Log.Warn("Setting power-on pin mode failed", "pin", pin, "mode", mode, "error", err)
+		}
+	}
+	for _, f := range s.features {
+		if r, ok := f.(Resetter); ok {
+			r.Reset()
+		}
+	}
+}
+
+// Caller must hold s.lock
+func (s *Server) setSamplingInterval(d time.Duration) {
+	select {
+	case <-s.interval:
+	default:
+	}
+	s.interval <- d
+}
+
+// Report digital ports and analog channels, and let features report their
+// data, every sampling interval
+func (s *Server) sample() {
+	t := time.NewTicker(DefaultSamplingInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-s.done:
+			return
+		case d := <-s.interval:
+			t.Reset(d)
+		case <-t.C:
+			s.lock.Lock()
+			var msg []byte
+			for port := range s.reportPorts {
+				msg = append(msg, s.portReport(port, false)...)
+			}
+			for pin, p := range s.pins {
+				if p.AnalogChannel < 0 || !s.reportAnalog[p.AnalogChannel] || s.modes[pin] != firmata.Analog {
+					continue
+				}
+				v, err := s.backend.AnalogRead(pin)
+				if err != nil {
+					s.Log.Warn("Analog read failed", "pin", pin, "error", err)
+					continue
+				}
+				msg = append(msg, byte(firmata.AnalogMessage)|byte(p.AnalogChannel&0x0F), byte(v&0x7F), byte((v>>7)&0x7F))
+			}
+			s.lock.Unlock()
+
+			s.Send(msg...)
+			for _, f := range s.features {
+				if sm, ok := f.(Sampler); ok {
+					sm.Sample(s)
+				}
+			}
+		}
+	}
+}
+
+// Digital report for a port. Only returned if reporting is enabled and,
+// unless force is set, the value changed. Caller must hold s.lock.
+func (s *Server) portReport(port byte, force bool) []byte {
+	if !s.reportPorts[port] {
+		return nil
+	}
+	val := byte(0)
+	for i := 0; i < 8; i++ {
+		pin := int(port)*8 + i
+		if pin >= len(s.modes) {
+			break
+		}
+		if s.modes[pin] != firmata.Input && s.modes[pin] != firmata.InputPullup {
+			continue
+		}
+		high, err := s.backend.DigitalRead(pin)
+		if err != nil {
+			s.Log.Warn("Digital read failed", "pin", pin, "error", err)
+			continue
+		}
+		if high {
+			val = val | 1<<uint(i)
+		}
+	}
+	if !force && s.lastPorts[port] == val {
+		return nil
+	}
+	s.lastPorts[port] = val
+	return []byte{byte(firmata.DigitalMessage) | port, val & 0x7F, val >> 7}
+}
+
+type discardHandler struct{}
+
+func (discardHandler) Enabled(context.Context, slog.Level) bool  { return false }
+func (discardHandler) Handle(context.Context, slog.Record) error { return nil }
+func (h discardHandler) WithAttrs([]slog.Attr) slog.Handler      { return h }
+func (h discardHandler) WithGroup(string) slog.Handler           { return h }
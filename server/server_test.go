@@ -0,0 +1,125 @@
+// Copyright 2014 Krishna Raman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+
+	"github.com/kraman/go-firmata"
+)
+
+// Backend with four pins supporting output, PWM and servo modes that
+// records the values written
+type testBackend struct {
+	lock    sync.Mutex
+	written map[int]int
+}
+
+func (b *testBackend) Pins() []Pin {
+	pins := make([]Pin, 4)
+	for i := range pins {
+		pins[i] = Pin{
+			Modes:         map[firmata.PinMode]byte{firmata.Output: 1, firmata.PWM: 8, firmata.Servo: 14},
+			AnalogChannel: -1,
+		}
+	}
+	return pins
+}
+
+func (b *testBackend) SetPinMode(pin int, mode firmata.PinMode) error { return nil }
+func (b *testBackend) DigitalRead(pin int) (bool, error)              { return false, nil }
+func (b *testBackend) AnalogRead(pin int) (int, error)                { return 0, nil }
+
+func (b *testBackend) DigitalWrite(pin int, val bool) error {
+	v := 0
+	if val {
+		v = 1
+	}
+	return b.AnalogWrite(pin, v)
+}
+
+func (b *testBackend) AnalogWrite(pin int, val int) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.written[pin] = val
+	return nil
+}
+
+func (b *testBackend) value(pin int) (val int, ok bool) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	val, ok = b.written[pin]
+	return
+}
+
+func newTestServer() (*Server, *testBackend) {
+	b := &testBackend{written: make(map[int]int)}
+	s := New(&bytes.Buffer{}, b)
+	s.reset()
+	return s, b
+}
+
+func TestAnalogWriteMode(t *testing.T) {
+	s, b := newTestServer()
+
+	// pins start as outputs
+	s.handleCommand(firmata.AnalogMessage|0, 100, 0)
+	if v, ok := b.value(0); ok {
+		t.Errorf("analog write to output pin wrote %v", v)
+	}
+
+	s.handleCommand(firmata.SetPinMode, 1, byte(firmata.PWM))
+	s.handleCommand(firmata.AnalogMessage|1, 100, 0)
+	if v, _ := b.value(1); v != 100 {
+		t.Errorf("PWM pin written %v, want 100", v)
+	}
+
+	s.handleCommand(firmata.SetPinMode, 2, byte(firmata.Servo))
+	s.handleSysEx(firmata.ExtendedAnalog, []byte{2, 0x34, 0x01})
+	if v, _ := b.value(2); v != 0xB4 {
+		t.Errorf("servo pin written %v, want 180", v)
+	}
+	if s.outputs[2] != 0xB4 {
+		t.Errorf("servo output recorded as %v", s.outputs[2])
+	}
+
+	s.handleCommand(firmata.SetPinMode, 1, byte(firmata.Output))
+	s.handleCommand(firmata.AnalogMessage|1, 50, 0)
+	if v, _ := b.value(1); v == 50 {
+		t.Error("analog write applied after switching back to output")
+	}
+}
+
+func TestDigitalWriteMode(t *testing.T) {
+	s, b := newTestServer()
+
+	s.handleCommand(firmata.SetPinMode, 3, byte(firmata.PWM))
+	s.handleCommand(firmata.DigitalMessage|0, 0x0F, 0)
+	for pin := 0; pin < 3; pin++ {
+		if v, _ := b.value(pin); v != 1 {
+			t.Errorf("output pin %v written %v", pin, v)
+		}
+	}
+	if v, ok := b.value(3); ok {
+		t.Errorf("digital write to PWM pin wrote %v", v)
+	}
+
+	s.handleCommand(firmata.SetDigitalPinValue, 0, 0)
+	if v, _ := b.value(0); v != 0 {
+		t.Errorf("single pin write left pin 0 at %v", v)
+	}
+}
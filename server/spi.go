@@ -0,0 +1,68 @@
+// Copyright 2014 Krishna Raman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"fmt"
+
+	"github.com/kraman/go-firmata"
+)
+
+// SPI bus behind an SPIFeature. Devices are selected by chip-select pin.
+type SPIBus interface {
+	Configure(csPin int, mode byte) error
+	// Write data to the device and return the bytes clocked back
+	Transfer(csPin int, data []byte) ([]byte, error)
+}
+
+// Serves SPI transfers from the client on an SPIBus. Replies echo the
+// request id so clients can match them to their request.
+type SPIFeature struct {
+	bus SPIBus
+}
+
+func NewSPIFeature(bus SPIBus) *SPIFeature {
+	return &SPIFeature{bus: bus}
+}
+
+func (f *SPIFeature) SysExCommands() []firmata.SysExCommand {
+	return []firmata.SysExCommand{firmata.SysExSPI}
+}
+
+func (f *SPIFeature) HandleSysEx(s *Server, cmd firmata.SysExCommand, data []byte) error {
+	if len(data) < 3 {
+		return fmt.Errorf("SPI: short request")
+	}
+	csPin := int(data[1]&0x7F) | int(data[2]&0x7F)<<7
+
+	switch firmata.SPISubCommand(data[0] & 0xF0) {
+	case firmata.SPIConfig:
+		if len(data) < 5 {
+			return fmt.Errorf("SPI: short config request")
+		}
+		mode := firmata.Decode7Bit(data[3:5])[0]
+		if err := f.bus.Configure(csPin, mode); err != nil {
+			return fmt.Errorf("SPI: configuring pin %v failed: %v", csPin, err)
+		}
+	case firmata.SPIComm:
+		out, err := f.bus.Transfer(csPin, firmata.Decode7Bit(data[3:]))
+		if err != nil {
+			return fmt.Errorf("SPI: transfer on pin %v failed: %v", csPin, err)
+		}
+		msg := []byte{data[0], data[1], data[2]}
+		return s.SendSysEx(firmata.SysExSPI, append(msg, firmata.Encode7Bit(out)...)...)
+	}
+	return nil
+}
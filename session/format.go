@@ -0,0 +1,119 @@
+// Copyright 2014 Krishna Raman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Recording and replay of the byte stream between a FirmataClient and a board.
+//
+// Sessions are stored as text, one transfer per line:
+//
+//	# go-firmata session v1
+//	0.000000 out ff
+//	0.512345 in f9 02 05
+//
+// The first field is the time in seconds since the session started, the
+// second the direction as seen from the client and the rest the bytes in hex.
+// Lines starting with # are comments.
+package session
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const header = "# go-firmata session v1"
+
+type Direction byte
+
+const (
+	In  Direction = iota // board to client
+	Out                  // client to board
+)
+
+func (d Direction) String() string {
+	if d == In {
+		return "in"
+	}
+	return "out"
+}
+
+// A single read or write on the transport
+type Record struct {
+	Time      time.Duration
+	Direction Direction
+	Data      []byte
+}
+
+func (r Record) String() string {
+	return fmt.Sprintf("%.6f %v %v", r.Time.Seconds(), r.Direction, hexBytes(r.Data))
+}
+
+// Read all records of a session
+func ReadRecords(r io.Reader) (records []Record, err error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		var rec Record
+		if rec, err = parseRecord(text); err != nil {
+			err = fmt.Errorf("line %v: %w", line, err)
+			return
+		}
+		records = append(records, rec)
+	}
+	err = scanner.Err()
+	return
+}
+
+func parseRecord(text string) (rec Record, err error) {
+	fields := strings.Fields(text)
+	if len(fields) < 2 {
+		err = fmt.Errorf("Malformed record %q", text)
+		return
+	}
+	secs, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return
+	}
+	rec.Time = time.Duration(secs * float64(time.Second))
+
+	switch fields[1] {
+	case "in":
+		rec.Direction = In
+	case "out":
+		rec.Direction = Out
+	default:
+		err = fmt.Errorf("Unknown direction %q", fields[1])
+		return
+	}
+
+	rec.Data, err = hex.DecodeString(strings.Join(fields[2:], ""))
+	return
+}
+
+func hexBytes(data []byte) string {
+	parts := make([]string, len(data))
+	for i, b := range data {
+		parts[i] = fmt.Sprintf("%02x", b)
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,92 @@
+// Copyright 2014 Krishna Raman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// Transport wrapper that records every byte read and written.
+//
+// Usage:
+//
+//	f, _ := os.Create("session.txt")
+//	rec := session.NewRecorder(port, f)
+//	arduino, err := firmata.NewClientWithTransport(rec)
+type Recorder struct {
+	conn  io.ReadWriteCloser
+	out   io.Writer
+	start time.Time
+
+	lock sync.Mutex
+	err  error
+}
+
+// Wrap conn, writing the session to out. out is closed with the recorder if
+// it is an io.Closer.
+func NewRecorder(conn io.ReadWriteCloser, out io.Writer) *Recorder {
+	r := &Recorder{conn: conn, out: out, start: time.Now()}
+	_, r.err = fmt.Fprintln(out, header)
+	return r
+}
+
+func (r *Recorder) Read(p []byte) (n int, err error) {
+	n, err = r.conn.Read(p)
+	if n > 0 {
+		r.record(In, p[:n])
+	}
+	return
+}
+
+func (r *Recorder) Write(p []byte) (n int, err error) {
+	n, err = r.conn.Write(p)
+	if n > 0 {
+		r.record(Out, p[:n])
+	}
+	return
+}
+
+// Close the wrapped transport and the session output
+func (r *Recorder) Close() error {
+	err := r.conn.Close()
+	if c, ok := r.out.(io.Closer); ok {
+		if cerr := c.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// First error encountered writing the session, if any. Recording errors do
+// not interrupt the wrapped transport.
+func (r *Recorder) Err() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.err
+}
+
+func (r *Recorder) record(dir Direction, data []byte) {
+	rec := Record{Time: time.Since(r.start), Direction: dir, Data: data}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.err != nil {
+		return
+	}
+	_, r.err = fmt.Fprintln(r.out, rec)
+}
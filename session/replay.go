@@ -0,0 +1,159 @@
+// Copyright 2014 Krishna Raman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// Transport that plays a recorded session back to a FirmataClient.
+// Bytes the board sent are returned from Read at their recorded time, scaled
+// by the replay speed. Bytes written by the client are compared with the
+// recording; differences are counted and, in strict mode, returned as errors.
+type Replay struct {
+	// Return an error from Write when the client diverges from the recording
+	Strict bool
+
+	in    []Record
+	out   []byte
+	speed float64
+	// set by the first Read so time spent on setup is not replayed
+	start time.Time
+
+	lock       sync.Mutex
+	pending    []byte
+	outPos     int
+	mismatches int
+	closed     chan struct{}
+	closeOnce  sync.Once
+}
+
+// Replay a session read from r. speed scales the recorded timing: 1 replays
+// in real time, 10 ten times faster and 0 as fast as possible.
+func NewReplay(r io.Reader, speed float64) (*Replay, error) {
+	records, err := ReadRecords(r)
+	if err != nil {
+		return nil, err
+	}
+	p := &Replay{speed: speed, closed: make(chan struct{})}
+	for _, rec := range records {
+		if rec.Direction == In {
+			p.in = append(p.in, rec)
+		} else {
+			p.out = append(p.out, rec.Data...)
+		}
+	}
+	return p, nil
+}
+
+// Return recorded board output. Returns io.EOF once the recording is exhausted.
+func (p *Replay) Read(b []byte) (n int, err error) {
+	select {
+	case <-p.closed:
+		return 0, io.ErrClosedPipe
+	default:
+	}
+
+	p.lock.Lock()
+	if p.start.IsZero() {
+		p.start = time.Now()
+	}
+	if len(p.pending) == 0 {
+		if len(p.in) == 0 {
+			p.lock.Unlock()
+			return 0, io.EOF
+		}
+		rec := p.in[0]
+		p.in = p.in[1:]
+		start := p.start
+		p.lock.Unlock()
+
+		if err = p.wait(start, rec.Time); err != nil {
+			return
+		}
+
+		p.lock.Lock()
+		p.pending = rec.Data
+	}
+	n = copy(b, p.pending)
+	p.pending = p.pending[n:]
+	p.lock.Unlock()
+	return
+}
+
+// Compare client output with the recording
+func (p *Replay) Write(b []byte) (n int, err error) {
+	select {
+	case <-p.closed:
+		return 0, io.ErrClosedPipe
+	default:
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	end := p.outPos + len(b)
+	if end > len(p.out) {
+		end = len(p.out)
+	}
+	expected := p.out[p.outPos:end]
+	p.outPos = end
+	if !bytes.Equal(expected, b) {
+		p.mismatches++
+		if p.Strict {
+			return 0, fmt.Errorf("Replay diverged: client wrote % x, recording has % x", b, expected)
+		}
+	}
+	return len(b), nil
+}
+
+// Unblock pending reads. Subsequent reads and writes fail.
+func (p *Replay) Close() error {
+	p.closeOnce.Do(func() { close(p.closed) })
+	return nil
+}
+
+// Number of writes that did not match the recording
+func (p *Replay) Mismatches() int {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.mismatches
+}
+
+func (p *Replay) wait(start time.Time, at time.Duration) error {
+	if p.speed > 0 {
+		delay := time.Until(start.Add(time.Duration(float64(at) / p.speed)))
+		if delay > 0 {
+			t := time.NewTimer(delay)
+			defer t.Stop()
+			select {
+			case <-t.C:
+			case <-p.closed:
+				return io.ErrClosedPipe
+			}
+			return nil
+		}
+	}
+	select {
+	case <-p.closed:
+		return io.ErrClosedPipe
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright 2014 Krishna Raman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+const recording = `# go-firmata session v1
+0.000000 out ff
+0.050000 in f9 02 05
+0.050000 in e0 10 00
+`
+
+type scriptedConn struct {
+	io.Reader
+	written bytes.Buffer
+}
+
+func (c *scriptedConn) Write(p []byte) (int, error) { return c.written.Write(p) }
+func (c *scriptedConn) Close() error                { return nil }
+
+func TestRecorder(t *testing.T) {
+	conn := &scriptedConn{Reader: bytes.NewReader([]byte{0xF9, 0x02, 0x05})}
+	var out bytes.Buffer
+	rec := NewRecorder(conn, &out)
+
+	rec.Write([]byte{0xFF})
+	buf := make([]byte, 8)
+	n, _ := rec.Read(buf)
+	if n != 3 {
+		t.Fatalf("read %v bytes", n)
+	}
+	if err := rec.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := ReadRecords(&out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("%v records: %v", len(records), records)
+	}
+	if records[0].Direction != Out || !bytes.Equal(records[0].Data, []byte{0xFF}) {
+		t.Errorf("first record %v", records[0])
+	}
+	if records[1].Direction != In || !bytes.Equal(records[1].Data, []byte{0xF9, 0x02, 0x05}) {
+		t.Errorf("second record %v", records[1])
+	}
+}
+
+func TestReadRecordsErrors(t *testing.T) {
+	for _, text := range []string{"0.1", "x in ff", "0.1 sideways ff", "0.1 in zz"} {
+		if _, err := ReadRecords(strings.NewReader(text)); err == nil {
+			t.Errorf("%q parsed", text)
+		}
+	}
+}
+
+func TestReplayTiming(t *testing.T) {
+	p, err := NewReplay(strings.NewReader(recording), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	// time before the first read is not part of the recording
+	time.Sleep(100 * time.Millisecond)
+
+	begin := time.Now()
+	buf := make([]byte, 8)
+	n, err := p.Read(buf)
+	if err != nil || !bytes.Equal(buf[:n], []byte{0xF9, 0x02, 0x05}) {
+		t.Fatalf("read %x, %v", buf[:n], err)
+	}
+	if d := time.Since(begin); d < 40*time.Millisecond {
+		t.Errorf("first reply after %v, recorded at 50ms", d)
+	}
+
+	n, err = p.Read(buf)
+	if err != nil || !bytes.Equal(buf[:n], []byte{0xE0, 0x10, 0x00}) {
+		t.Fatalf("read %x, %v", buf[:n], err)
+	}
+	if _, err = p.Read(buf); err != io.EOF {
+		t.Errorf("read past the end returned %v", err)
+	}
+}
+
+func TestReplayClose(t *testing.T) {
+	p, err := NewReplay(strings.NewReader(recording), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// leave bytes pending
+	buf := make([]byte, 1)
+	if _, err = p.Read(buf); err != nil {
+		t.Fatal(err)
+	}
+	p.Close()
+
+	if n, err := p.Read(buf); !errors.Is(err, io.ErrClosedPipe) {
+		t.Errorf("read after close returned %v bytes, %v", n, err)
+	}
+	if _, err := p.Write([]byte{0xFF}); !errors.Is(err, io.ErrClosedPipe) {
+		t.Errorf("write after close returned %v", err)
+	}
+}
+
+func TestReplayWrites(t *testing.T) {
+	p, err := NewReplay(strings.NewReader(recording), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Strict = true
+
+	if _, err = p.Write([]byte{0xFF}); err != nil {
+		t.Error(err)
+	}
+	if _, err = p.Write([]byte{0xF9}); err == nil {
+		t.Error("write beyond the recording accepted in strict mode")
+	}
+	if n := p.Mismatches(); n != 1 {
+		t.Errorf("%v mismatches, want 1", n)
+	}
+}
@@ -14,13 +14,35 @@
 
 package firmata
 
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
 type SPISubCommand byte
 
+// Time SPIReadWrite waits for the board to answer a transfer
+const DefaultSPITimeout = time.Second * 2
+
+// Handle to a SPI device selected by a chip-select pin on the board.
+// Transfers are serialized on the bus and the handle is safe for concurrent use.
+type SPIDevice struct {
+	client *FirmataClient
+	csPin  byte
+	mode   byte
+}
+
+type spiRequest struct {
+	id    byte
+	csPin byte
+	reply chan []byte
+}
+
 // Enable SPI communication for selected chip-select pin
 func (c *FirmataClient) SPIConfig(csPin byte, spiMode byte) (err error) {
 	csPinBytes := to7Bit(csPin)
 	spiModeBytes := to7Bit(spiMode)
-	c.spiChan = make(chan []byte)
 
 	err = c.sendSysEx(SysExSPI, byte(SPIConfig),
 		csPinBytes[0], csPinBytes[1],
@@ -28,10 +50,71 @@ func (c *FirmataClient) SPIConfig(csPin byte, spiMode byte) (err error) {
 	return
 }
 
-// Read and write data to SPI device
+// Enable SPI communication for selected chip-select pin and return a handle
+// for transfers to the device on that pin
+func (c *FirmataClient) NewSPIDevice(csPin byte, spiMode byte) (dev *SPIDevice, err error) {
+	if err = c.SPIConfig(csPin, spiMode); err != nil {
+		return
+	}
+	dev = &SPIDevice{client: c, csPin: csPin, mode: spiMode}
+	return
+}
+
+// Read and write data to SPI device. Waits at most DefaultSPITimeout for a reply.
 func (c *FirmataClient) SPIReadWrite(csPin byte, data []byte) (dataOut []byte, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultSPITimeout)
+	defer cancel()
+	dataOut, err = c.spiTransfer(ctx, csPin, data)
+	return
+}
+
+// Chip-select pin of the device
+func (d *SPIDevice) CSPin() byte {
+	return d.csPin
+}
+
+// SPI mode the device was configured with
+func (d *SPIDevice) Mode() byte {
+	return d.mode
+}
+
+func (d *SPIDevice) String() string {
+	return fmt.Sprintf("SPI device (cs pin %v, mode %#x)", d.csPin, d.mode)
+}
+
+// Write w to the device and read the bytes clocked in at the same time into r.
+// r may be nil, otherwise it must be the same length as w. The transfer is
+// abandoned when ctx is done; a late reply from the board is discarded.
+func (d *SPIDevice) Tx(ctx context.Context, w, r []byte) (err error) {
+	if r != nil && len(r) != len(w) {
+		err = fmt.Errorf("SPI read buffer length %v does not match write length %v", len(r), len(w))
+		return
+	}
+	data, err := d.client.spiTransfer(ctx, d.csPin, w)
+	if err != nil {
+		return
+	}
+	copy(r, data)
+	return
+}
+
+func (c *FirmataClient) spiTransfer(ctx context.Context, csPin byte, data []byte) (dataOut []byte, err error) {
+	defer c.observeQuery("spi", time.Now(), &err)
+
+	// only one transfer may be outstanding on the bus at a time
+	select {
+	case c.spiBus <- struct{}{}:
+	case <-ctx.Done():
+		err = fmt.Errorf("SPI transfer on pin %v: %w", csPin, ctx.Err())
+		return
+	}
+	defer func() { <-c.spiBus }()
+
+	req := c.newSPIRequest(csPin)
+	defer c.releaseSPIRequest(req)
+
 	csPinBytes := to7Bit(csPin)
-	data7Bit := []byte{byte(SPIComm)}
+	data7Bit := []byte{byte(SPIComm) | req.id}
 
 	data7Bit = append(data7Bit, csPinBytes...)
 	for i := 0; i < len(data); i++ {
@@ -39,17 +122,69 @@ func (c *FirmataClient) SPIReadWrite(csPin byte, data []byte) (dataOut []byte, e
 		data7Bit = append(data7Bit, bytes...)
 	}
 
-	err = c.sendSysEx(SysExSPI, data7Bit...)
-	dataOut = <-c.spiChan
+	if err = c.sendSysEx(SysExSPI, data7Bit...); err != nil {
+		return
+	}
+
+	select {
+	case dataOut = <-req.reply:
+	case <-ctx.Done():
+		err = fmt.Errorf("SPI transfer on pin %v: %w", csPin, ctx.Err())
+	}
 	return
 }
 
+// Request ids travel in the low nibble of the SPI_COMM sub-command and are
+// echoed back by the firmware so replies can be matched to their request.
+func (c *FirmataClient) newSPIRequest(csPin byte) *spiRequest {
+	c.spiLock.Lock()
+	defer c.spiLock.Unlock()
+
+	if c.spiPending == nil {
+		c.spiPending = make(map[byte]*spiRequest)
+	}
+	req := &spiRequest{id: c.spiRequestID, csPin: csPin, reply: make(chan []byte, 1)}
+	c.spiRequestID = (c.spiRequestID + 1) & 0x0F
+	c.spiPending[req.id] = req
+	return req
+}
+
+func (c *FirmataClient) releaseSPIRequest(req *spiRequest) {
+	c.spiLock.Lock()
+	defer c.spiLock.Unlock()
+
+	if c.spiPending[req.id] == req {
+		delete(c.spiPending, req.id)
+	}
+}
+
 func (c *FirmataClient) parseSPIResponse(data7bit []byte) {
+	if len(data7bit) < 3 {
+		c.Log.Warn("Discarding short SPI reply", "length", len(data7bit))
+		c.metrics.ParseError(SysExSPI)
+		return
+	}
+	id := data7bit[0] & 0x0F
+	csPin := from7Bit(data7bit[1], data7bit[2])
+
 	data := make([]byte, 0)
-	for i, _ := range data7bit {
-		if i >= 3 && i%2 != 0 {
-			data = append(data, from7Bit(data7bit[i], data7bit[i+1]))
-		}
+	for i := 3; i+1 < len(data7bit); i = i + 2 {
+		data = append(data, from7Bit(data7bit[i], data7bit[i+1]))
+	}
+
+	c.spiLock.Lock()
+	req := c.spiPending[id]
+	if req != nil && req.csPin == csPin {
+		delete(c.spiPending, id)
+	} else {
+		req = nil
+	}
+	c.spiLock.Unlock()
+
+	if req == nil {
+		c.Log.Warn("Discarding SPI reply. No pending request", "id", id, "pin", csPin)
+		c.metrics.DroppedEvent("spi_reply")
+		return
 	}
-	c.spiChan <- data
+	req.reply <- data
 }
@@ -0,0 +1,153 @@
+// Copyright 2014 Krishna Raman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type spiResult struct {
+	data []byte
+	err  error
+}
+
+// Start a transfer in the background
+func startSPITransfer(c *FirmataClient, ctx context.Context, csPin byte, data ...byte) <-chan spiResult {
+	done := make(chan spiResult, 1)
+	go func() {
+		out, err := c.spiTransfer(ctx, csPin, data)
+		done <- spiResult{out, err}
+	}()
+	return done
+}
+
+// SPI reply payload as sent by the firmware
+func spiReply(id byte, csPin byte, data ...byte) []byte {
+	msg := append([]byte{byte(SPIComm) | id}, to7Bit(csPin)...)
+	for _, b := range data {
+		msg = append(msg, to7Bit(b)...)
+	}
+	return msg
+}
+
+func spiResultOf(t *testing.T, done <-chan spiResult) spiResult {
+	t.Helper()
+	select {
+	case res := <-done:
+		return res
+	case <-time.After(time.Second):
+		t.Fatal("SPI transfer did not return")
+		return spiResult{}
+	}
+}
+
+func TestSPIRequestIDs(t *testing.T) {
+	c, rc := newOfflineClient()
+	ctx := context.Background()
+
+	// ids count up in the low nibble of SPI_COMM and wrap after 15
+	sent := 0
+	for i := 0; i < 18; i++ {
+		id := byte(i & 0x0F)
+		done := startSPITransfer(c, ctx, 9, 0xAB)
+		want := []byte{byte(StartSysEx), byte(SysExSPI), byte(SPIComm) | id, 9, 0, 0x2B, 1, byte(EndSysEx)}
+		msg := rc.waitSent(t, sent+len(want))[sent:]
+		if !bytes.Equal(msg, want) {
+			t.Fatalf("transfer %v sent %x, want %x", i, msg, want)
+		}
+		sent += len(want)
+
+		c.parseSPIResponse(spiReply(id, 9, byte(i)))
+		if res := spiResultOf(t, done); res.err != nil || !bytes.Equal(res.data, []byte{byte(i)}) {
+			t.Fatalf("transfer %v returned %x, %v", i, res.data, res.err)
+		}
+	}
+}
+
+func TestSPITwoChipSelects(t *testing.T) {
+	c, rc := newOfflineClient()
+	ctx := context.Background()
+
+	first := startSPITransfer(c, ctx, 9, 0x01)
+	rc.waitSent(t, 8)
+	// the second transfer waits for the bus
+	second := startSPITransfer(c, ctx, 10, 0x02)
+	time.Sleep(10 * time.Millisecond)
+	if n := len(rc.Sent()); n != 8 {
+		t.Fatalf("second transfer sent while the first was outstanding: %x", rc.Sent())
+	}
+
+	// a reply for the right id but another chip select is not taken
+	c.parseSPIResponse(spiReply(0, 10, 0xEE))
+	c.parseSPIResponse(spiReply(0, 9, 0x11))
+	if res := spiResultOf(t, first); !bytes.Equal(res.data, []byte{0x11}) {
+		t.Errorf("pin 9 got %x, %v", res.data, res.err)
+	}
+
+	msg := rc.waitSent(t, 16)[8:]
+	if msg[2] != byte(SPIComm)|1 || msg[3] != 10 {
+		t.Fatalf("second transfer sent %x", msg)
+	}
+	c.parseSPIResponse(spiReply(1, 10, 0x22))
+	if res := spiResultOf(t, second); !bytes.Equal(res.data, []byte{0x22}) {
+		t.Errorf("pin 10 got %x, %v", res.data, res.err)
+	}
+}
+
+func TestSPITimeoutDropsLateReply(t *testing.T) {
+	c, rc := newOfflineClient()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	res := spiResultOf(t, startSPITransfer(c, ctx, 9, 0x01))
+	if !errors.Is(res.err, context.DeadlineExceeded) {
+		t.Fatalf("timed out transfer returned %x, %v", res.data, res.err)
+	}
+
+	// the late reply is dropped instead of answering the next transfer
+	done := startSPITransfer(c, context.Background(), 9, 0x02)
+	rc.waitSent(t, 16)
+	c.parseSPIResponse(spiReply(0, 9, 0xEE))
+	select {
+	case res = <-done:
+		t.Fatalf("late reply answered the next transfer: %x", res.data)
+	case <-time.After(10 * time.Millisecond):
+	}
+	c.parseSPIResponse(spiReply(1, 9, 0x22))
+	if res = spiResultOf(t, done); !bytes.Equal(res.data, []byte{0x22}) {
+		t.Errorf("got %x, %v", res.data, res.err)
+	}
+
+	c.spiLock.Lock()
+	defer c.spiLock.Unlock()
+	if len(c.spiPending) != 0 {
+		t.Errorf("%v requests left pending", len(c.spiPending))
+	}
+}
+
+func TestSPITxLengthMismatch(t *testing.T) {
+	c, rc := newOfflineClient()
+	dev := &SPIDevice{client: c, csPin: 9}
+	if err := dev.Tx(context.Background(), []byte{1, 2}, make([]byte, 1)); err == nil {
+		t.Error("short read buffer accepted")
+	}
+	if sent := rc.Sent(); len(sent) != 0 {
+		t.Errorf("sent %x for a rejected transfer", sent)
+	}
+}
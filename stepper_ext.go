@@ -0,0 +1,438 @@
+// Copyright 2014 Krishna Raman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+type StepperSubCommand byte
+type AccelStepperSubCommand byte
+type StepperInterface byte
+type StepperStepSize byte
+type StepperDirection byte
+type StepperEventType byte
+
+// Stepper notification delivered on the channel returned by GetStepperEvents()
+type StepperEvent struct {
+	Type StepperEventType
+	// Device number, or group number for MultiStepperMoveComplete
+	Device byte
+	// Current position in steps. Only reported by AccelStepper devices.
+	Position int32
+}
+
+func (e StepperEvent) String() string {
+	return fmt.Sprintf("%v: device %v at %v", e.Type, e.Device, e.Position)
+}
+
+// Wiring of an AccelStepper device
+type AccelStepperConfig struct {
+	Interface StepperInterface
+	StepSize  StepperStepSize
+	// Step and direction pins for StepperDriver, motor pins otherwise
+	Pins         []byte
+	HasEnablePin bool
+	EnablePin    byte
+	// Bit mask of pins to invert: bits 0-3 for Pins, bit 4 for EnablePin
+	InvertPins byte
+}
+
+// Pending stepper move. Completes when the board reports the move is done.
+type StepperMove struct {
+	done     chan struct{}
+	once     sync.Once
+	position int32
+}
+
+type stepperKey struct {
+	cmd    SysExCommand
+	group  bool
+	device byte
+}
+
+func newStepperMove() *StepperMove {
+	return &StepperMove{done: make(chan struct{})}
+}
+
+// Channel closed when the move completes
+func (m *StepperMove) Done() <-chan struct{} {
+	return m.done
+}
+
+// Position reported at the end of the move. Only valid after Done() is closed.
+func (m *StepperMove) Position() int32 {
+	return m.position
+}
+
+// Wait for the move to complete and return the final position
+func (m *StepperMove) Wait(ctx context.Context) (pos int32, err error) {
+	select {
+	case <-m.done:
+		pos = m.position
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	return
+}
+
+func (m *StepperMove) complete(pos int32) {
+	m.once.Do(func() {
+		m.position = pos
+		close(m.done)
+	})
+}
+
+// Get channel for stepper move notifications
+func (c *FirmataClient) GetStepperEvents() <-chan StepperEvent {
+	return c.stepperChan
+}
+
+// Configure a stepper using the legacy stepper protocol. Pins are the
+// direction and step pins for StepperDriver, or the motor pins otherwise.
+// The legacy protocol has no three wire interface; use AccelStepperConfig.
+func (c *FirmataClient) StepperConfig(device byte, iface StepperInterface, stepsPerRev int, pins ...byte) (err error) {
+	if iface == StepperThreeWire {
+		return fmt.Errorf("Stepper interface %v is only supported by AccelStepper", iface)
+	}
+	if err = checkStepperPins(iface, pins); err != nil {
+		return
+	}
+	steps := int14to7Bit(stepsPerRev)
+	data := []byte{byte(StepperConfigCmd), device, byte(iface), steps[0], steps[1]}
+	data = append(data, pins...)
+	err = c.sendSysEx(StepperData, data...)
+	return
+}
+
+// Move a legacy stepper by steps in dir. speed is in 0.01 rad/s. accel and
+// decel are in 0.01 rad/s^2 and are only sent when both are non-zero.
+func (c *FirmataClient) StepperStep(device byte, dir StepperDirection, steps int, speed int, accel int, decel int) (move *StepperMove, err error) {
+	stepBytes := intto7Bit(steps)
+	speedBytes := int14to7Bit(speed)
+	data := []byte{byte(StepperStepCmd), device, byte(dir),
+		stepBytes[0], stepBytes[1], stepBytes[2],
+		speedBytes[0], speedBytes[1]}
+	if accel != 0 && decel != 0 {
+		data = append(data, int14to7Bit(accel)...)
+		data = append(data, int14to7Bit(decel)...)
+	}
+
+	key := stepperKey{cmd: StepperData, device: device}
+	move = c.addStepperMove(key)
+	if err = c.sendSysEx(StepperData, data...); err != nil {
+		c.removeStepperMove(key, move)
+		move = nil
+	}
+	return
+}
+
+// Configure an AccelStepper device
+func (c *FirmataClient) AccelStepperConfig(device byte, cfg AccelStepperConfig) (err error) {
+	if err = checkStepperPins(cfg.Interface, cfg.Pins); err != nil {
+		return
+	}
+	iface := byte(cfg.Interface)<<4 | byte(cfg.StepSize)<<1
+	if cfg.HasEnablePin {
+		iface = iface | 0x01
+	}
+	data := []byte{byte(AccelStepperConfigCmd), device, iface}
+	data = append(data, cfg.Pins...)
+	if cfg.HasEnablePin {
+		data = append(data, cfg.EnablePin)
+	}
+	if cfg.InvertPins != 0 {
+		data = append(data, cfg.InvertPins&0x1F)
+	}
+	err = c.sendAccelStepper(data...)
+	return
+}
+
+// Set the current position of an AccelStepper device as zero
+func (c *FirmataClient) AccelStepperZero(device byte) error {
+	return c.sendAccelStepper(byte(AccelStepperZeroCmd), device)
+}
+
+// Move an AccelStepper device relative to its current position
+func (c *FirmataClient) AccelStepperStep(device byte, steps int32) (*StepperMove, error) {
+	return c.accelStepperMove(AccelStepperStepCmd, device, steps)
+}
+
+// Move an AccelStepper device to an absolute position
+func (c *FirmataClient) AccelStepperTo(device byte, position int32) (*StepperMove, error) {
+	return c.accelStepperMove(AccelStepperToCmd, device, position)
+}
+
+// Energize or release the motor outputs of an AccelStepper device
+func (c *FirmataClient) AccelStepperEnable(device byte, enabled bool) error {
+	val := byte(0x00)
+	if enabled {
+		val = 0x01
+	}
+	return c.sendAccelStepper(byte(AccelStepperEnableCmd), device, val)
+}
+
+// Stop an AccelStepper device. Pending moves complete with the position
+// where the device stopped.
+func (c *FirmataClient) AccelStepperStop(device byte) error {
+	return c.sendAccelStepper(byte(AccelStepperStopCmd), device)
+}
+
+// Set the maximum speed of an AccelStepper device in steps per second
+func (c *FirmataClient) AccelStepperSetSpeed(device byte, stepsPerSec float64) error {
+	data := append([]byte{byte(AccelStepperSetSpeedCmd), device}, floatTo7Bit(stepsPerSec)...)
+	return c.sendAccelStepper(data...)
+}
+
+// Set the acceleration of an AccelStepper device in steps per second squared
+func (c *FirmataClient) AccelStepperSetAcceleration(device byte, stepsPerSec2 float64) error {
+	data := append([]byte{byte(AccelStepperSetAccelerationCmd), device}, floatTo7Bit(stepsPerSec2)...)
+	return c.sendAccelStepper(data...)
+}
+
+// Ask an AccelStepper device for its current position
+func (c *FirmataClient) AccelStepperPosition(ctx context.Context, device byte) (pos int32, err error) {
+	defer c.observeQuery("stepper_position", time.Now(), &err)
+	reply := make(chan int32, 1)
+
+	c.stepperLock.Lock()
+	if c.stepperPositions == nil {
+		c.stepperPositions = make(map[byte][]chan int32)
+	}
+	c.stepperPositions[device] = append(c.stepperPositions[device], reply)
+	c.stepperLock.Unlock()
+
+	defer func() {
+		c.stepperLock.Lock()
+		defer c.stepperLock.Unlock()
+		waiters := c.stepperPositions[device]
+		for i, w := range waiters {
+			if w == reply {
+				c.stepperPositions[device] = append(waiters[:i:i], waiters[i+1:]...)
+				break
+			}
+		}
+	}()
+
+	if err = c.sendAccelStepper(byte(AccelStepperReportPositionCmd), device); err != nil {
+		return
+	}
+
+	select {
+	case pos = <-reply:
+	case <-ctx.Done():
+		err = fmt.Errorf("Stepper %v position query: %w", device, ctx.Err())
+	}
+	return
+}
+
+// Group AccelStepper devices so they can be moved together
+func (c *FirmataClient) MultiStepperConfig(group byte, devices ...byte) error {
+	data := append([]byte{byte(MultiStepperConfigCmd), group}, devices...)
+	return c.sendAccelStepper(data...)
+}
+
+// Move every device in group to its position so that all arrive at the same time
+func (c *FirmataClient) MultiStepperTo(group byte, positions ...int32) (move *StepperMove, err error) {
+	data := []byte{byte(MultiStepperToCmd), group}
+	for _, p := range positions {
+		data = append(data, int32To7Bit(p)...)
+	}
+
+	key := stepperKey{cmd: AccelStepperData, group: true, device: group}
+	move = c.addStepperMove(key)
+	if err = c.sendAccelStepper(data...); err != nil {
+		c.removeStepperMove(key, move)
+		move = nil
+	}
+	return
+}
+
+// Stop every device in group
+func (c *FirmataClient) MultiStepperStop(group byte) error {
+	return c.sendAccelStepper(byte(MultiStepperStopCmd), group)
+}
+
+func (c *FirmataClient) sendAccelStepper(data ...byte) error {
+	if err := c.requireFeature(FeatureAccelStepper); err != nil {
+		return err
+	}
+	return c.sendSysEx(AccelStepperData, data...)
+}
+
+func (c *FirmataClient) accelStepperMove(cmd AccelStepperSubCommand, device byte, steps int32) (move *StepperMove, err error) {
+	data := append([]byte{byte(cmd), device}, int32To7Bit(steps)...)
+
+	key := stepperKey{cmd: AccelStepperData, device: device}
+	move = c.addStepperMove(key)
+	if err = c.sendAccelStepper(data...); err != nil {
+		c.removeStepperMove(key, move)
+		move = nil
+	}
+	return
+}
+
+func checkStepperPins(iface StepperInterface, pins []byte) error {
+	want := 0
+	switch iface {
+	case StepperDriver, StepperTwoWire:
+		want = 2
+	case StepperThreeWire:
+		want = 3
+	case StepperFourWire:
+		want = 4
+	default:
+		return fmt.Errorf("Unsupported stepper interface %v", iface)
+	}
+	if len(pins) != want {
+		return fmt.Errorf("Stepper interface %v needs %v pins, got %v", iface, want, len(pins))
+	}
+	return nil
+}
+
+// The firmware reports a single completion per device, so a new move
+// supersedes earlier ones and all of them complete together.
+func (c *FirmataClient) addStepperMove(key stepperKey) *StepperMove {
+	move := newStepperMove()
+
+	c.stepperLock.Lock()
+	defer c.stepperLock.Unlock()
+	if c.stepperMoves == nil {
+		c.stepperMoves = make(map[stepperKey][]*StepperMove)
+	}
+	c.stepperMoves[key] = append(c.stepperMoves[key], move)
+	return move
+}
+
+// Forget a move whose request could not be sent. It is registered before
+// sending so a quick completion is not missed.
+func (c *FirmataClient) removeStepperMove(key stepperKey, move *StepperMove) {
+	c.stepperLock.Lock()
+	defer c.stepperLock.Unlock()
+	moves := c.stepperMoves[key]
+	for i, m := range moves {
+		if m == move {
+			moves = append(moves[:i:i], moves[i+1:]...)
+			break
+		}
+	}
+	if len(moves) == 0 {
+		delete(c.stepperMoves, key)
+	} else {
+		c.stepperMoves[key] = moves
+	}
+}
+
+func (c *FirmataClient) completeStepperMoves(key stepperKey, pos int32) {
+	c.stepperLock.Lock()
+	moves := c.stepperMoves[key]
+	delete(c.stepperMoves, key)
+	c.stepperLock.Unlock()
+
+	for _, m := range moves {
+		m.complete(pos)
+	}
+}
+
+func (c *FirmataClient) sendStepperEvent(ev StepperEvent) {
+	select {
+	case c.stepperChan <- ev:
+	default:
+		c.Log.Warn("Stepper event buffer overflow. No listener?", "device", ev.Device)
+		c.metrics.DroppedEvent("stepper")
+	}
+}
+
+func (c *FirmataClient) parseStepperResponse(data []byte) {
+	if len(data) < 1 {
+		c.Log.Warn("Discarding empty stepper reply")
+		c.metrics.ParseError(StepperData)
+		return
+	}
+	device := data[0]
+	c.completeStepperMoves(stepperKey{cmd: StepperData, device: device}, 0)
+	c.sendStepperEvent(StepperEvent{Type: StepperMoveComplete, Device: device})
+}
+
+func (c *FirmataClient) parseAccelStepperResponse(data []byte) {
+	if len(data) < 2 {
+		c.Log.Warn("Discarding short AccelStepper reply", "length", len(data))
+		c.metrics.ParseError(AccelStepperData)
+		return
+	}
+	cmd := AccelStepperSubCommand(data[0])
+	device := data[1]
+
+	switch cmd {
+	case AccelStepperReportPositionCmd, AccelStepperMoveCompleteReply:
+		if len(data) < 7 {
+			c.Log.Warn("Discarding short AccelStepper position reply", "length", len(data))
+			c.metrics.ParseError(AccelStepperData)
+			return
+		}
+		pos := int32From7Bit(data[2:7])
+
+		if cmd == AccelStepperMoveCompleteReply {
+			c.completeStepperMoves(stepperKey{cmd: AccelStepperData, device: device}, pos)
+			c.sendStepperEvent(StepperEvent{Type: StepperMoveComplete, Device: device, Position: pos})
+			return
+		}
+
+		c.stepperLock.Lock()
+		waiters := c.stepperPositions[device]
+		delete(c.stepperPositions, device)
+		c.stepperLock.Unlock()
+
+		for _, w := range waiters {
+			w <- pos
+		}
+		c.sendStepperEvent(StepperEvent{Type: StepperPosition, Device: device, Position: pos})
+	case MultiStepperMoveCompleteReply:
+		c.completeStepperMoves(stepperKey{cmd: AccelStepperData, group: true, device: device}, 0)
+		c.sendStepperEvent(StepperEvent{Type: MultiStepperMoveComplete, Device: device})
+	default:
+		c.Log.Warn("Discarding unexpected AccelStepper reply", "subcommand", byte(cmd), "device", device)
+		c.metrics.DiscardedBytes(len(data) + 3)
+	}
+}
+
+func (i StepperInterface) String() string {
+	switch i {
+	case StepperDriver:
+		return "DRIVER"
+	case StepperTwoWire:
+		return "TWO_WIRE"
+	case StepperThreeWire:
+		return "THREE_WIRE"
+	case StepperFourWire:
+		return "FOUR_WIRE"
+	}
+	return "UNKNOWN"
+}
+
+func (t StepperEventType) String() string {
+	switch t {
+	case StepperMoveComplete:
+		return "MoveComplete"
+	case StepperPosition:
+		return "Position"
+	case MultiStepperMoveComplete:
+		return "MultiStepperMoveComplete"
+	}
+	return "Unknown"
+}
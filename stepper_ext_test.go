@@ -0,0 +1,177 @@
+// Copyright 2014 Krishna Raman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"math"
+	"testing"
+)
+
+// Inverse of floatTo7Bit, as done by the AccelStepper firmware
+func floatFrom7Bit(b []byte) float64 {
+	significand := float64(uint32(b[0]) | uint32(b[1])<<7 | uint32(b[2])<<14 | uint32(b[3]&0x03)<<21)
+	exponent := int(b[3]>>2&0x0F) - 11
+	f := significand * math.Pow(10, float64(exponent))
+	if b[3]&0x40 != 0 {
+		f = -f
+	}
+	return f
+}
+
+func TestFloatTo7Bit(t *testing.T) {
+	tests := []struct {
+		in   float64
+		want []byte
+	}{
+		{0, []byte{0, 0, 0, 11 << 2}},
+		{1, []byte{1, 0, 0, 11 << 2}},
+		{100, []byte{1, 0, 0, 13 << 2}},
+		{-2.5, []byte{25, 0, 0, 10<<2 | 0x40}},
+	}
+	for _, tt := range tests {
+		if got := floatTo7Bit(tt.in); !bytes.Equal(got, tt.want) {
+			t.Errorf("floatTo7Bit(%v) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+
+	for _, f := range []float64{0.001, 0.5, 3.14159, 250, 1234.5, 40000, -800} {
+		got := floatFrom7Bit(floatTo7Bit(f))
+		if math.Abs(got-f) > math.Abs(f)*1e-6 {
+			t.Errorf("%v encoded as %v", f, got)
+		}
+		for _, b := range floatTo7Bit(f) {
+			if b > 0x7F {
+				t.Errorf("%v encoding has 8-bit byte %#x", f, b)
+			}
+		}
+	}
+}
+
+func TestInt32To7Bit(t *testing.T) {
+	if got, want := int32To7Bit(-1), []byte{1, 0, 0, 0, 0x08}; !bytes.Equal(got, want) {
+		t.Errorf("int32To7Bit(-1) = %v, want %v", got, want)
+	}
+	for _, i := range []int32{0, 1, 127, 128, -200, 1 << 24, math.MaxInt32, -math.MaxInt32} {
+		b := int32To7Bit(i)
+		if len(b) != 5 {
+			t.Fatalf("int32To7Bit(%v) has %v bytes", i, len(b))
+		}
+		if got := int32From7Bit(b); got != i {
+			t.Errorf("int32 %v round trips to %v", i, got)
+		}
+	}
+}
+
+func TestStepperConfigInterfaces(t *testing.T) {
+	c, _ := newOfflineClient()
+	if err := c.StepperConfig(0, StepperThreeWire, 200, 2, 3, 4); err == nil {
+		t.Error("legacy stepper accepted THREE_WIRE")
+	}
+	if err := c.StepperConfig(0, StepperFourWire, 200, 2, 3, 4); err == nil {
+		t.Error("FOUR_WIRE accepted 3 pins")
+	}
+	if err := c.StepperConfig(0, StepperFourWire, 200, 2, 3, 4, 5); err != nil {
+		t.Error(err)
+	}
+	if err := c.AccelStepperConfig(0, AccelStepperConfig{Interface: StepperThreeWire, Pins: []byte{2, 3, 4}}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestAccelStepperPositionCancel(t *testing.T) {
+	c, _ := newOfflineClient()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := c.AccelStepperPosition(ctx, 3); err == nil {
+		t.Fatal("position query on cancelled context succeeded")
+	}
+	if n := len(c.stepperPositions[3]); n != 0 {
+		t.Errorf("%v position waiters left after cancel", n)
+	}
+
+	// a reply completes a pending query
+	done := make(chan int32)
+	go func() {
+		pos, _ := c.AccelStepperPosition(context.Background(), 1)
+		done <- pos
+	}()
+	for {
+		c.stepperLock.Lock()
+		n := len(c.stepperPositions[1])
+		c.stepperLock.Unlock()
+		if n > 0 {
+			break
+		}
+	}
+	c.parseAccelStepperResponse(append([]byte{byte(AccelStepperReportPositionCmd), 1}, int32To7Bit(-42)...))
+	if pos := <-done; pos != -42 {
+		t.Errorf("position %v, want -42", pos)
+	}
+}
+
+// Transport whose writes always fail
+type failingConn struct {
+	recordingConn
+}
+
+func (c *failingConn) Write(p []byte) (int, error) {
+	return 0, errors.New("write failed")
+}
+
+func TestStepperMoveNotKeptOnError(t *testing.T) {
+	c, _ := newOfflineClient()
+	c.protocolVersion = []byte{2, 3}
+	if move, err := c.AccelStepperStep(1, 100); !errors.Is(err, ErrUnsupported) || move != nil {
+		t.Errorf("AccelStepper move on protocol 2.3 returned %v, %v", move, err)
+	}
+	if move, err := c.MultiStepperTo(0, 1, 2); !errors.Is(err, ErrUnsupported) || move != nil {
+		t.Errorf("MultiStepper move on protocol 2.3 returned %v, %v", move, err)
+	}
+
+	var conn io.ReadWriteCloser = &failingConn{}
+	c.conn = &conn
+	if move, err := c.StepperStep(2, StepperCW, 100, 50, 0, 0); err == nil || move != nil {
+		t.Errorf("stepper move with failing write returned %v, %v", move, err)
+	}
+	if n := len(c.stepperMoves); n != 0 {
+		t.Errorf("%v failed moves left registered", n)
+	}
+
+	// a move that was sent stays until it completes
+	c, _ = newOfflineClient()
+	move, err := c.AccelStepperTo(1, 500)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(c.stepperMoves); n != 1 {
+		t.Fatalf("%v moves registered", n)
+	}
+	c.parseAccelStepperResponse(append([]byte{byte(AccelStepperMoveCompleteReply), 1}, int32To7Bit(500)...))
+	if n := len(c.stepperMoves); n != 0 {
+		t.Errorf("%v moves left after completion", n)
+	}
+	select {
+	case <-move.Done():
+		if pos := move.Position(); pos != 500 {
+			t.Errorf("completed at %v", pos)
+		}
+	default:
+		t.Error("move not completed")
+	}
+}
@@ -23,46 +23,52 @@ func (c *FirmataClient) parseSysEx(data []byte) {
 	var cmd SysExCommand
 
 	cmd = SysExCommand(data[0])
+	c.metrics.SysExIn(cmd)
 
 	if c.Verbose {
-		c.Log.Printf("Processing sysex %v\n", cmd)
+		c.Log.Debug("Processing SysEx", "command", cmd)
 	}
 
 	data = data[1:]
 
-	bStr := ""
-	for _, b := range data {
-		bStr = bStr + fmt.Sprintf(" %#2x", b)
-	}
-	if c.Verbose {
-		c.Log.Printf("SysEx recv %v\n", bStr)
+	if c.tracing() {
+		c.Log.Debug("SysEx recv", "command", cmd, "bytes", hexBytes(data))
 	}
 
 	switch {
 	case cmd == StringData:
-		c.Log.Printf("String data: %v", string(data))
+		msg := multibyteString(data)
+		if !c.i2cError(msg) {
+			c.Log.Info("String data", "data", msg)
+		}
+	case cmd == I2CReply:
+		c.parseI2CReply(data)
 	case cmd == CapabilityResponse:
 		dataBuf := bytes.NewBuffer(data)
 		c.pinModes = make([]map[PinMode]interface{}, 0)
 
 		pin := 0
-		var err error
-		var modes []byte
-		for ; err == nil; modes, err = dataBuf.ReadBytes(127) {
-			pinModes := make(map[PinMode]interface{})
-			if len(modes) < 2 {
-				continue
+		for {
+			modes, err := dataBuf.ReadBytes(127)
+			if err != nil {
+				break
 			}
-
+			// pins without any supported mode still take a slot
+			pinModes := make(map[PinMode]interface{})
 			modes = modes[0 : len(modes)-1]
-			for i := 0; i < len(modes); i = i + 2 {
-				pinModes[PinMode(modes[i])] = modes[i+1]
+			for i := 0; i+1 < len(modes); i = i + 2 {
+				mode := PinMode(modes[i])
+				if c.Verbose && mode.String() == "UNKNOWN" {
+					c.Log.Debug("Pin reports unknown mode", "pin", pin, "mode", modes[i])
+				}
+				pinModes[mode] = modes[i+1]
 			}
 			c.pinModes = append(c.pinModes, pinModes)
 			pin = pin + 1
 		}
-		c.Log.Printf("Total pins: %v\n", pin-1)
+		c.Log.Info("Capabilities received", "pins", pin)
 		c.capabilityDone = true
+		c.checkHandshake()
 	case cmd == AnalogMappingResponse:
 		c.analogPinsChannelMap = make(map[int]byte)
 		c.analogChannelPinsMap = make(map[byte]int)
@@ -72,26 +78,89 @@ func (c *FirmataClient) parseSysEx(data []byte) {
 				c.analogChannelPinsMap[channel] = pin
 			}
 		}
-		c.Log.Printf("pin -> channel: %v\n", c.analogPinsChannelMap)
+		c.Log.Debug("Analog mapping received", "pins", c.analogPinsChannelMap)
 		c.analogMappingDone = true
+		c.checkHandshake()
 	case cmd == ReportFirmware:
-		c.firmwareVersion = make([]int, 2)
-		c.firmwareVersion[0] = int(data[0])
-		c.firmwareVersion[1] = int(data[1])
-		data = data[2:]
-		c.Log.Printf("in %v", data[2:])
-		c.firmwareName = multibyteString(data)
-		c.Log.Printf("Firmware: %v [%v.%v]", c.firmwareName, c.firmwareVersion[0], c.firmwareVersion[1])
-		c.ready = true
-		c.sendSysEx(AnalogMappingQuery)
-		c.sendSysEx(CapabilityQuery)
+		if len(data) < 2 {
+			c.Log.Warn("Discarding short firmware report", "length", len(data))
+			c.metrics.ParseError(cmd)
+			return
+		}
+		name := multibyteString(data[2:])
+		c.setFirmware(name, int(data[0]), int(data[1]))
+		c.Log.Info("Firmware", "name", name, "major", data[0], "minor", data[1])
+		// pin mappings are only needed once, on the initial handshake
+		if !c.ready {
+			c.ready = true
+			c.sendSysEx(AnalogMappingQuery)
+			c.sendSysEx(CapabilityQuery)
+		}
+		c.checkHandshake()
 	case cmd == Serial:
 		c.parseSerialResponse(data)
 	case cmd == SysExSPI:
 		c.parseSPIResponse(data)
+	case cmd == OneWireData:
+		c.parseOneWireResponse(data)
+	case cmd == StepperData:
+		c.parseStepperResponse(data)
+	case cmd == AccelStepperData:
+		c.parseAccelStepperResponse(data)
+	case cmd == EncoderData:
+		c.parseEncoderResponse(data)
+	case cmd == SchedulerData:
+		c.parseSchedulerResponse(data)
 	default:
-		c.Log.Printf("Discarding unexpected SysEx command %v", cmd)
+		c.sysExHandlersLock.Lock()
+		handler := c.sysExHandlers[cmd]
+		c.sysExHandlersLock.Unlock()
+		if handler != nil {
+			handler(cmd, data)
+			return
+		}
+		c.Log.Warn("Discarding unexpected SysEx command", "command", cmd)
+		c.metrics.DiscardedBytes(len(data) + 3)
+	}
+}
+
+// Handler for a user-defined SysEx command. data holds the message payload
+// without the command byte. Handlers run on the reply reader goroutine and
+// must not block.
+type SysExHandler func(cmd SysExCommand, data []byte)
+
+// Register a handler for replies to a user-defined SysEx command
+// (0x00-0x0F). A nil handler removes the registration.
+func (c *FirmataClient) RegisterSysExHandler(cmd SysExCommand, handler SysExHandler) error {
+	if cmd < UserSysExFirst || cmd > UserSysExLast {
+		return fmt.Errorf("SysEx command %v is not in the user-defined range", cmd)
+	}
+
+	c.sysExHandlersLock.Lock()
+	defer c.sysExHandlersLock.Unlock()
+	if handler == nil {
+		delete(c.sysExHandlers, cmd)
+		return nil
+	}
+	if c.sysExHandlers == nil {
+		c.sysExHandlers = make(map[SysExCommand]SysExHandler)
+	}
+	c.sysExHandlers[cmd] = handler
+	return nil
+}
+
+// Send a user-defined SysEx command (0x00-0x0F). Every payload byte must be
+// 7-bit; use Encode7Bit or Pack7Bit to encode 8-bit data.
+func (c *FirmataClient) SendSysEx(cmd SysExCommand, payload ...byte) error {
+	if cmd < UserSysExFirst || cmd > UserSysExLast {
+		return fmt.Errorf("SysEx command %v is not in the user-defined range", cmd)
+	}
+	for i, b := range payload {
+		if b > 0x7F {
+			return fmt.Errorf("SysEx payload byte %v (%#x) is not 7-bit", i, b)
+		}
 	}
+	return c.sendSysEx(cmd, payload...)
 }
 
 func (c *FirmataClient) sendSysEx(cmd SysExCommand, data ...byte) (err error) {
@@ -102,12 +171,14 @@ func (c *FirmataClient) sendSysEx(cmd SysExCommand, data ...byte) (err error) {
 	b.Write(data)
 	b.WriteByte(byte(EndSysEx))
 
-	bStr := ""
-	for _, b := range b.Bytes() {
-		bStr = bStr + fmt.Sprintf(" %#2x", b)
+	if c.tracing() {
+		c.Log.Debug("SysEx send", "command", cmd, "bytes", hexBytes(b.Bytes()))
 	}
-	c.Log.Printf("SysEx send %v\n", bStr)
 
-	_, err = b.WriteTo(*(c.conn))
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+	n, err := b.WriteTo(*(c.conn))
+	c.metrics.BytesOut(int(n))
+	c.metrics.SysExOut(cmd)
 	return
 }
@@ -14,6 +14,10 @@
 
 package firmata
 
+import (
+	"math"
+)
+
 func from7Bit(b0 byte, b1 byte) byte {
 	return (b0 & 0x7F) | ((b1 & 0x7F) << 7)
 }
@@ -22,6 +26,10 @@ func to7Bit(i byte) []byte {
 	return []byte{i & 0x7f, (i >> 7) & 0x7f}
 }
 
+func int14to7Bit(i int) []byte {
+	return []byte{byte(i & 0x7f), byte((i >> 7) & 0x7f)}
+}
+
 func intto7Bit(i int) []byte {
 	return []byte{byte(i & 0x7f), byte((i >> 7) & 0x7f), byte((i >> 14) & 0x7f)}
 }
@@ -38,3 +46,126 @@ func multibyteString(data []byte) (str string) {
 
 	return
 }
+
+// Encode each 8-bit byte of data as a LSB, MSB pair of 7-bit bytes
+func Encode7Bit(data []byte) (out []byte) {
+	for _, b := range data {
+		out = append(out, to7Bit(b)...)
+	}
+	return
+}
+
+// Decode LSB, MSB pairs of 7-bit bytes produced by Encode7Bit. A trailing
+// odd byte is ignored.
+func Decode7Bit(data []byte) (out []byte) {
+	for i := 0; i+1 < len(data); i = i + 2 {
+		out = append(out, from7Bit(data[i], data[i+1]))
+	}
+	return
+}
+
+// Pack 8-bit data into a stream of 7-bit bytes as done by Firmata's Encoder7Bit
+func Pack7Bit(data []byte) (out []byte) {
+	shift := uint(0)
+	previous := byte(0)
+	for _, b := range data {
+		if shift == 0 {
+			out = append(out, b&0x7F)
+			shift++
+			previous = b >> 7
+		} else {
+			out = append(out, ((b<<shift)&0x7F)|previous)
+			if shift == 6 {
+				out = append(out, b>>1)
+				shift = 0
+			} else {
+				shift++
+				previous = b >> (8 - shift)
+			}
+		}
+	}
+	if shift > 0 {
+		out = append(out, previous)
+	}
+	return
+}
+
+// Unpack a stream of 7-bit bytes produced by Firmata's Encoder7Bit
+func Unpack7Bit(data []byte) (out []byte) {
+	count := len(data) * 7 / 8
+	out = make([]byte, count)
+	for i := 0; i < count; i++ {
+		j := i * 8
+		pos := j / 7
+		shift := uint(j % 7)
+		out[i] = (data[pos] >> shift) | (data[pos+1] << (7 - shift))
+	}
+	return
+}
+
+// Encode a signed 32-bit integer as 5 7-bit bytes, sign in bit 3 of the last byte
+func int32To7Bit(i int32) []byte {
+	v := uint32(i)
+	if i < 0 {
+		v = uint32(-int64(i))
+	}
+	b := []byte{byte(v & 0x7F), byte((v >> 7) & 0x7F), byte((v >> 14) & 0x7F), byte((v >> 21) & 0x7F), byte((v >> 28) & 0x07)}
+	if i < 0 {
+		b[4] = b[4] | 0x08
+	}
+	return b
+}
+
+func int32From7Bit(b []byte) int32 {
+	v := int64(b[0]&0x7F) | int64(b[1]&0x7F)<<7 | int64(b[2]&0x7F)<<14 | int64(b[3]&0x7F)<<21 | int64(b[4]&0x07)<<28
+	if b[4]&0x08 != 0 {
+		v = -v
+	}
+	return int32(v)
+}
+
+// Encode a float as 4 7-bit bytes: a 23-bit significand, a 4-bit base 10
+// exponent offset by -11 and a sign bit
+func floatTo7Bit(f float64) []byte {
+	const maxSignificand = 1 << 23
+
+	sign := byte(0)
+	if f < 0 {
+		sign = 1
+		f = -f
+	}
+
+	exponent := 0
+	if f != 0 {
+		exponent = int(math.Floor(math.Log10(f)))
+		f = f / math.Pow(10, float64(exponent))
+		for math.Abs(f-math.Round(f)) > 1e-9*f && f*10 < maxSignificand && exponent > -11 {
+			exponent--
+			f = f * 10
+		}
+		for f >= maxSignificand || exponent < -11 {
+			exponent++
+			f = f / 10
+		}
+		for exponent > 4 && f*10 < maxSignificand {
+			exponent--
+			f = f * 10
+		}
+	}
+	if exponent > 4 {
+		exponent = 4
+		f = maxSignificand - 1
+	}
+
+	significand := uint32(math.Round(f))
+	if significand >= maxSignificand {
+		significand = maxSignificand - 1
+	}
+	e := byte(exponent + 11)
+	return []byte{
+		byte(significand & 0x7F),
+		byte((significand >> 7) & 0x7F),
+		byte((significand >> 14) & 0x7F),
+		byte((significand>>21)&0x03) | (e&0x0F)<<2 | sign<<6,
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright 2014 Krishna Raman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPack7Bit(t *testing.T) {
+	tests := []struct {
+		in   []byte
+		want []byte
+	}{
+		{[]byte{}, nil},
+		{[]byte{0xFF}, []byte{0x7F, 0x01}},
+		{[]byte{0x80, 0x01}, []byte{0x00, 0x03, 0x00}},
+		{[]byte{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}, []byte{0x7F, 0x7F, 0x7F, 0x7F, 0x7F, 0x7F, 0x7F, 0x7F}},
+	}
+	for _, tt := range tests {
+		got := Pack7Bit(tt.in)
+		if !bytes.Equal(got, tt.want) {
+			t.Errorf("Pack7Bit(%#v) = %#v, want %#v", tt.in, got, tt.want)
+		}
+		if len(got) != (len(tt.in)*8+6)/7 {
+			t.Errorf("Pack7Bit(%#v) has %v bytes", tt.in, len(got))
+		}
+	}
+}
+
+func TestPack7BitRoundTrip(t *testing.T) {
+	for n := 0; n < 20; n++ {
+		data := make([]byte, n)
+		for i := range data {
+			data[i] = byte(i*37 + 0x85)
+		}
+		packed := Pack7Bit(data)
+		for _, b := range packed {
+			if b > 0x7F {
+				t.Fatalf("Pack7Bit of %v bytes produced 8-bit byte %#x", n, b)
+			}
+		}
+		if got := Unpack7Bit(packed); !bytes.Equal(got, data) {
+			t.Errorf("%v bytes round trip to %#v, want %#v", n, got, data)
+		}
+	}
+}
+
+func TestEncode7Bit(t *testing.T) {
+	data := []byte{0x00, 0x7F, 0x80, 0xFF}
+	encoded := Encode7Bit(data)
+	if want := []byte{0x00, 0x00, 0x7F, 0x00, 0x00, 0x01, 0x7F, 0x01}; !bytes.Equal(encoded, want) {
+		t.Errorf("Encode7Bit = %#v, want %#v", encoded, want)
+	}
+	if got := Decode7Bit(append(encoded, 0x05)); !bytes.Equal(got, data) {
+		t.Errorf("Decode7Bit = %#v, want %#v", got, data)
+	}
+}
@@ -0,0 +1,316 @@
+// Copyright 2014 Krishna Raman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// In-process virtual Firmata board for testing code built on FirmataClient
+// without hardware.
+//
+// Usage:
+//
+//	board := virtual.New(virtual.ArduinoUno())
+//	defer board.Close()
+//	client, err := firmata.NewClientWithTransport(board.Transport())
+//	board.SetAnalogInput(14, 512)
+package virtual
+
+import (
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/kraman/go-firmata"
+	"github.com/kraman/go-firmata/server"
+)
+
+// Simulated I2C device
+type I2CDevice interface {
+	// Data written to the device
+	Write(data []byte)
+	// Read count bytes starting at register, or at the current position if
+	// register is firmata.I2CRegisterNotSpecified
+	Read(register int, count int) []byte
+}
+
+// Handles a SPI transfer to the device on csPin and returns the bytes
+// clocked back
+type SPIHandler func(csPin byte, data []byte) []byte
+
+// A simulated board. The device side of the protocol is handled by a
+// server.Server; the board is its pin backend.
+type Board struct {
+	profile Profile
+	client  *pipeEnd
+	srv     *server.Server
+
+	lock       sync.Mutex
+	outputs    []int
+	inputs     []int
+	i2cDevices map[int]I2CDevice
+	spiHandler SPIHandler
+	spiModes   map[byte]byte
+	serialOut  map[firmata.SerialPort][]byte
+}
+
+// Create and start a virtual board. The board announces its protocol
+// version and firmware on start, like a freshly reset Arduino.
+func New(profile Profile) *Board {
+	client, conn := newPipe()
+	b := &Board{
+		profile:    profile,
+		client:     client,
+		outputs:    make([]int, len(profile.Pins)),
+		inputs:     make([]int, len(profile.Pins)),
+		i2cDevices: make(map[int]I2CDevice),
+		spiHandler: func(csPin byte, data []byte) []byte { return data },
+		spiModes:   make(map[byte]byte),
+		serialOut:  make(map[firmata.SerialPort][]byte),
+	}
+	b.srv = server.New(conn, b,
+		server.WithFirmware(profile.FirmwareName, profile.FirmwareMajor, profile.FirmwareMinor),
+		server.WithProtocolVersion(profile.ProtocolMajor, profile.ProtocolMinor))
+	b.srv.AddFeature(server.NewI2CFeature(i2cBus{b}))
+	b.srv.AddFeature(server.NewSPIFeature(spiBus{b}))
+	b.srv.AddFeature(serialFeature{b})
+	go b.srv.Serve()
+	return b
+}
+
+// Client end of the link, to be passed to firmata.NewClientWithTransport
+func (b *Board) Transport() io.ReadWriteCloser {
+	return b.client
+}
+
+// Stop the board and close the link
+func (b *Board) Close() error {
+	return b.srv.Close()
+}
+
+// Set the level seen by a digital input pin. Reported to the client if
+// reporting is enabled for the pin's port.
+func (b *Board) SetDigitalInput(pin int, val bool) error {
+	b.lock.Lock()
+	if pin < 0 || pin >= len(b.inputs) {
+		b.lock.Unlock()
+		return fmt.Errorf("Invalid pin number %v", pin)
+	}
+	b.inputs[pin] = 0
+	if val {
+		b.inputs[pin] = 1
+	}
+	b.lock.Unlock()
+
+	b.srv.DigitalInputChanged(pin)
+	return nil
+}
+
+// Set the raw value read by an analog input pin. Reported to the client on
+// the next sampling interval if reporting is enabled for the pin's channel.
+func (b *Board) SetAnalogInput(pin int, val int) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if pin < 0 || pin >= len(b.inputs) || b.profile.Pins[pin].AnalogChannel < 0 {
+		return fmt.Errorf("Pin %v has no analog channel", pin)
+	}
+	b.inputs[pin] = val
+	return nil
+}
+
+// Current mode of a pin
+func (b *Board) PinMode(pin int) firmata.PinMode {
+	return b.srv.PinMode(pin)
+}
+
+// Level last written by the client to a digital output pin
+func (b *Board) DigitalOutput(pin int) bool {
+	return b.AnalogOutput(pin) != 0
+}
+
+// Value last written by the client to a pin (PWM duty, servo angle, digital level)
+func (b *Board) AnalogOutput(pin int) int {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if pin < 0 || pin >= len(b.outputs) {
+		return 0
+	}
+	return b.outputs[pin]
+}
+
+// Attach a simulated I2C device at addr
+func (b *Board) AttachI2CDevice(addr int, dev I2CDevice) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.i2cDevices[addr] = dev
+}
+
+// Replace the SPI handler. The default handler loops written bytes back.
+func (b *Board) SetSPIHandler(h SPIHandler) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.spiHandler = h
+}
+
+// Mode byte the client last configured for the SPI device on csPin
+func (b *Board) SPIMode(csPin byte) (mode byte, ok bool) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	mode, ok = b.spiModes[csPin]
+	return
+}
+
+// Deliver data to the client as if received on a serial port of the board
+func (b *Board) SerialInput(port firmata.SerialPort, data []byte) {
+	msg := []byte{byte(firmata.SerialComm) | byte(port)}
+	b.srv.SendSysEx(firmata.Serial, append(msg, firmata.Encode7Bit(data)...)...)
+}
+
+// Get and clear the data the client sent to a serial port of the board
+func (b *Board) SerialOutput(port firmata.SerialPort) []byte {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	data := b.serialOut[port]
+	delete(b.serialOut, port)
+	return data
+}
+
+// Send a string message to the client
+func (b *Board) SendString(s string) {
+	b.srv.SendString(s)
+}
+
+func (b *Board) Pins() []server.Pin {
+	return b.profile.Pins
+}
+
+func (b *Board) SetPinMode(pin int, mode firmata.PinMode) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.outputs[pin] = 0
+	if mode == firmata.InputPullup {
+		b.inputs[pin] = 1
+	}
+	return nil
+}
+
+func (b *Board) DigitalWrite(pin int, val bool) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.outputs[pin] = 0
+	if val {
+		b.outputs[pin] = 1
+	}
+	return nil
+}
+
+func (b *Board) DigitalRead(pin int) (bool, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.inputs[pin] != 0, nil
+}
+
+func (b *Board) AnalogWrite(pin int, val int) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.outputs[pin] = val
+	return nil
+}
+
+func (b *Board) AnalogRead(pin int) (int, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.inputs[pin], nil
+}
+
+// Clear data written to serial ports and SPI modes on a system reset
+func (b *Board) Reset() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.serialOut = make(map[firmata.SerialPort][]byte)
+	b.spiModes = make(map[byte]byte)
+}
+
+// Routes I2C requests to the attached devices
+type i2cBus struct {
+	b *Board
+}
+
+func (bus i2cBus) device(addr int) (dev I2CDevice, err error) {
+	bus.b.lock.Lock()
+	defer bus.b.lock.Unlock()
+	dev = bus.b.i2cDevices[addr]
+	if dev == nil {
+		err = fmt.Errorf("No device at address %#x", addr)
+	}
+	return
+}
+
+func (bus i2cBus) Write(addr int, data []byte) error {
+	dev, err := bus.device(addr)
+	if err != nil {
+		return err
+	}
+	dev.Write(data)
+	return nil
+}
+
+func (bus i2cBus) Read(addr int, register int, count int) ([]byte, error) {
+	dev, err := bus.device(addr)
+	if err != nil {
+		return nil, err
+	}
+	return dev.Read(register, count), nil
+}
+
+// Routes SPI transfers to the board's SPI handler
+type spiBus struct {
+	b *Board
+}
+
+func (bus spiBus) Configure(csPin int, mode byte) error {
+	bus.b.lock.Lock()
+	defer bus.b.lock.Unlock()
+	bus.b.spiModes[byte(csPin)] = mode
+	return nil
+}
+
+func (bus spiBus) Transfer(csPin int, data []byte) ([]byte, error) {
+	bus.b.lock.Lock()
+	h := bus.b.spiHandler
+	bus.b.lock.Unlock()
+	return h(byte(csPin), data), nil
+}
+
+// Collects data the client writes to the board's serial ports
+type serialFeature struct {
+	b *Board
+}
+
+func (f serialFeature) SysExCommands() []firmata.SysExCommand {
+	return []firmata.SysExCommand{firmata.Serial}
+}
+
+func (f serialFeature) HandleSysEx(s *server.Server, cmd firmata.SysExCommand, data []byte) error {
+	if len(data) < 1 {
+		return nil
+	}
+	port := firmata.SerialPort(data[0] & 0x0F)
+
+	f.b.lock.Lock()
+	defer f.b.lock.Unlock()
+	switch firmata.SerialSubCommand(data[0] & 0xF0) {
+	case firmata.SerialComm:
+		f.b.serialOut[port] = append(f.b.serialOut[port], firmata.Decode7Bit(data[1:])...)
+	case firmata.SerialClose:
+		delete(f.b.serialOut, port)
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+// Copyright 2014 Krishna Raman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package virtual
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// One direction of an in-memory link. Writes never block, like a serial
+// port with a large buffer, so a slow reader cannot deadlock the writer.
+type bufferedPipe struct {
+	lock   sync.Mutex
+	cond   *sync.Cond
+	buf    bytes.Buffer
+	closed bool
+}
+
+func newBufferedPipe() *bufferedPipe {
+	p := &bufferedPipe{}
+	p.cond = sync.NewCond(&p.lock)
+	return p
+}
+
+func (p *bufferedPipe) Read(b []byte) (int, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	for p.buf.Len() == 0 && !p.closed {
+		p.cond.Wait()
+	}
+	if p.buf.Len() == 0 {
+		return 0, io.EOF
+	}
+	return p.buf.Read(b)
+}
+
+func (p *bufferedPipe) Write(b []byte) (int, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.closed {
+		return 0, io.ErrClosedPipe
+	}
+	p.cond.Broadcast()
+	return p.buf.Write(b)
+}
+
+func (p *bufferedPipe) Close() error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.closed = true
+	p.cond.Broadcast()
+	return nil
+}
+
+// One end of a full duplex in-memory link
+type pipeEnd struct {
+	r *bufferedPipe
+	w *bufferedPipe
+}
+
+func newPipe() (a *pipeEnd, b *pipeEnd) {
+	ab, ba := newBufferedPipe(), newBufferedPipe()
+	return &pipeEnd{r: ba, w: ab}, &pipeEnd{r: ab, w: ba}
+}
+
+func (e *pipeEnd) Read(b []byte) (int, error) {
+	return e.r.Read(b)
+}
+
+func (e *pipeEnd) Write(b []byte) (int, error) {
+	return e.w.Write(b)
+}
+
+// Closing either end closes the link in both directions
+func (e *pipeEnd) Close() error {
+	e.r.Close()
+	return e.w.Close()
+}
@@ -0,0 +1,67 @@
+// Copyright 2014 Krishna Raman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package virtual
+
+import (
+	"github.com/kraman/go-firmata"
+	"github.com/kraman/go-firmata/server"
+)
+
+// Capabilities of a single pin
+type Pin = server.Pin
+
+// Description of the board to simulate
+type Profile struct {
+	FirmwareName  string
+	FirmwareMajor byte
+	FirmwareMinor byte
+	ProtocolMajor byte
+	ProtocolMinor byte
+	Pins          []Pin
+}
+
+// Profile of an Arduino Uno running StandardFirmata 2.5
+func ArduinoUno() Profile {
+	p := Profile{
+		FirmwareName:  "StandardFirmata.ino",
+		FirmwareMajor: 2,
+		FirmwareMinor: 5,
+		ProtocolMajor: 2,
+		ProtocolMinor: 5,
+	}
+	for pin := 0; pin < 20; pin++ {
+		modes := map[firmata.PinMode]byte{}
+		if pin > 1 {
+			modes[firmata.Input] = 1
+			modes[firmata.Output] = 1
+			modes[firmata.InputPullup] = 1
+			modes[firmata.Servo] = 14
+		}
+		switch pin {
+		case 3, 5, 6, 9, 10, 11:
+			modes[firmata.PWM] = 8
+		}
+		channel := -1
+		if pin >= 14 {
+			channel = pin - 14
+			modes[firmata.Analog] = 10
+		}
+		if pin == 18 || pin == 19 {
+			modes[firmata.I2C] = 1
+		}
+		p.Pins = append(p.Pins, Pin{Modes: modes, AnalogChannel: channel})
+	}
+	return p
+}
@@ -0,0 +1,341 @@
+// Copyright 2014 Krishna Raman
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firmata_test
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kraman/go-firmata"
+	"github.com/kraman/go-firmata/virtual"
+)
+
+func newVirtualClient(t *testing.T) (*firmata.FirmataClient, *virtual.Board) {
+	t.Helper()
+	board := virtual.New(virtual.ArduinoUno())
+	client, err := firmata.NewClientWithTransport(board.Transport())
+	if err != nil {
+		board.Close()
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		client.Close()
+		board.Close()
+	})
+	return client, board
+}
+
+// Wait until cond holds or fail the test after a second
+func eventually(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %v", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// Simulated I2C device with 8-bit registers. A write sets the register
+// pointer from its first byte and stores the rest.
+type registerDevice struct {
+	lock      sync.Mutex
+	registers [256]byte
+	pointer   int
+}
+
+func (d *registerDevice) Write(data []byte) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if len(data) == 0 {
+		return
+	}
+	d.pointer = int(data[0])
+	for _, b := range data[1:] {
+		d.registers[d.pointer&0xFF] = b
+		d.pointer++
+	}
+}
+
+func (d *registerDevice) Read(register int, count int) []byte {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if register != firmata.I2CRegisterNotSpecified {
+		d.pointer = register
+	}
+	out := make([]byte, count)
+	for i := range out {
+		out[i] = d.registers[d.pointer&0xFF]
+		d.pointer++
+	}
+	return out
+}
+
+func TestVirtualHandshake(t *testing.T) {
+	client, _ := newVirtualClient(t)
+
+	info := client.BoardInfo()
+	if info.FirmwareName != "StandardFirmata.ino" || info.FirmwareMajor != 2 || info.FirmwareMinor != 5 {
+		t.Errorf("firmware %v", info)
+	}
+	if info.ProtocolMajor != 2 || info.ProtocolMinor != 5 {
+		t.Errorf("protocol %v.%v", info.ProtocolMajor, info.ProtocolMinor)
+	}
+	if info.Pins != 20 || info.AnalogChannels != 6 {
+		t.Errorf("%v pins, %v analog channels", info.Pins, info.AnalogChannels)
+	}
+	if !client.SupportsPinMode(3, firmata.PWM) || client.SupportsPinMode(4, firmata.PWM) {
+		t.Error("PWM capabilities do not match the profile")
+	}
+	if ch, ok := client.AnalogChannel(16); !ok || ch != 2 {
+		t.Errorf("pin 16 maps to channel %v, %v", ch, ok)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	major, minor, err := client.QueryVersion(ctx)
+	if err != nil || major != 2 || minor != 5 {
+		t.Errorf("version query returned %v.%v, %v", major, minor, err)
+	}
+}
+
+func TestVirtualWrites(t *testing.T) {
+	client, board := newVirtualClient(t)
+
+	if err := client.SetPinMode(13, firmata.Output); err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, "output mode", func() bool { return board.PinMode(13) == firmata.Output })
+	if err := client.DigitalWrite(13, true); err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, "pin 13 high", func() bool { return board.DigitalOutput(13) })
+	if err := client.DigitalWrite(13, false); err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, "pin 13 low", func() bool { return !board.DigitalOutput(13) })
+
+	client.SetPinMode(10, firmata.Output)
+	client.SetPinMode(12, firmata.Output)
+	if err := client.DigitalWritePort(1, 0x14, 0x14); err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, "pins 10 and 12 high", func() bool { return board.DigitalOutput(10) && board.DigitalOutput(12) })
+
+	if err := client.SetPinMode(9, firmata.PWM); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.AnalogWrite(9, 128); err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, "PWM duty 128", func() bool { return board.AnalogOutput(9) == 128 })
+}
+
+func TestVirtualReporting(t *testing.T) {
+	client, board := newVirtualClient(t)
+
+	client.SetAnalogSamplingInterval(10)
+	client.SetPinMode(15, firmata.Analog)
+	board.SetAnalogInput(15, 700)
+	if err := client.EnableAnalogInput(15, true); err != nil {
+		t.Fatal(err)
+	}
+
+	client.SetPinMode(4, firmata.Input)
+	if err := client.EnableDigitalInput(4, true); err != nil {
+		t.Fatal(err)
+	}
+	board.SetDigitalInput(4, true)
+
+	var analog, digital bool
+	timeout := time.After(time.Second)
+	for !analog || !digital {
+		select {
+		case v := <-client.GetValues():
+			if v.IsAnalog() {
+				pin, val, _ := v.GetAnalogValue()
+				analog = analog || (pin == 15 && val == 700)
+			} else {
+				_, vals, _ := v.GetDigitalValue()
+				digital = digital || vals[4] == true
+			}
+		case <-timeout:
+			t.Fatalf("reports missing: analog %v, digital %v", analog, digital)
+		}
+	}
+
+	if v, err := client.DigitalReadPort(0); err != nil || v&0x10 == 0 {
+		t.Errorf("port 0 reads %#x, %v", v, err)
+	}
+}
+
+func TestVirtualI2C(t *testing.T) {
+	client, board := newVirtualClient(t)
+	dev := &registerDevice{}
+	board.AttachI2CDevice(0x48, dev)
+
+	if err := client.I2CConfig(0); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.I2CWrite(0x48, []byte{0x10, 0xAB, 0xCD}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	data, err := client.I2CRead(ctx, 0x48, 0x10, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, []byte{0xAB, 0xCD}) {
+		t.Errorf("read %#v", data)
+	}
+
+	if _, err := client.I2CRead(ctx, 0x50, 0x00, 1); err == nil {
+		t.Error("read from missing device succeeded")
+	}
+}
+
+func TestVirtualSPI(t *testing.T) {
+	client, board := newVirtualClient(t)
+
+	dev, err := client.NewSPIDevice(10, firmata.SPI_MODE0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	r := make([]byte, 3)
+	if err = dev.Tx(ctx, []byte{0x01, 0x80, 0xFF}, r); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(r, []byte{0x01, 0x80, 0xFF}) {
+		t.Errorf("loopback read %#v", r)
+	}
+
+	board.SetSPIHandler(func(csPin byte, data []byte) []byte {
+		out := make([]byte, len(data))
+		for i, b := range data {
+			out[i] = ^b
+		}
+		return out
+	})
+	if err = dev.Tx(ctx, []byte{0x0F, 0xF0}, r[:2]); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(r[:2], []byte{0xF0, 0x0F}) {
+		t.Errorf("inverted read %#v", r[:2])
+	}
+}
+
+func TestVirtualSerial(t *testing.T) {
+	client, board := newVirtualClient(t)
+
+	if err := client.SerialConfig(firmata.HardSerial1, 9600, 0, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.SerialWrite(firmata.HardSerial1, []byte("ping")); err != nil {
+		t.Fatal(err)
+	}
+	var out []byte
+	eventually(t, "serial output", func() bool {
+		out = append(out, board.SerialOutput(firmata.HardSerial1)...)
+		return len(out) >= 4
+	})
+	if string(out) != "ping" {
+		t.Errorf("board received %q", out)
+	}
+
+	board.SerialInput(firmata.HardSerial2, []byte("pong"))
+	select {
+	case ev := <-client.GetSerialEvents():
+		if ev.Port != firmata.HardSerial2 || string(ev.Data) != "pong" {
+			t.Errorf("got %v", ev)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no serial event")
+	}
+}
+
+func TestVirtualSerialData(t *testing.T) {
+	client, board := newVirtualClient(t)
+	data := client.GetSerialData()
+	if data == nil {
+		t.Fatal("no serial data channel")
+	}
+
+	board.SerialInput(firmata.SoftSerial, []byte("hello"))
+	select {
+	case d := <-data:
+		if d != "hello" {
+			t.Errorf("got %q", d)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no serial data")
+	}
+
+	client.Close()
+	board.Close()
+	select {
+	case _, ok := <-data:
+		if ok {
+			t.Error("unexpected data after close")
+		}
+	case <-time.After(time.Second):
+		t.Error("serial data channel not closed with the client")
+	}
+}
+
+func TestVirtualSPIConcurrentDevices(t *testing.T) {
+	client, board := newVirtualClient(t)
+	board.SetSPIHandler(func(csPin byte, data []byte) []byte {
+		out := make([]byte, len(data))
+		for i, b := range data {
+			out[i] = b + csPin
+		}
+		return out
+	})
+
+	var wg sync.WaitGroup
+	for _, cs := range []byte{9, 10} {
+		dev, err := client.NewSPIDevice(cs, firmata.SPI_MODE0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		wg.Add(1)
+		go func(dev *firmata.SPIDevice) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			r := make([]byte, 2)
+			for i := byte(0); i < 20; i++ {
+				if err := dev.Tx(ctx, []byte{i, 100}, r); err != nil {
+					t.Error(err)
+					return
+				}
+				if r[0] != i+dev.CSPin() || r[1] != 100+dev.CSPin() {
+					t.Errorf("%v got %v", dev, r)
+					return
+				}
+			}
+		}(dev)
+	}
+	wg.Wait()
+}